@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/wmolicki/go-monkey/astjson"
+	"github.com/wmolicki/go-monkey/optimizer"
+)
+
+// runAst implements `monke ast [--json] (-e expr | file.monke)`.
+func runAst(args []string) int {
+	fs := flag.NewFlagSet("ast", flag.ExitOnError)
+	expr := fs.String("e", "", "print the tree of expr instead of a file")
+	asJSON := fs.Bool("json", false, "print the tree as JSON")
+	optimize := fs.Bool("O", false, "print the tree after optimization")
+	passes := fs.String("passes", "", "comma separated optimizer passes used with -O, all by default")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: monke ast [--json] [-O [-passes list]] (-e expr | file.monke)\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	_, src, err := programSource(*expr, fs.Args())
+	if err == errUsage {
+		fs.Usage()
+		return exitUsage
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	program, ok := parse(src)
+	if !ok {
+		return exitError
+	}
+
+	if *optimize {
+		passes, err := optimizerPasses(*passes)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+		optimizer.Optimize(program, passes)
+	}
+
+	if !*asJSON {
+		fmt.Println(program.String())
+		return exitOK
+	}
+
+	encoded, err := astjson.Marshal(program)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error encoding tree: %v\n", err)
+		return exitError
+	}
+	var out bytes.Buffer
+	json.Indent(&out, encoded, "", "  ")
+	out.WriteString("\n")
+	out.WriteTo(os.Stdout)
+
+	return exitOK
+}
@@ -2,6 +2,7 @@ package ast
 
 import (
 	"bytes"
+	"sort"
 	"strings"
 
 	"github.com/wmolicki/go-monkey/token"
@@ -10,6 +11,8 @@ import (
 type Node interface {
 	TokenLiteral() string
 	String() string
+	// Pos returns the position of the token the node was parsed from.
+	Pos() token.Position
 }
 
 type Statement interface {
@@ -44,6 +47,13 @@ func (p *Program) TokenLiteral() string {
 	return ""
 }
 
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+	return token.Position{}
+}
+
 type LetStatement struct {
 	Token token.Token // token.LET
 	Name  *Identifier
@@ -68,6 +78,7 @@ func (ls *LetStatement) String() string {
 
 func (ls *LetStatement) statementNode()       {}
 func (ls *LetStatement) TokenLiteral() string { return ls.Token.Literal }
+func (ls *LetStatement) Pos() token.Position  { return ls.Token.Pos() }
 
 // interesting interface thing - cannot assign to concrete value, must be pointer type
 // as methods on LetStatement have pointer receivers
@@ -127,6 +138,10 @@ func (es *ExpressionStatement) TokenLiteral() string {
 	return es.Token.Literal
 }
 
+func (es *ExpressionStatement) Pos() token.Position {
+	return es.Token.Pos()
+}
+
 func (es *ExpressionStatement) statementNode() {}
 
 var _ Statement = &ExpressionStatement{}
@@ -140,6 +155,10 @@ func (il *IntegerLiteral) TokenLiteral() string {
 	return il.Token.Literal
 }
 
+func (il *IntegerLiteral) Pos() token.Position {
+	return il.Token.Pos()
+}
+
 func (il *IntegerLiteral) String() string {
 	return il.Token.Literal
 }
@@ -158,6 +177,10 @@ func (pe *PrefixExpression) TokenLiteral() string {
 	return pe.Token.Literal
 }
 
+func (pe *PrefixExpression) Pos() token.Position {
+	return pe.Token.Pos()
+}
+
 func (pe *PrefixExpression) String() string {
 	var out bytes.Buffer
 
@@ -184,6 +207,10 @@ func (ie *InfixExpression) TokenLiteral() string {
 	return ie.Token.Literal
 }
 
+func (ie *InfixExpression) Pos() token.Position {
+	return ie.Token.Pos()
+}
+
 func (ie *InfixExpression) String() string {
 	var out bytes.Buffer
 
@@ -209,6 +236,10 @@ func (b *Boolean) TokenLiteral() string {
 	return b.Token.Literal
 }
 
+func (b *Boolean) Pos() token.Position {
+	return b.Token.Pos()
+}
+
 func (b *Boolean) String() string {
 	return b.Token.Literal
 }
@@ -228,6 +259,10 @@ func (ie *IfExpression) TokenLiteral() string {
 	return ie.Token.Literal
 }
 
+func (ie *IfExpression) Pos() token.Position {
+	return ie.Token.Pos()
+}
+
 func (ie *IfExpression) String() string {
 	var out bytes.Buffer
 
@@ -249,12 +284,17 @@ var _ Expression = &IfExpression{}
 type BlockStatement struct {
 	Token      token.Token // { token
 	Statements []Statement
+	EndToken   token.Token // } token
 }
 
 func (bs *BlockStatement) TokenLiteral() string {
 	return bs.Token.Literal
 }
 
+func (bs *BlockStatement) Pos() token.Position {
+	return bs.Token.Pos()
+}
+
 func (bs *BlockStatement) String() string {
 	var out bytes.Buffer
 
@@ -279,6 +319,10 @@ func (fl *FunctionLiteral) TokenLiteral() string {
 	return fl.Token.Literal
 }
 
+func (fl *FunctionLiteral) Pos() token.Position {
+	return fl.Token.Pos()
+}
+
 func (fl *FunctionLiteral) String() string {
 	var out bytes.Buffer
 
@@ -310,6 +354,10 @@ func (ce *CallExpression) TokenLiteral() string {
 	return ce.Token.Literal
 }
 
+func (ce *CallExpression) Pos() token.Position {
+	return ce.Token.Pos()
+}
+
 func (ce *CallExpression) String() string {
 	var out bytes.Buffer
 
@@ -336,6 +384,7 @@ type StringLiteral struct {
 }
 
 func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
+func (sl *StringLiteral) Pos() token.Position  { return sl.Token.Pos() }
 func (sl *StringLiteral) String() string       { return sl.Token.Literal }
 
 func (sl *StringLiteral) expressionNode() {}
@@ -348,7 +397,11 @@ type ArrayLiteral struct {
 }
 
 func (al *ArrayLiteral) TokenLiteral() string {
-	panic("implement me")
+	return al.Token.Literal
+}
+
+func (al *ArrayLiteral) Pos() token.Position {
+	return al.Token.Pos()
 }
 
 func (al *ArrayLiteral) String() string {
@@ -371,6 +424,33 @@ func (al *ArrayLiteral) expressionNode() {}
 
 var _ Expression = &ArrayLiteral{}
 
+type SetLiteral struct {
+	Token    token.Token // '#{' token
+	Elements []Expression
+}
+
+func (sl *SetLiteral) TokenLiteral() string { return sl.Token.Literal }
+func (sl *SetLiteral) Pos() token.Position  { return sl.Token.Pos() }
+
+func (sl *SetLiteral) String() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, el := range sl.Elements {
+		elements = append(elements, el.String())
+	}
+
+	out.WriteString("#{")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+func (sl *SetLiteral) expressionNode() {}
+
+var _ Expression = &SetLiteral{}
+
 type IndexExpression struct {
 	Token token.Token // '[' token
 	Left  Expression
@@ -378,6 +458,7 @@ type IndexExpression struct {
 }
 
 func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IndexExpression) Pos() token.Position  { return ie.Token.Pos() }
 
 func (ie *IndexExpression) String() string {
 	var out bytes.Buffer
@@ -401,13 +482,14 @@ type HashLiteral struct {
 }
 
 func (hl *HashLiteral) TokenLiteral() string { return hl.Token.Literal }
+func (hl *HashLiteral) Pos() token.Position  { return hl.Token.Pos() }
 
 func (hl *HashLiteral) String() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for k, v := range hl.Pairs {
-		pairs = append(pairs, k.String()+":"+v.String())
+	for _, k := range hl.Keys() {
+		pairs = append(pairs, k.String()+":"+hl.Pairs[k].String())
 	}
 
 	out.WriteString("{")
@@ -417,6 +499,23 @@ func (hl *HashLiteral) String() string {
 	return out.String()
 }
 
+// Keys returns keys of the hash literal in the order they appear in source,
+// keys without position are ordered by their String().
+func (hl *HashLiteral) Keys() []Expression {
+	keys := make([]Expression, 0, len(hl.Pairs))
+	for k := range hl.Pairs {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		pi, pj := keys[i].Pos(), keys[j].Pos()
+		if pi != pj {
+			return pi.Before(pj)
+		}
+		return keys[i].String() < keys[j].String()
+	})
+	return keys
+}
+
 func (hl *HashLiteral) expressionNode() {}
 
 var _ Expression = &HashLiteral{}
@@ -433,6 +532,10 @@ func (fe *ForExpression) TokenLiteral() string {
 	return fe.Token.Literal
 }
 
+func (fe *ForExpression) Pos() token.Position {
+	return fe.Token.Pos()
+}
+
 func (fe *ForExpression) String() string {
 	var out bytes.Buffer
 
@@ -452,3 +555,206 @@ func (fe *ForExpression) String() string {
 func (fe *ForExpression) expressionNode() {}
 
 var _ Expression = &ForExpression{}
+
+type MacroLiteral struct {
+	Token      token.Token // macro token
+	Parameters []*Identifier
+	Body       *BlockStatement
+}
+
+func (ml *MacroLiteral) TokenLiteral() string {
+	return ml.Token.Literal
+}
+
+func (ml *MacroLiteral) Pos() token.Position {
+	return ml.Token.Pos()
+}
+
+func (ml *MacroLiteral) String() string {
+	var out bytes.Buffer
+
+	params := []string{}
+	for _, p := range ml.Parameters {
+		params = append(params, p.String())
+	}
+
+	out.WriteString(ml.TokenLiteral())
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") ")
+	out.WriteString(ml.Body.String())
+
+	return out.String()
+}
+
+func (ml *MacroLiteral) expressionNode() {}
+
+var _ Expression = &MacroLiteral{}
+
+type ThrowStatement struct {
+	Token token.Token // throw token
+	Value Expression
+}
+
+func (ts *ThrowStatement) TokenLiteral() string { return ts.Token.Literal }
+func (ts *ThrowStatement) Pos() token.Position  { return ts.Token.Pos() }
+
+func (ts *ThrowStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ts.TokenLiteral() + " ")
+	if ts.Value != nil {
+		out.WriteString(ts.Value.String())
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
+func (ts *ThrowStatement) statementNode() {}
+
+var _ Statement = &ThrowStatement{}
+
+// YieldStatement hands a value to the consumer of a generator, functions
+// containing it are generators.
+type YieldStatement struct {
+	Token token.Token // yield token
+	Value Expression
+}
+
+func (ys *YieldStatement) TokenLiteral() string { return ys.Token.Literal }
+func (ys *YieldStatement) Pos() token.Position  { return ys.Token.Pos() }
+
+func (ys *YieldStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ys.TokenLiteral() + " ")
+	if ys.Value != nil {
+		out.WriteString(ys.Value.String())
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
+func (ys *YieldStatement) statementNode() {}
+
+var _ Statement = &YieldStatement{}
+
+// TryExpression has a Catch block, a Finally block or both.
+// CatchParameter is nil if the caught error is not bound.
+type TryExpression struct {
+	Token          token.Token // try token
+	Body           *BlockStatement
+	CatchParameter *Identifier
+	Catch          *BlockStatement
+	Finally        *BlockStatement
+}
+
+func (te *TryExpression) TokenLiteral() string { return te.Token.Literal }
+func (te *TryExpression) Pos() token.Position  { return te.Token.Pos() }
+
+func (te *TryExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("try ")
+	out.WriteString(te.Body.String())
+	if te.Catch != nil {
+		out.WriteString(" catch ")
+		if te.CatchParameter != nil {
+			out.WriteString("(" + te.CatchParameter.String() + ") ")
+		}
+		out.WriteString(te.Catch.String())
+	}
+	if te.Finally != nil {
+		out.WriteString(" finally ")
+		out.WriteString(te.Finally.String())
+	}
+
+	return out.String()
+}
+
+func (te *TryExpression) expressionNode() {}
+
+var _ Expression = &TryExpression{}
+
+// StructMethod is a method declared in a struct, its first
+// parameter is the instance it's called on.
+type StructMethod struct {
+	Name     *Identifier
+	Function *FunctionLiteral
+}
+
+type StructStatement struct {
+	Token    token.Token // struct token
+	Name     *Identifier
+	Fields   []*Identifier
+	Methods  []*StructMethod
+	EndToken token.Token // } token
+}
+
+func (ss *StructStatement) TokenLiteral() string { return ss.Token.Literal }
+func (ss *StructStatement) Pos() token.Position  { return ss.Token.Pos() }
+
+func (ss *StructStatement) String() string {
+	var out bytes.Buffer
+
+	members := []string{}
+	for _, f := range ss.Fields {
+		members = append(members, f.String())
+	}
+	for _, m := range ss.Methods {
+		params := []string{}
+		for _, p := range m.Function.Parameters {
+			params = append(params, p.String())
+		}
+		members = append(members, "fn "+m.Name.String()+"("+strings.Join(params, ", ")+") "+m.Function.Body.String())
+	}
+
+	out.WriteString("struct ")
+	out.WriteString(ss.Name.String())
+	out.WriteString(" {")
+	out.WriteString(strings.Join(members, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+func (ss *StructStatement) statementNode() {}
+
+var _ Statement = &StructStatement{}
+
+type MemberExpression struct {
+	Token  token.Token // . token
+	Object Expression
+	Member *Identifier
+}
+
+func (me *MemberExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MemberExpression) Pos() token.Position  { return me.Token.Pos() }
+
+func (me *MemberExpression) String() string {
+	return "(" + me.Object.String() + "." + me.Member.String() + ")"
+}
+
+func (me *MemberExpression) expressionNode() {}
+
+var _ Expression = &MemberExpression{}
+
+// AssignExpression assigns to a field, Target is a MemberExpression.
+type AssignExpression struct {
+	Token  token.Token // = token
+	Target Expression
+	Value  Expression
+}
+
+func (ae *AssignExpression) TokenLiteral() string { return ae.Token.Literal }
+func (ae *AssignExpression) Pos() token.Position  { return ae.Token.Pos() }
+
+func (ae *AssignExpression) String() string {
+	return "(" + ae.Target.String() + " = " + ae.Value.String() + ")"
+}
+
+func (ae *AssignExpression) expressionNode() {}
+
+var _ Expression = &AssignExpression{}
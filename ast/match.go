@@ -0,0 +1,177 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/wmolicki/go-monkey/token"
+)
+
+// Pattern is the left hand side of a match arm.
+type Pattern interface {
+	Node
+	patternNode()
+}
+
+type MatchExpression struct {
+	Token    token.Token // match token
+	Subject  Expression
+	Arms     []*MatchArm
+	EndToken token.Token // } token
+}
+
+// MatchArm is a single `pattern if guard => body` arm, Guard is nil
+// if the arm has no guard.
+type MatchArm struct {
+	Pattern Pattern
+	Guard   Expression
+	Body    Expression
+}
+
+func (ma *MatchArm) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ma.Pattern.String())
+	if ma.Guard != nil {
+		out.WriteString(" if ")
+		out.WriteString(ma.Guard.String())
+	}
+	out.WriteString(" => ")
+	out.WriteString(ma.Body.String())
+
+	return out.String()
+}
+
+func (me *MatchExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MatchExpression) Pos() token.Position  { return me.Token.Pos() }
+
+func (me *MatchExpression) String() string {
+	var out bytes.Buffer
+
+	arms := []string{}
+	for _, arm := range me.Arms {
+		arms = append(arms, arm.String())
+	}
+
+	out.WriteString("match (")
+	out.WriteString(me.Subject.String())
+	out.WriteString(") {")
+	out.WriteString(strings.Join(arms, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+func (me *MatchExpression) expressionNode() {}
+
+var _ Expression = &MatchExpression{}
+
+// WildcardPattern `_` matches any value without binding it.
+type WildcardPattern struct {
+	Token token.Token // _ token
+}
+
+func (wp *WildcardPattern) TokenLiteral() string { return wp.Token.Literal }
+func (wp *WildcardPattern) Pos() token.Position  { return wp.Token.Pos() }
+func (wp *WildcardPattern) String() string       { return wp.Token.Literal }
+func (wp *WildcardPattern) patternNode()         {}
+
+var _ Pattern = &WildcardPattern{}
+
+// BindingPattern matches any value and binds it to Name.
+type BindingPattern struct {
+	Name *Identifier
+}
+
+func (bp *BindingPattern) TokenLiteral() string { return bp.Name.TokenLiteral() }
+func (bp *BindingPattern) Pos() token.Position  { return bp.Name.Pos() }
+func (bp *BindingPattern) String() string       { return bp.Name.String() }
+func (bp *BindingPattern) patternNode()         {}
+
+var _ Pattern = &BindingPattern{}
+
+// LiteralPattern matches values equal to an integer, string or boolean literal.
+type LiteralPattern struct {
+	Value Expression
+}
+
+func (lp *LiteralPattern) TokenLiteral() string { return lp.Value.TokenLiteral() }
+func (lp *LiteralPattern) Pos() token.Position  { return lp.Value.Pos() }
+func (lp *LiteralPattern) String() string       { return lp.Value.String() }
+func (lp *LiteralPattern) patternNode()         {}
+
+var _ Pattern = &LiteralPattern{}
+
+// ArrayPattern matches arrays element by element. Without a rest the
+// array has to have exactly as many elements as there are patterns,
+// with one the remaining elements are bound to Rest, unless it's nil.
+type ArrayPattern struct {
+	Token    token.Token // [ token
+	Elements []Pattern
+	HasRest  bool
+	Rest     *Identifier
+}
+
+func (ap *ArrayPattern) TokenLiteral() string { return ap.Token.Literal }
+func (ap *ArrayPattern) Pos() token.Position  { return ap.Token.Pos() }
+
+func (ap *ArrayPattern) String() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, el := range ap.Elements {
+		elements = append(elements, el.String())
+	}
+	if ap.HasRest {
+		rest := "..."
+		if ap.Rest != nil {
+			rest += ap.Rest.String()
+		}
+		elements = append(elements, rest)
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+func (ap *ArrayPattern) patternNode() {}
+
+var _ Pattern = &ArrayPattern{}
+
+// HashPatternPair is a single `key: pattern` pair of a hash pattern.
+type HashPatternPair struct {
+	Key   Expression
+	Value Pattern
+}
+
+// HashPattern matches hashes containing all of its keys, with values
+// matching their patterns. Other keys are ignored.
+type HashPattern struct {
+	Token token.Token // { token
+	Pairs []*HashPatternPair
+}
+
+func (hp *HashPattern) TokenLiteral() string { return hp.Token.Literal }
+func (hp *HashPattern) Pos() token.Position  { return hp.Token.Pos() }
+
+func (hp *HashPattern) String() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for _, pair := range hp.Pairs {
+		pairs = append(pairs, pair.Key.String()+": "+pair.Value.String())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+func (hp *HashPattern) patternNode() {}
+
+var _ Pattern = &HashPattern{}
@@ -0,0 +1,286 @@
+package ast
+
+type ModifierFunc func(Node) Node
+
+// Modify walks the tree depth first, replacing every node with the result
+// of calling modifier on it. Children are modified before their parents.
+func Modify(node Node, modifier ModifierFunc) Node {
+	switch node := node.(type) {
+	case *Program:
+		for i, statement := range node.Statements {
+			node.Statements[i], _ = Modify(statement, modifier).(Statement)
+		}
+	case *ExpressionStatement:
+		node.Expression, _ = Modify(node.Expression, modifier).(Expression)
+	case *LetStatement:
+		node.Value, _ = Modify(node.Value, modifier).(Expression)
+	case *ReturnStatement:
+		node.ReturnValue, _ = Modify(node.ReturnValue, modifier).(Expression)
+	case *BlockStatement:
+		for i, statement := range node.Statements {
+			node.Statements[i], _ = Modify(statement, modifier).(Statement)
+		}
+	case *PrefixExpression:
+		node.Right, _ = Modify(node.Right, modifier).(Expression)
+	case *InfixExpression:
+		node.Left, _ = Modify(node.Left, modifier).(Expression)
+		node.Right, _ = Modify(node.Right, modifier).(Expression)
+	case *IfExpression:
+		node.Condition, _ = Modify(node.Condition, modifier).(Expression)
+		node.Consequence, _ = Modify(node.Consequence, modifier).(*BlockStatement)
+		if node.Alternative != nil {
+			node.Alternative, _ = Modify(node.Alternative, modifier).(*BlockStatement)
+		}
+	case *FunctionLiteral:
+		for i, param := range node.Parameters {
+			node.Parameters[i], _ = Modify(param, modifier).(*Identifier)
+		}
+		node.Body, _ = Modify(node.Body, modifier).(*BlockStatement)
+	case *MacroLiteral:
+		for i, param := range node.Parameters {
+			node.Parameters[i], _ = Modify(param, modifier).(*Identifier)
+		}
+		node.Body, _ = Modify(node.Body, modifier).(*BlockStatement)
+	case *CallExpression:
+		node.Function, _ = Modify(node.Function, modifier).(Expression)
+		for i, arg := range node.Arguments {
+			node.Arguments[i], _ = Modify(arg, modifier).(Expression)
+		}
+	case *ArrayLiteral:
+		for i, el := range node.Elements {
+			node.Elements[i], _ = Modify(el, modifier).(Expression)
+		}
+	case *SetLiteral:
+		for i, el := range node.Elements {
+			node.Elements[i], _ = Modify(el, modifier).(Expression)
+		}
+	case *IndexExpression:
+		node.Left, _ = Modify(node.Left, modifier).(Expression)
+		node.Index, _ = Modify(node.Index, modifier).(Expression)
+	case *HashLiteral:
+		newPairs := make(map[Expression]Expression)
+		for key, val := range node.Pairs {
+			newKey, _ := Modify(key, modifier).(Expression)
+			newVal, _ := Modify(val, modifier).(Expression)
+			newPairs[newKey] = newVal
+		}
+		node.Pairs = newPairs
+	case *ForExpression:
+		node.Initializer, _ = Modify(node.Initializer, modifier).(Statement)
+		node.Condition, _ = Modify(node.Condition, modifier).(Expression)
+		node.Loop, _ = Modify(node.Loop, modifier).(Statement)
+		node.Body, _ = Modify(node.Body, modifier).(*BlockStatement)
+	case *ThrowStatement:
+		node.Value, _ = Modify(node.Value, modifier).(Expression)
+	case *YieldStatement:
+		node.Value, _ = Modify(node.Value, modifier).(Expression)
+	case *TryExpression:
+		node.Body, _ = Modify(node.Body, modifier).(*BlockStatement)
+		if node.Catch != nil {
+			node.Catch, _ = Modify(node.Catch, modifier).(*BlockStatement)
+		}
+		if node.Finally != nil {
+			node.Finally, _ = Modify(node.Finally, modifier).(*BlockStatement)
+		}
+	case *StructStatement:
+		for _, method := range node.Methods {
+			method.Function, _ = Modify(method.Function, modifier).(*FunctionLiteral)
+		}
+	case *MemberExpression:
+		node.Object, _ = Modify(node.Object, modifier).(Expression)
+	case *AssignExpression:
+		node.Target, _ = Modify(node.Target, modifier).(Expression)
+		node.Value, _ = Modify(node.Value, modifier).(Expression)
+	case *SpawnExpression:
+		node.Value, _ = Modify(node.Value, modifier).(Expression)
+	case *SelectExpression:
+		for _, c := range node.Cases {
+			if c.Call != nil {
+				c.Call, _ = Modify(c.Call, modifier).(*CallExpression)
+			}
+			c.Body, _ = Modify(c.Body, modifier).(Expression)
+		}
+	case *MatchExpression:
+		node.Subject, _ = Modify(node.Subject, modifier).(Expression)
+		for _, arm := range node.Arms {
+			arm.Pattern, _ = Modify(arm.Pattern, modifier).(Pattern)
+			if arm.Guard != nil {
+				arm.Guard, _ = Modify(arm.Guard, modifier).(Expression)
+			}
+			arm.Body, _ = Modify(arm.Body, modifier).(Expression)
+		}
+	case *LiteralPattern:
+		node.Value, _ = Modify(node.Value, modifier).(Expression)
+	case *ArrayPattern:
+		for i, el := range node.Elements {
+			node.Elements[i], _ = Modify(el, modifier).(Pattern)
+		}
+	case *HashPattern:
+		for _, pair := range node.Pairs {
+			pair.Key, _ = Modify(pair.Key, modifier).(Expression)
+			pair.Value, _ = Modify(pair.Value, modifier).(Pattern)
+		}
+	}
+
+	if node == nil {
+		return nil
+	}
+	return modifier(node)
+}
+
+// Inspect walks the tree depth first, calling f for every node before its
+// children. Children are skipped if f returns false.
+func Inspect(node Node, f func(Node) bool) {
+	if node == nil || !f(node) {
+		return
+	}
+
+	switch node := node.(type) {
+	case *Program:
+		for _, statement := range node.Statements {
+			inspectStatement(statement, f)
+		}
+	case *ExpressionStatement:
+		inspectExpression(node.Expression, f)
+	case *LetStatement:
+		if node.Name != nil {
+			Inspect(node.Name, f)
+		}
+		inspectExpression(node.Value, f)
+	case *ReturnStatement:
+		inspectExpression(node.ReturnValue, f)
+	case *BlockStatement:
+		for _, statement := range node.Statements {
+			inspectStatement(statement, f)
+		}
+	case *PrefixExpression:
+		inspectExpression(node.Right, f)
+	case *InfixExpression:
+		inspectExpression(node.Left, f)
+		inspectExpression(node.Right, f)
+	case *IfExpression:
+		inspectExpression(node.Condition, f)
+		inspectBlock(node.Consequence, f)
+		inspectBlock(node.Alternative, f)
+	case *FunctionLiteral:
+		for _, param := range node.Parameters {
+			Inspect(param, f)
+		}
+		inspectBlock(node.Body, f)
+	case *MacroLiteral:
+		for _, param := range node.Parameters {
+			Inspect(param, f)
+		}
+		inspectBlock(node.Body, f)
+	case *CallExpression:
+		inspectExpression(node.Function, f)
+		for _, arg := range node.Arguments {
+			inspectExpression(arg, f)
+		}
+	case *ArrayLiteral:
+		for _, el := range node.Elements {
+			inspectExpression(el, f)
+		}
+	case *SetLiteral:
+		for _, el := range node.Elements {
+			inspectExpression(el, f)
+		}
+	case *IndexExpression:
+		inspectExpression(node.Left, f)
+		inspectExpression(node.Index, f)
+	case *HashLiteral:
+		for _, key := range node.Keys() {
+			inspectExpression(key, f)
+			inspectExpression(node.Pairs[key], f)
+		}
+	case *ForExpression:
+		inspectStatement(node.Initializer, f)
+		inspectExpression(node.Condition, f)
+		inspectStatement(node.Loop, f)
+		inspectBlock(node.Body, f)
+	case *ThrowStatement:
+		inspectExpression(node.Value, f)
+	case *YieldStatement:
+		inspectExpression(node.Value, f)
+	case *TryExpression:
+		inspectBlock(node.Body, f)
+		if node.CatchParameter != nil {
+			Inspect(node.CatchParameter, f)
+		}
+		inspectBlock(node.Catch, f)
+		inspectBlock(node.Finally, f)
+	case *StructStatement:
+		Inspect(node.Name, f)
+		for _, field := range node.Fields {
+			Inspect(field, f)
+		}
+		for _, method := range node.Methods {
+			Inspect(method.Name, f)
+			Inspect(method.Function, f)
+		}
+	case *MemberExpression:
+		// the member is a field name, not a variable, so it's not visited
+		inspectExpression(node.Object, f)
+	case *AssignExpression:
+		inspectExpression(node.Target, f)
+		inspectExpression(node.Value, f)
+	case *SpawnExpression:
+		inspectExpression(node.Value, f)
+	case *SelectExpression:
+		for _, c := range node.Cases {
+			if c.Binding != nil {
+				Inspect(c.Binding, f)
+			}
+			if c.Call != nil {
+				Inspect(c.Call, f)
+			}
+			inspectExpression(c.Body, f)
+		}
+	case *MatchExpression:
+		inspectExpression(node.Subject, f)
+		for _, arm := range node.Arms {
+			if arm.Pattern != nil {
+				Inspect(arm.Pattern, f)
+			}
+			inspectExpression(arm.Guard, f)
+			inspectExpression(arm.Body, f)
+		}
+	case *BindingPattern:
+		Inspect(node.Name, f)
+	case *LiteralPattern:
+		inspectExpression(node.Value, f)
+	case *ArrayPattern:
+		for _, el := range node.Elements {
+			Inspect(el, f)
+		}
+		if node.Rest != nil {
+			Inspect(node.Rest, f)
+		}
+	case *HashPattern:
+		for _, pair := range node.Pairs {
+			inspectExpression(pair.Key, f)
+			Inspect(pair.Value, f)
+		}
+	}
+}
+
+// nil pointers in interfaces are not nil, these helpers make sure
+// Inspect is never called on a nil node.
+
+func inspectStatement(s Statement, f func(Node) bool) {
+	if s != nil {
+		Inspect(s, f)
+	}
+}
+
+func inspectExpression(e Expression, f func(Node) bool) {
+	if e != nil {
+		Inspect(e, f)
+	}
+}
+
+func inspectBlock(b *BlockStatement, f func(Node) bool) {
+	if b != nil {
+		Inspect(b, f)
+	}
+}
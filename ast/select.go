@@ -0,0 +1,95 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/wmolicki/go-monkey/token"
+)
+
+// SpawnExpression runs a call on a new goroutine. Value is either a call,
+// whose function and arguments are evaluated before spawning, or a
+// function which is called without arguments.
+type SpawnExpression struct {
+	Token token.Token // spawn token
+	Value Expression
+}
+
+func (se *SpawnExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SpawnExpression) Pos() token.Position  { return se.Token.Pos() }
+
+func (se *SpawnExpression) String() string {
+	return "spawn " + se.Value.String()
+}
+
+func (se *SpawnExpression) expressionNode() {}
+
+var _ Expression = &SpawnExpression{}
+
+// SelectExpression waits until one of its cases can proceed and
+// evaluates its body.
+type SelectExpression struct {
+	Token    token.Token // select token
+	Cases    []*SelectCase
+	EndToken token.Token // } token
+}
+
+// SelectCase is a `recv(ch) => body`, `let v = recv(ch) => body`,
+// `send(ch, value) => body` or default `_ => body` case of a select.
+type SelectCase struct {
+	Token token.Token // first token of the case
+	// Binding is the name of the received value, nil if it's not bound
+	Binding *Identifier
+	// Call is a call of recv or send, nil for the default case
+	Call *CallExpression
+	Body Expression
+}
+
+func (sc *SelectCase) IsDefault() bool { return sc.Call == nil }
+
+func (sc *SelectCase) IsSend() bool {
+	if sc.Call == nil {
+		return false
+	}
+	ident, ok := sc.Call.Function.(*Identifier)
+	return ok && ident.Value == "send"
+}
+
+func (sc *SelectCase) String() string {
+	var out bytes.Buffer
+
+	if sc.Binding != nil {
+		out.WriteString("let " + sc.Binding.String() + " = ")
+	}
+	if sc.Call != nil {
+		out.WriteString(sc.Call.String())
+	} else {
+		out.WriteString("_")
+	}
+	out.WriteString(" => ")
+	out.WriteString(sc.Body.String())
+
+	return out.String()
+}
+
+func (se *SelectExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SelectExpression) Pos() token.Position  { return se.Token.Pos() }
+
+func (se *SelectExpression) String() string {
+	var out bytes.Buffer
+
+	cases := []string{}
+	for _, c := range se.Cases {
+		cases = append(cases, c.String())
+	}
+
+	out.WriteString("select { ")
+	out.WriteString(strings.Join(cases, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+func (se *SelectExpression) expressionNode() {}
+
+var _ Expression = &SelectExpression{}
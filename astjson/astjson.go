@@ -0,0 +1,745 @@
+// Package astjson encodes Monkey syntax trees as JSON and decodes them
+// back, for tools which are not written in Go.
+//
+// Every node is encoded as an object with its "kind" (the name of the
+// ast type), "pos" (line and column of its token), the "token" it was
+// parsed from and its children under lowerCamelCase field names, e.g.
+//
+//	{"kind": "PrefixExpression", "pos": {"line": 1, "column": 1},
+//	 "token": {"type": "-", "literal": "-"}, "operator": "-",
+//	 "right": {"kind": "Identifier", ...}}
+//
+// Missing children are encoded as null. Encoding is lossless, decoding
+// the output gives back an equal tree, including positions.
+package astjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/wmolicki/go-monkey/ast"
+	"github.com/wmolicki/go-monkey/token"
+)
+
+// Marshal returns JSON encoding of node.
+func Marshal(node ast.Node) ([]byte, error) {
+	return json.Marshal(encode(node))
+}
+
+// Unmarshal decodes a node encoded by Marshal.
+func Unmarshal(data []byte) (ast.Node, error) {
+	return decode(data)
+}
+
+// UnmarshalProgram decodes a program encoded by Marshal.
+func UnmarshalProgram(data []byte) (*ast.Program, error) {
+	node, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+	program, ok := node.(*ast.Program)
+	if !ok {
+		return nil, fmt.Errorf("expected Program, got %s", kindOf(node))
+	}
+	return program, nil
+}
+
+type field struct {
+	name  string
+	value interface{}
+}
+
+// object is a JSON object which keeps its fields in order,
+// so the kind of a node is always written first.
+type object []field
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(f.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+type jsonPos struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type jsonToken struct {
+	Type    string   `json:"type"`
+	Literal string   `json:"literal"`
+	Pos     *jsonPos `json:"pos,omitempty"`
+}
+
+func encodePos(pos token.Position) jsonPos {
+	return jsonPos{Line: pos.Line, Column: pos.Column}
+}
+
+func kindOf(node ast.Node) string {
+	return reflect.TypeOf(node).Elem().Name()
+}
+
+func isNil(node ast.Node) bool {
+	if node == nil {
+		return true
+	}
+	v := reflect.ValueOf(node)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// encode returns a JSON-marshallable representation of node.
+func encode(node ast.Node) interface{} {
+	if isNil(node) {
+		return nil
+	}
+
+	o := object{{"kind", kindOf(node)}, {"pos", encodePos(node.Pos())}}
+	add := func(name string, value interface{}) {
+		o = append(o, field{name, value})
+	}
+	addToken := func(t token.Token) {
+		add("token", jsonToken{Type: string(t.Type), Literal: t.Literal})
+	}
+
+	switch node := node.(type) {
+	case *ast.Program:
+		add("statements", encodeStatements(node.Statements))
+	case *ast.LetStatement:
+		addToken(node.Token)
+		add("name", encode(node.Name))
+		add("value", encode(node.Value))
+	case *ast.ReturnStatement:
+		addToken(node.Token)
+		add("returnValue", encode(node.ReturnValue))
+	case *ast.ExpressionStatement:
+		addToken(node.Token)
+		add("expression", encode(node.Expression))
+	case *ast.BlockStatement:
+		addToken(node.Token)
+		add("statements", encodeStatements(node.Statements))
+		endPos := encodePos(node.EndToken.Pos())
+		add("end", jsonToken{Type: string(node.EndToken.Type), Literal: node.EndToken.Literal, Pos: &endPos})
+	case *ast.Identifier:
+		addToken(node.Token)
+		add("value", node.Value)
+	case *ast.IntegerLiteral:
+		addToken(node.Token)
+		add("value", node.Value)
+	case *ast.StringLiteral:
+		addToken(node.Token)
+		add("value", node.Value)
+	case *ast.Boolean:
+		addToken(node.Token)
+		add("value", node.Value)
+	case *ast.PrefixExpression:
+		addToken(node.Token)
+		add("operator", node.Operator)
+		add("right", encode(node.Right))
+	case *ast.InfixExpression:
+		addToken(node.Token)
+		add("left", encode(node.Left))
+		add("operator", node.Operator)
+		add("right", encode(node.Right))
+	case *ast.IfExpression:
+		addToken(node.Token)
+		add("condition", encode(node.Condition))
+		add("consequence", encode(node.Consequence))
+		add("alternative", encode(node.Alternative))
+	case *ast.FunctionLiteral:
+		addToken(node.Token)
+		add("parameters", encodeIdentifiers(node.Parameters))
+		add("body", encode(node.Body))
+	case *ast.MacroLiteral:
+		addToken(node.Token)
+		add("parameters", encodeIdentifiers(node.Parameters))
+		add("body", encode(node.Body))
+	case *ast.CallExpression:
+		addToken(node.Token)
+		add("function", encode(node.Function))
+		add("arguments", encodeExpressions(node.Arguments))
+	case *ast.ArrayLiteral:
+		addToken(node.Token)
+		add("elements", encodeExpressions(node.Elements))
+	case *ast.SetLiteral:
+		addToken(node.Token)
+		add("elements", encodeExpressions(node.Elements))
+	case *ast.IndexExpression:
+		addToken(node.Token)
+		add("left", encode(node.Left))
+		add("index", encode(node.Index))
+	case *ast.HashLiteral:
+		addToken(node.Token)
+		pairs := []interface{}{}
+		for _, key := range node.Keys() {
+			pairs = append(pairs, object{{"key", encode(key)}, {"value", encode(node.Pairs[key])}})
+		}
+		add("pairs", pairs)
+	case *ast.ForExpression:
+		addToken(node.Token)
+		add("initializer", encode(node.Initializer))
+		add("condition", encode(node.Condition))
+		add("loop", encode(node.Loop))
+		add("body", encode(node.Body))
+	case *ast.ThrowStatement:
+		addToken(node.Token)
+		add("value", encode(node.Value))
+	case *ast.YieldStatement:
+		addToken(node.Token)
+		add("value", encode(node.Value))
+	case *ast.TryExpression:
+		addToken(node.Token)
+		add("body", encode(node.Body))
+		add("catchParameter", encode(node.CatchParameter))
+		add("catch", encode(node.Catch))
+		add("finally", encode(node.Finally))
+	case *ast.StructStatement:
+		addToken(node.Token)
+		add("name", encode(node.Name))
+		add("fields", encodeIdentifiers(node.Fields))
+		methods := []interface{}{}
+		for _, method := range node.Methods {
+			methods = append(methods, object{{"name", encode(method.Name)}, {"function", encode(method.Function)}})
+		}
+		add("methods", methods)
+		endPos := encodePos(node.EndToken.Pos())
+		add("end", jsonToken{Type: string(node.EndToken.Type), Literal: node.EndToken.Literal, Pos: &endPos})
+	case *ast.MemberExpression:
+		addToken(node.Token)
+		add("object", encode(node.Object))
+		add("member", encode(node.Member))
+	case *ast.AssignExpression:
+		addToken(node.Token)
+		add("target", encode(node.Target))
+		add("value", encode(node.Value))
+	case *ast.MatchExpression:
+		addToken(node.Token)
+		add("subject", encode(node.Subject))
+		arms := []interface{}{}
+		for _, arm := range node.Arms {
+			arms = append(arms, object{{"pattern", encode(arm.Pattern)}, {"guard", encode(arm.Guard)}, {"body", encode(arm.Body)}})
+		}
+		add("arms", arms)
+		endPos := encodePos(node.EndToken.Pos())
+		add("end", jsonToken{Type: string(node.EndToken.Type), Literal: node.EndToken.Literal, Pos: &endPos})
+	case *ast.SpawnExpression:
+		addToken(node.Token)
+		add("value", encode(node.Value))
+	case *ast.SelectExpression:
+		addToken(node.Token)
+		cases := []interface{}{}
+		for _, c := range node.Cases {
+			pos := encodePos(c.Token.Pos())
+			cases = append(cases, object{
+				{"token", jsonToken{Type: string(c.Token.Type), Literal: c.Token.Literal, Pos: &pos}},
+				{"binding", encode(c.Binding)},
+				{"call", encode(c.Call)},
+				{"body", encode(c.Body)},
+			})
+		}
+		add("cases", cases)
+		endPos := encodePos(node.EndToken.Pos())
+		add("end", jsonToken{Type: string(node.EndToken.Type), Literal: node.EndToken.Literal, Pos: &endPos})
+	case *ast.WildcardPattern:
+		addToken(node.Token)
+	case *ast.BindingPattern:
+		add("name", encode(node.Name))
+	case *ast.LiteralPattern:
+		add("value", encode(node.Value))
+	case *ast.ArrayPattern:
+		addToken(node.Token)
+		elements := []interface{}{}
+		for _, el := range node.Elements {
+			elements = append(elements, encode(el))
+		}
+		add("elements", elements)
+		add("hasRest", node.HasRest)
+		add("rest", encode(node.Rest))
+	case *ast.HashPattern:
+		addToken(node.Token)
+		pairs := []interface{}{}
+		for _, pair := range node.Pairs {
+			pairs = append(pairs, object{{"key", encode(pair.Key)}, {"value", encode(pair.Value)}})
+		}
+		add("pairs", pairs)
+	}
+
+	return o
+}
+
+func encodeStatements(statements []ast.Statement) []interface{} {
+	result := []interface{}{}
+	for _, s := range statements {
+		result = append(result, encode(s))
+	}
+	return result
+}
+
+func encodeExpressions(expressions []ast.Expression) []interface{} {
+	if expressions == nil {
+		return nil
+	}
+	result := []interface{}{}
+	for _, e := range expressions {
+		result = append(result, encode(e))
+	}
+	return result
+}
+
+func encodeIdentifiers(identifiers []*ast.Identifier) []interface{} {
+	if identifiers == nil {
+		return nil
+	}
+	result := []interface{}{}
+	for _, i := range identifiers {
+		result = append(result, encode(i))
+	}
+	return result
+}
+
+// tokenless are kinds of nodes which have no token of their own,
+// their position is the position of their first child.
+var tokenless = map[string]bool{"Program": true, "BindingPattern": true, "LiteralPattern": true}
+
+// fields are the raw fields of an encoded node.
+type fields map[string]json.RawMessage
+
+func isNull(data json.RawMessage) bool {
+	return len(data) == 0 || string(data) == "null"
+}
+
+func decode(data json.RawMessage) (ast.Node, error) {
+	if isNull(data) {
+		return nil, nil
+	}
+
+	var f fields
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+
+	var kind string
+	if err := f.value("kind", &kind); err != nil {
+		return nil, err
+	}
+	var pos jsonPos
+	if err := f.value("pos", &pos); err != nil {
+		return nil, err
+	}
+	var tok token.Token
+	if !tokenless[kind] {
+		var t jsonToken
+		if err := f.value("token", &t); err != nil {
+			return nil, err
+		}
+		tok = token.Token{Type: token.TokenType(t.Type), Literal: t.Literal, Line: pos.Line, Column: pos.Column}
+	}
+
+	d := decoder{fields: f}
+	var node ast.Node
+
+	switch kind {
+	case "Program":
+		node = &ast.Program{Statements: d.statements("statements")}
+	case "LetStatement":
+		node = &ast.LetStatement{Token: tok, Name: d.identifier("name"), Value: d.expression("value")}
+	case "ReturnStatement":
+		node = &ast.ReturnStatement{Token: tok, ReturnValue: d.expression("returnValue")}
+	case "ExpressionStatement":
+		node = &ast.ExpressionStatement{Token: tok, Expression: d.expression("expression")}
+	case "BlockStatement":
+		node = &ast.BlockStatement{Token: tok, Statements: d.statements("statements"), EndToken: d.endToken("end")}
+	case "Identifier":
+		ident := &ast.Identifier{Token: tok}
+		d.value("value", &ident.Value)
+		node = ident
+	case "IntegerLiteral":
+		lit := &ast.IntegerLiteral{Token: tok}
+		d.value("value", &lit.Value)
+		node = lit
+	case "StringLiteral":
+		lit := &ast.StringLiteral{Token: tok}
+		d.value("value", &lit.Value)
+		node = lit
+	case "Boolean":
+		b := &ast.Boolean{Token: tok}
+		d.value("value", &b.Value)
+		node = b
+	case "PrefixExpression":
+		exp := &ast.PrefixExpression{Token: tok, Right: d.expression("right")}
+		d.value("operator", &exp.Operator)
+		node = exp
+	case "InfixExpression":
+		exp := &ast.InfixExpression{Token: tok, Left: d.expression("left"), Right: d.expression("right")}
+		d.value("operator", &exp.Operator)
+		node = exp
+	case "IfExpression":
+		node = &ast.IfExpression{
+			Token:       tok,
+			Condition:   d.expression("condition"),
+			Consequence: d.block("consequence"),
+			Alternative: d.block("alternative"),
+		}
+	case "FunctionLiteral":
+		node = &ast.FunctionLiteral{Token: tok, Parameters: d.identifiers("parameters"), Body: d.block("body")}
+	case "MacroLiteral":
+		node = &ast.MacroLiteral{Token: tok, Parameters: d.identifiers("parameters"), Body: d.block("body")}
+	case "CallExpression":
+		node = &ast.CallExpression{Token: tok, Function: d.expression("function"), Arguments: d.expressions("arguments")}
+	case "ArrayLiteral":
+		node = &ast.ArrayLiteral{Token: tok, Elements: d.expressions("elements")}
+	case "SetLiteral":
+		node = &ast.SetLiteral{Token: tok, Elements: d.expressions("elements")}
+	case "IndexExpression":
+		node = &ast.IndexExpression{Token: tok, Left: d.expression("left"), Index: d.expression("index")}
+	case "HashLiteral":
+		node = &ast.HashLiteral{Token: tok, Pairs: d.pairs("pairs")}
+	case "ForExpression":
+		node = &ast.ForExpression{
+			Token:       tok,
+			Initializer: d.statement("initializer"),
+			Condition:   d.expression("condition"),
+			Loop:        d.statement("loop"),
+			Body:        d.block("body"),
+		}
+	case "ThrowStatement":
+		node = &ast.ThrowStatement{Token: tok, Value: d.expression("value")}
+	case "YieldStatement":
+		node = &ast.YieldStatement{Token: tok, Value: d.expression("value")}
+	case "TryExpression":
+		node = &ast.TryExpression{
+			Token:          tok,
+			Body:           d.block("body"),
+			CatchParameter: d.identifier("catchParameter"),
+			Catch:          d.block("catch"),
+			Finally:        d.block("finally"),
+		}
+	case "StructStatement":
+		node = &ast.StructStatement{
+			Token:    tok,
+			Name:     d.identifier("name"),
+			Fields:   d.identifiers("fields"),
+			Methods:  d.methods("methods"),
+			EndToken: d.endToken("end"),
+		}
+	case "MemberExpression":
+		node = &ast.MemberExpression{Token: tok, Object: d.expression("object"), Member: d.identifier("member")}
+	case "AssignExpression":
+		node = &ast.AssignExpression{Token: tok, Target: d.expression("target"), Value: d.expression("value")}
+	case "MatchExpression":
+		node = &ast.MatchExpression{Token: tok, Subject: d.expression("subject"), Arms: d.arms("arms"), EndToken: d.endToken("end")}
+	case "SpawnExpression":
+		node = &ast.SpawnExpression{Token: tok, Value: d.expression("value")}
+	case "SelectExpression":
+		node = &ast.SelectExpression{Token: tok, Cases: d.selectCases("cases"), EndToken: d.endToken("end")}
+	case "WildcardPattern":
+		node = &ast.WildcardPattern{Token: tok}
+	case "BindingPattern":
+		node = &ast.BindingPattern{Name: d.identifier("name")}
+	case "LiteralPattern":
+		node = &ast.LiteralPattern{Value: d.expression("value")}
+	case "ArrayPattern":
+		pattern := &ast.ArrayPattern{Token: tok, Elements: d.patterns("elements"), Rest: d.identifier("rest")}
+		d.value("hasRest", &pattern.HasRest)
+		node = pattern
+	case "HashPattern":
+		node = &ast.HashPattern{Token: tok, Pairs: d.patternPairs("pairs")}
+	default:
+		return nil, fmt.Errorf("unknown node kind %q", kind)
+	}
+
+	if d.err != nil {
+		return nil, fmt.Errorf("%s: %v", kind, d.err)
+	}
+	return node, nil
+}
+
+func (f fields) value(name string, v interface{}) error {
+	data, ok := f[name]
+	if !ok {
+		return fmt.Errorf("missing field %q", name)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("field %q: %v", name, err)
+	}
+	return nil
+}
+
+// decoder decodes children of a node, remembering the first error
+// so a node can be built in a single expression.
+type decoder struct {
+	fields fields
+	err    error
+}
+
+func (d *decoder) fail(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+}
+
+func (d *decoder) value(name string, v interface{}) {
+	if err := d.fields.value(name, v); err != nil {
+		d.fail(err)
+	}
+}
+
+func (d *decoder) node(name string, data json.RawMessage) ast.Node {
+	node, err := decode(data)
+	if err != nil {
+		d.fail(fmt.Errorf("field %q: %v", name, err))
+	}
+	return node
+}
+
+func (d *decoder) list(name string) []json.RawMessage {
+	data, ok := d.fields[name]
+	if !ok {
+		d.fail(fmt.Errorf("missing field %q", name))
+		return nil
+	}
+	if isNull(data) {
+		return nil
+	}
+	list := []json.RawMessage{}
+	if err := json.Unmarshal(data, &list); err != nil {
+		d.fail(fmt.Errorf("field %q: %v", name, err))
+	}
+	return list
+}
+
+func (d *decoder) expression(name string) ast.Expression {
+	return d.toExpression(name, d.node(name, d.fields[name]))
+}
+
+func (d *decoder) toExpression(name string, node ast.Node) ast.Expression {
+	if node == nil {
+		return nil
+	}
+	exp, ok := node.(ast.Expression)
+	if !ok {
+		d.fail(fmt.Errorf("field %q: expected expression, got %s", name, kindOf(node)))
+	}
+	return exp
+}
+
+func (d *decoder) statement(name string) ast.Statement {
+	return d.toStatement(name, d.node(name, d.fields[name]))
+}
+
+func (d *decoder) toStatement(name string, node ast.Node) ast.Statement {
+	if node == nil {
+		return nil
+	}
+	stmt, ok := node.(ast.Statement)
+	if !ok {
+		d.fail(fmt.Errorf("field %q: expected statement, got %s", name, kindOf(node)))
+	}
+	return stmt
+}
+
+func (d *decoder) block(name string) *ast.BlockStatement {
+	node := d.node(name, d.fields[name])
+	if node == nil {
+		return nil
+	}
+	block, ok := node.(*ast.BlockStatement)
+	if !ok {
+		d.fail(fmt.Errorf("field %q: expected BlockStatement, got %s", name, kindOf(node)))
+	}
+	return block
+}
+
+func (d *decoder) identifier(name string) *ast.Identifier {
+	return d.toIdentifier(name, d.node(name, d.fields[name]))
+}
+
+func (d *decoder) toIdentifier(name string, node ast.Node) *ast.Identifier {
+	if node == nil {
+		return nil
+	}
+	ident, ok := node.(*ast.Identifier)
+	if !ok {
+		d.fail(fmt.Errorf("field %q: expected Identifier, got %s", name, kindOf(node)))
+	}
+	return ident
+}
+
+func (d *decoder) statements(name string) []ast.Statement {
+	result := []ast.Statement{}
+	for _, data := range d.list(name) {
+		result = append(result, d.toStatement(name, d.node(name, data)))
+	}
+	return result
+}
+
+func (d *decoder) expressions(name string) []ast.Expression {
+	list := d.list(name)
+	if list == nil {
+		return nil
+	}
+	result := []ast.Expression{}
+	for _, data := range list {
+		result = append(result, d.toExpression(name, d.node(name, data)))
+	}
+	return result
+}
+
+func (d *decoder) identifiers(name string) []*ast.Identifier {
+	list := d.list(name)
+	if list == nil {
+		return nil
+	}
+	result := []*ast.Identifier{}
+	for _, data := range list {
+		result = append(result, d.toIdentifier(name, d.node(name, data)))
+	}
+	return result
+}
+
+func (d *decoder) pairs(name string) map[ast.Expression]ast.Expression {
+	pairs := make(map[ast.Expression]ast.Expression)
+	for _, data := range d.list(name) {
+		var pair fields
+		if err := json.Unmarshal(data, &pair); err != nil {
+			d.fail(fmt.Errorf("field %q: %v", name, err))
+			continue
+		}
+		key := d.toExpression(name, d.node(name, pair["key"]))
+		value := d.toExpression(name, d.node(name, pair["value"]))
+		if key != nil {
+			pairs[key] = value
+		}
+	}
+	return pairs
+}
+
+// endToken decodes a closing token, which is encoded with its own position.
+func (d *decoder) endToken(name string) token.Token {
+	var end jsonToken
+	d.value(name, &end)
+	t := token.Token{Type: token.TokenType(end.Type), Literal: end.Literal}
+	if end.Pos != nil {
+		t.Line, t.Column = end.Pos.Line, end.Pos.Column
+	}
+	return t
+}
+
+func (d *decoder) pattern(name string) ast.Pattern {
+	return d.toPattern(name, d.node(name, d.fields[name]))
+}
+
+func (d *decoder) toPattern(name string, node ast.Node) ast.Pattern {
+	if node == nil {
+		return nil
+	}
+	pattern, ok := node.(ast.Pattern)
+	if !ok {
+		d.fail(fmt.Errorf("field %q: expected pattern, got %s", name, kindOf(node)))
+	}
+	return pattern
+}
+
+func (d *decoder) patterns(name string) []ast.Pattern {
+	result := []ast.Pattern{}
+	for _, data := range d.list(name) {
+		result = append(result, d.toPattern(name, d.node(name, data)))
+	}
+	return result
+}
+
+// sub returns a decoder of an object nested in field name, which is not a node.
+func (d *decoder) sub(name string, data json.RawMessage) *decoder {
+	var f fields
+	if err := json.Unmarshal(data, &f); err != nil {
+		d.fail(fmt.Errorf("field %q: %v", name, err))
+	}
+	return &decoder{fields: f}
+}
+
+func (d *decoder) arms(name string) []*ast.MatchArm {
+	var arms []*ast.MatchArm
+	for _, data := range d.list(name) {
+		sub := d.sub(name, data)
+		arms = append(arms, &ast.MatchArm{
+			Pattern: sub.pattern("pattern"),
+			Guard:   sub.expression("guard"),
+			Body:    sub.expression("body"),
+		})
+		if sub.err != nil {
+			d.fail(fmt.Errorf("field %q: %v", name, sub.err))
+		}
+	}
+	return arms
+}
+
+func (d *decoder) selectCases(name string) []*ast.SelectCase {
+	var cases []*ast.SelectCase
+	for _, data := range d.list(name) {
+		sub := d.sub(name, data)
+		c := &ast.SelectCase{Token: sub.endToken("token"), Binding: sub.identifier("binding"), Body: sub.expression("body")}
+		if node := sub.node("call", sub.fields["call"]); node != nil {
+			call, ok := node.(*ast.CallExpression)
+			if !ok {
+				sub.fail(fmt.Errorf("field %q: expected CallExpression, got %s", "call", kindOf(node)))
+			}
+			c.Call = call
+		}
+		cases = append(cases, c)
+		if sub.err != nil {
+			d.fail(fmt.Errorf("field %q: %v", name, sub.err))
+		}
+	}
+	return cases
+}
+
+func (d *decoder) methods(name string) []*ast.StructMethod {
+	var methods []*ast.StructMethod
+	for _, data := range d.list(name) {
+		sub := d.sub(name, data)
+		method := &ast.StructMethod{Name: sub.identifier("name")}
+		if node := sub.node("function", sub.fields["function"]); node != nil {
+			fn, ok := node.(*ast.FunctionLiteral)
+			if !ok {
+				sub.fail(fmt.Errorf("field %q: expected FunctionLiteral, got %s", "function", kindOf(node)))
+			}
+			method.Function = fn
+		}
+		methods = append(methods, method)
+		if sub.err != nil {
+			d.fail(fmt.Errorf("field %q: %v", name, sub.err))
+		}
+	}
+	return methods
+}
+
+func (d *decoder) patternPairs(name string) []*ast.HashPatternPair {
+	var pairs []*ast.HashPatternPair
+	for _, data := range d.list(name) {
+		sub := d.sub(name, data)
+		pairs = append(pairs, &ast.HashPatternPair{Key: sub.expression("key"), Value: sub.pattern("value")})
+		if sub.err != nil {
+			d.fail(fmt.Errorf("field %q: %v", name, sub.err))
+		}
+	}
+	return pairs
+}
@@ -0,0 +1,122 @@
+package astjson
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/wmolicki/go-monkey/ast"
+	"github.com/wmolicki/go-monkey/lexer"
+	"github.com/wmolicki/go-monkey/parser"
+)
+
+func TestRoundTrip(t *testing.T) {
+	tests := []string{
+		"let x = 5;",
+		"return 5 + 6;",
+		"-a * !b",
+		"a + b * c == d",
+		`"hello" + "world"`,
+		"if (x < y) { x } else { y }",
+		"if (x) { 1 }",
+		"fn(x, y) { return x + y; }(1, 2)",
+		"fn() {}",
+		"let m = macro(a, b) { quote(unquote(a) + unquote(b)) };",
+		"[1, 2 * 3, [4]][0]",
+		`{"one": 1, true: 2, 3: fn(x) { x }}`,
+		"{}",
+		`#{1, "two", [3]}`,
+		"#{}",
+		"for (let i = 0; i < 10; let i = i + 1) { puts(i) }",
+		`try { throw error("boom", "Custom") } catch (e) { e["message"] } finally { puts("done") }`,
+		"try { 1 } catch { 2 }",
+		"fn() { yield 1; yield [2]; }",
+		"try { 1 } finally { 2 }",
+		"struct Point { x, y fn add(self, o) { Point(self.x + o.x, self.y + o.y) } }; Point(1, 2).add(Point(3, 4)).x",
+		"struct Empty {}",
+		"p.x = p.y = 3",
+		"let c = chan(1); spawn worker(c, 2); spawn fn() { send(c, 1) }",
+		"select { let v = recv(a) => v, recv(b) => 1, send(c, 2) => 3, _ => 4 }",
+		"select {}",
+		`match (x) { 0 => "zero", -1 => "minus", [a, ...rest] if a > 1 => rest, [..._] => 1, {"k": [_, b]} => b, n => n }`,
+	}
+
+	script, err := os.ReadFile("../test.monke")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests = append(tests, string(script))
+
+	for _, input := range tests {
+		program := parse(t, input)
+
+		encoded, err := Marshal(program)
+		if err != nil {
+			t.Fatalf("%q: Marshal failed: %v", input, err)
+		}
+		if !json.Valid(encoded) {
+			t.Fatalf("%q: Marshal returned invalid JSON: %s", input, encoded)
+		}
+
+		decoded, err := UnmarshalProgram(encoded)
+		if err != nil {
+			t.Fatalf("%q: Unmarshal failed: %v", input, err)
+		}
+		if decoded.String() != program.String() {
+			t.Errorf("%q: decoded program differs, expected %q, got=%q",
+				input, program.String(), decoded.String())
+		}
+
+		reencoded, err := Marshal(decoded)
+		if err != nil {
+			t.Fatalf("%q: Marshal of decoded program failed: %v", input, err)
+		}
+		if string(reencoded) != string(encoded) {
+			t.Errorf("%q: encoding is not lossless, expected:\n%s\ngot:\n%s", input, encoded, reencoded)
+		}
+	}
+}
+
+func TestMarshalNode(t *testing.T) {
+	program := parse(t, "-x")
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+
+	encoded, err := Marshal(stmt.Expression)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"kind":"PrefixExpression","pos":{"line":1,"column":1},` +
+		`"token":{"type":"-","literal":"-"},"operator":"-",` +
+		`"right":{"kind":"Identifier","pos":{"line":1,"column":2},` +
+		`"token":{"type":"IDENT","literal":"x"},"value":"x"}}`
+	if string(encoded) != expected {
+		t.Errorf("wrong encoding, expected:\n%s\ngot:\n%s", expected, encoded)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []string{
+		`{"kind": "Nope", "pos": {"line": 0, "column": 0}, "token": {"type": "", "literal": ""}}`,
+		`{"kind": "Program", "pos": {"line": 0, "column": 0}, "statements": [{"kind": "Identifier"}]}`,
+		`{"kind": "LetStatement", "pos": {"line": 1, "column": 1}, "token": {"type": "LET", "literal": "let"},
+		  "name": {"kind": "Boolean", "pos": {"line": 1, "column": 5}, "token": {"type": "true", "literal": "true"}, "value": true},
+		  "value": null}`,
+		`[]`,
+	}
+
+	for _, input := range tests {
+		if _, err := Unmarshal([]byte(input)); err == nil {
+			t.Errorf("expected an error decoding %s", input)
+		}
+	}
+}
+
+func parse(t *testing.T, input string) *ast.Program {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+	return program
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/wmolicki/go-monkey/evaluator"
+	"github.com/wmolicki/go-monkey/lexer"
+	"github.com/wmolicki/go-monkey/parser"
+	"github.com/wmolicki/go-monkey/resolver"
+)
+
+// runCheck implements `monke check (-e expr | files...)`, it reports
+// syntax errors and undefined names without running the programs.
+func runCheck(args []string) int {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	expr := fs.String("e", "", "check expr instead of files")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: monke check (-e expr | files...)\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if (*expr == "") == (fs.NArg() == 0) {
+		fs.Usage()
+		return exitUsage
+	}
+
+	if *expr != "" {
+		return printCheckErrors("-e", check(*expr))
+	}
+
+	status := exitOK
+	for _, filename := range fs.Args() {
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", filename, err)
+			status = exitError
+			continue
+		}
+		if printCheckErrors(filename, check(string(src))) != exitOK {
+			status = exitError
+		}
+	}
+	return status
+}
+
+// check returns syntax errors of src, or if there are none, uses
+// of undefined names.
+func check(src string) []string {
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return p.Errors()
+	}
+	// args is defined by `monke run`
+	universe := append(evaluator.BuiltinNames(), "args")
+	return resolver.Resolve(program, universe).Errors
+}
+
+func printCheckErrors(name string, errors []string) int {
+	for _, msg := range errors {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", name, msg)
+	}
+	if len(errors) > 0 {
+		return exitError
+	}
+	return exitOK
+}
@@ -0,0 +1,95 @@
+package evaluator
+
+import (
+	"strings"
+
+	"github.com/wmolicki/go-monkey/object"
+)
+
+func init() {
+	// assert_error calls functions, which refers back to builtins
+	builtins["assert"] = &object.Builtin{Fn: assert}
+	builtins["assert_eq"] = &object.Builtin{Fn: assertEqual}
+	builtins["assert_error"] = &object.Builtin{Fn: assertError}
+}
+
+// Apply calls fn with args, as a call expression would.
+func Apply(fn object.Object, args ...object.Object) object.Object {
+	return applyFunction(fn, args)
+}
+
+// assert raises an AssertionError if its first argument is not truthy,
+// the optional second argument describes the assertion.
+func assert(args ...object.Object) object.Object {
+	if len(args) < 1 || len(args) > 2 {
+		return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: 1 or 2", len(args))
+	}
+	if isTruthy(args[0]) {
+		return NULL
+	}
+	if len(args) == 2 {
+		return object.NewError(object.AssertionError, "assertion failed: %s", describeValue(args[1]))
+	}
+	return object.NewError(object.AssertionError, "assertion failed")
+}
+
+// assertEqual raises an AssertionError listing differences between its
+// arguments if they're not structurally equal.
+func assertEqual(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args), 2)
+	}
+	diff := object.Diff(args[0], args[1])
+	if diff == nil {
+		return NULL
+	}
+	return object.NewError(object.AssertionError, "values are not equal:\n%s", strings.Join(diff, "\n"))
+}
+
+// assertError calls a function without arguments and raises an
+// AssertionError unless it raises an error, of the given kind if there
+// is a second argument. The caught error is returned.
+func assertError(args ...object.Object) object.Object {
+	if len(args) < 1 || len(args) > 2 {
+		return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: 1 or 2", len(args))
+	}
+	switch args[0].(type) {
+	case *object.Function, *object.Builtin, *object.BoundMethod:
+	default:
+		return object.NewError(object.TypeError,
+			"argument to `assert_error` not supported, must be %s, got %s", object.FUNCTION_OBJ, args[0].Type())
+	}
+	kind := ""
+	if len(args) == 2 {
+		s, ok := args[1].(*object.String)
+		if !ok {
+			return object.NewError(object.TypeError,
+				"argument to `assert_error` not supported, must be %s, got %s", object.STRING_OBJ, args[1].Type())
+		}
+		kind = s.Value
+	}
+
+	result := applyFunction(args[0], nil)
+	err, ok := result.(*object.Error)
+	if !ok {
+		if isError(result) {
+			return result
+		}
+		return object.NewError(object.AssertionError, "expected an error, got %s", describeValue(result))
+	}
+	if kind != "" && err.ErrorKind() != kind {
+		return object.NewError(object.AssertionError, "expected %s, got %s: %s", kind, err.ErrorKind(), err.Message)
+	}
+	return &object.ErrorValue{Err: err}
+}
+
+// describeValue returns obj as it's written in assertion messages.
+func describeValue(obj object.Object) string {
+	if obj == nil {
+		return "null"
+	}
+	if s, ok := obj.(*object.String); ok {
+		return s.Value
+	}
+	return obj.Inspect()
+}
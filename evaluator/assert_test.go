@@ -0,0 +1,67 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/wmolicki/go-monkey/object"
+)
+
+func TestAssertions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"assert(true)", "null"},
+		{"assert(1, \"one\")", "null"},
+		{"assert_eq([1, {\"a\": 2}], [1, {\"a\": 2}])", "null"},
+		{"assert_eq(1 + 1, 2)", "null"},
+		{`assert_error(fn() { throw "boom" })`, "Error: boom"},
+		{`assert_error(fn() { 1 + true }, "TypeError")`, "TypeError: type mismatch: INTEGER + BOOLEAN"},
+		{`assert_error(fn() { foo })["kind"]`, "NameError"},
+		{`try { assert(false) } catch (e) { e["kind"] }`, "AssertionError"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestAssertionErrors(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedKind string
+		expected     string
+	}{
+		{"assert(false)", object.AssertionError, "assertion failed"},
+		{`assert(1 > 2, "1 is not greater than 2")`, object.AssertionError, "assertion failed: 1 is not greater than 2"},
+		{"assert()", object.ArgumentError, "wrong number of arguments, got: 0, want: 1 or 2"},
+		{"assert(true, 1, 2)", object.ArgumentError, "wrong number of arguments, got: 3, want: 1 or 2"},
+		{"assert_eq(1, 2)", object.AssertionError, "values are not equal:\ngot 1, want 2"},
+		{`assert_eq([1, {"a": [2]}], [1, {"a": [3], "b": 4}])`, object.AssertionError,
+			"values are not equal:\n[1][\"a\"][0]: got 2, want 3\n[1][\"b\"]: missing 4"},
+		{"assert_eq(1)", object.ArgumentError, "wrong number of arguments, got: 1, want: 2"},
+		{"assert_error(fn() { 1 })", object.AssertionError, "expected an error, got 1"},
+		{`assert_error(fn() { foo }, "TypeError")`, object.AssertionError, "expected TypeError, got NameError: identifier not found: foo"},
+		{"assert_error(fn() { 1 }, 1)", object.TypeError, "argument to `assert_error` not supported, must be STRING, got INTEGER"},
+		{"assert_error()", object.ArgumentError, "wrong number of arguments, got: 0, want: 1 or 2"},
+		{"assert_error(1)", object.TypeError, "argument to `assert_error` not supported, must be FUNCTION, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		err, ok := testEval(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("%s: no error returned", tt.input)
+			continue
+		}
+		if err.ErrorKind() != tt.expectedKind || err.Message != tt.expected {
+			t.Errorf("%s: wrong error.\nexpected=%s: %q\ngot=     %s: %q", tt.input, tt.expectedKind, tt.expected, err.ErrorKind(), err.Message)
+		}
+	}
+
+	if _, ok := testEval("assert_error(fn() { exit(1) })").(*Exit); !ok {
+		t.Errorf("assert_error didn't pass exit through")
+	}
+}
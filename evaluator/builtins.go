@@ -1,42 +1,60 @@
 package evaluator
 
 import (
-	"fmt"
+	"sort"
 	"unicode/utf8"
 
 	"github.com/wmolicki/go-monkey/object"
 )
 
+// BuiltinNames returns names of all builtin functions in sorted order.
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	// compare and sort call overloaded operators, which refer back to builtins
+	builtins["compare"] = &object.Builtin{Fn: compareValues}
+	builtins["sort"] = &object.Builtin{Fn: sortArray}
+}
+
 var builtins = map[string]*object.Builtin{
 	"len": &object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
 			if len(args) != 1 {
-				return newError("wrong number of arguments, got: %d, want: %d", len(args), 1)
+				return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args), 1)
 			}
 			switch arg := args[0].(type) {
 			case *object.String:
 				return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
 			case *object.Array:
-				return &object.Integer{Value: int64(len(arg.Elements))}
+				return &object.Integer{Value: int64(arg.Len())}
+			case *object.Set:
+				return &object.Integer{Value: int64(arg.Len())}
 			default:
-				return newError("argument to `len` not supported: %s", args[0].Type())
+				return object.NewError(object.TypeError, "argument to `len` not supported: %s", args[0].Type())
 			}
 		},
 	},
 	"first": &object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
 			if len(args) != 1 {
-				return newError("wrong number of arguments, got: %d, want: %d", len(args), 1)
+				return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args), 1)
 			}
 			if args[0].Type() != object.ARRAY_OBJ {
-				return newError(
+				return object.NewError(object.TypeError,
 					"argument to `first` not supported, must be %s, got %s", object.ARRAY_OBJ,
 					args[0].Type())
 
 			}
 			arr := args[0].(*object.Array)
-			if len(arr.Elements) > 0 {
-				return arr.Elements[0]
+			if arr.Len() > 0 {
+				return arr.At(0)
 			}
 
 			return NULL
@@ -45,17 +63,17 @@ var builtins = map[string]*object.Builtin{
 	"last": &object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
 			if len(args) != 1 {
-				return newError("wrong number of arguments, got: %d, want: %d", len(args), 1)
+				return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args), 1)
 			}
 			if args[0].Type() != object.ARRAY_OBJ {
-				return newError(
+				return object.NewError(object.TypeError,
 					"argument to `last` not supported, must be %s, got %s", object.ARRAY_OBJ,
 					args[0].Type())
 
 			}
 			arr := args[0].(*object.Array)
-			if len(arr.Elements) > 0 {
-				return arr.Elements[len(arr.Elements)-1]
+			if arr.Len() > 0 {
+				return arr.At(arr.Len() - 1)
 			}
 
 			return NULL
@@ -64,20 +82,17 @@ var builtins = map[string]*object.Builtin{
 	"rest": &object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
 			if len(args) != 1 {
-				return newError("wrong number of arguments, got: %d, want: %d", len(args), 1)
+				return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args), 1)
 			}
 			if args[0].Type() != object.ARRAY_OBJ {
-				return newError(
+				return object.NewError(object.TypeError,
 					"argument to `rest` not supported, must be %s, got %s", object.ARRAY_OBJ,
 					args[0].Type())
 
 			}
 			arr := args[0].(*object.Array)
-			length := len(arr.Elements)
-			if length > 0 {
-				newElems := make([]object.Object, length-1)
-				copy(newElems, arr.Elements[1:length])
-				return &object.Array{Elements: newElems}
+			if arr.Len() > 0 {
+				return arr.Rest()
 			}
 
 			return NULL
@@ -86,30 +101,151 @@ var builtins = map[string]*object.Builtin{
 	"push": &object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
 			if len(args) != 2 {
-				return newError("wrong number of arguments, got: %d, want: %d", len(args), 2)
+				return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args), 2)
 			}
 			if args[0].Type() != object.ARRAY_OBJ {
-				return newError(
+				return object.NewError(object.TypeError,
 					"argument to `push` not supported, must be %s, got %s", object.ARRAY_OBJ,
 					args[0].Type())
 
 			}
 			arr := args[0].(*object.Array)
-			length := len(arr.Elements)
-			newElems := make([]object.Object, length+1)
-			copy(newElems, arr.Elements)
-			newElems[length] = args[1]
 
-			return &object.Array{Elements: newElems}
+			return arr.Push(args[1])
 		},
 	},
-	"puts": &object.Builtin{
+	"puts": &object.Builtin{Fn: puts},
+	"error": &object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
-			for _, a := range args {
-				fmt.Print(a.Inspect())
+			if len(args) != 1 && len(args) != 2 {
+				return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: 1 or 2", len(args))
 			}
-			fmt.Print("\n")
-			return NULL
+			message, ok := args[0].(*object.String)
+			if !ok {
+				return object.NewError(object.TypeError,
+					"argument to `error` not supported, must be %s, got %s", object.STRING_OBJ,
+					args[0].Type())
+			}
+			kind := object.GenericError
+			if len(args) == 2 {
+				k, ok := args[1].(*object.String)
+				if !ok {
+					return object.NewError(object.TypeError,
+						"kind passed to `error` must be %s, got %s", object.STRING_OBJ,
+						args[1].Type())
+				}
+				kind = k.Value
+			}
+			return &object.ErrorValue{Err: &object.Error{Message: message.Value, Kind: kind}}
 		},
 	},
+	"type": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args), 1)
+			}
+			if instance, ok := args[0].(*object.StructInstance); ok {
+				return &object.String{Value: instance.StructType.Name}
+			}
+			return &object.String{Value: string(args[0].Type())}
+		},
+	},
+	"iter": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args), 1)
+			}
+			it, ok := iterate(args[0])
+			if !ok {
+				return object.NewError(object.TypeError, "argument to `iter` not supported, must be iterable, got %s", args[0].Type())
+			}
+			return it
+		},
+	},
+	"to_array": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args), 1)
+			}
+			if arr, ok := args[0].(*object.Array); ok {
+				return arr
+			}
+			it, ok := iterate(args[0])
+			if !ok {
+				return object.NewError(object.TypeError, "argument to `to_array` not supported, must be iterable, got %s", args[0].Type())
+			}
+			return toArray(it)
+		},
+	},
+	"set": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args), 1)
+			}
+			if len(args) == 0 {
+				return object.NewSet()
+			}
+			switch arg := args[0].(type) {
+			case *object.Array:
+				return newSet(arg.Elements())
+			case *object.Set:
+				return arg
+			default:
+				return object.NewError(object.TypeError,
+					"argument to `set` not supported, must be %s, got %s", object.ARRAY_OBJ,
+					args[0].Type())
+			}
+		},
+	},
+	"chan":  &object.Builtin{Fn: newChannel},
+	"send":  &object.Builtin{Fn: channelSend},
+	"recv":  &object.Builtin{Fn: channelReceive},
+	"close": &object.Builtin{Fn: channelClose},
+
+	"read_line": &object.Builtin{Fn: readLine},
+	"read_all":  &object.Builtin{Fn: readAll},
+	"exit":      &object.Builtin{Fn: exit},
+}
+
+// compareValues returns -1, 0 or 1 as its first argument is less than,
+// equal to or greater than the second.
+func compareValues(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args), 2)
+	}
+	result, err := compareObjects(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	return &object.Integer{Value: int64(result)}
+}
+
+// sortArray returns a sorted copy of an array, ordered by compareObjects.
+func sortArray(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args), 1)
+	}
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return object.NewError(object.TypeError,
+			"argument to `sort` not supported, must be %s, got %s", object.ARRAY_OBJ,
+			args[0].Type())
+	}
+
+	sorted := arr.Elements()
+
+	var err object.Object
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if err != nil {
+			return false
+		}
+		var result int
+		result, err = compareObjects(sorted[i], sorted[j])
+		return result < 0
+	})
+	if err != nil {
+		return err
+	}
+
+	return object.NewArray(sorted)
 }
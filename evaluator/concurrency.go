@@ -0,0 +1,175 @@
+package evaluator
+
+import (
+	"github.com/wmolicki/go-monkey/ast"
+	"github.com/wmolicki/go-monkey/object"
+)
+
+// evalSpawnExpression calls a function on a new goroutine and returns
+// a channel which receives its result. The function and its arguments
+// are evaluated before spawning, a value which is not a call is called
+// without arguments. An error is delivered to the channel as well, so
+// receiving the result raises it.
+func evalSpawnExpression(se *ast.SpawnExpression, env *object.Environment) object.Object {
+	var function object.Object
+	var args []object.Object
+
+	call, isCall := se.Value.(*ast.CallExpression)
+	if isCall {
+		function = Eval(call.Function, env)
+		if isError(function) {
+			return function
+		}
+		args = evalExpressions(call.Arguments, env)
+		if len(args) == 1 && isError(args[0]) {
+			return args[0]
+		}
+	} else {
+		function = Eval(se.Value, env)
+		if isError(function) {
+			return function
+		}
+	}
+
+	result := object.NewChannel(1)
+	go func() {
+		value := applyFunction(function, args)
+		if err, ok := value.(*object.Error); ok && isCall && isUserFunction(function) {
+			err.Stack = append(err.Stack, callFrame(call))
+		}
+		if value == nil {
+			value = NULL
+		}
+		result.Send(value)
+		result.Close()
+	}()
+	return result
+}
+
+func evalSelectExpression(se *ast.SelectExpression, env *object.Environment) object.Object {
+	cases := []object.SelectCase{}
+	// arms[i] is the case of cases[i]
+	arms := []*ast.SelectCase{}
+	var defaultCase *ast.SelectCase
+
+	for _, c := range se.Cases {
+		if c.IsDefault() {
+			defaultCase = c
+			continue
+		}
+
+		args := evalExpressions(c.Call.Arguments, env)
+		if len(args) == 1 && isError(args[0]) {
+			return args[0]
+		}
+		name := c.Call.Function.TokenLiteral()
+		ch, ok := args[0].(*object.Channel)
+		if !ok {
+			return object.NewError(object.TypeError,
+				"argument to `%s` not supported, must be %s, got %s", name, object.CHANNEL_OBJ, args[0].Type())
+		}
+
+		selectCase := object.SelectCase{Channel: ch}
+		if c.IsSend() {
+			selectCase.Send = args[1]
+		}
+		cases = append(cases, selectCase)
+		arms = append(arms, c)
+	}
+
+	if len(cases) == 0 && defaultCase == nil {
+		return object.NewError(object.ChannelError, "select without cases blocks forever")
+	}
+
+	chosen, value, ok := object.Select(cases, defaultCase != nil)
+	if chosen < 0 {
+		return Eval(defaultCase.Body, object.NewEnclosedEnvironment(env))
+	}
+
+	arm := arms[chosen]
+	if arm.IsSend() {
+		if !ok {
+			return object.NewError(object.ChannelError, "send on closed channel")
+		}
+		return Eval(arm.Body, object.NewEnclosedEnvironment(env))
+	}
+
+	if !ok {
+		value = NULL
+	}
+	if isError(value) {
+		return value
+	}
+	// the binding is only visible in the body of the case
+	caseEnv := object.NewEnclosedEnvironment(env)
+	if arm.Binding != nil {
+		caseEnv.Set(arm.Binding.Value, value)
+	}
+	return Eval(arm.Body, caseEnv)
+}
+
+// channelArgument returns the channel passed as the first argument to
+// the builtin name.
+func channelArgument(name string, args []object.Object, want int) (*object.Channel, *object.Error) {
+	if len(args) != want {
+		return nil, object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args), want)
+	}
+	ch, ok := args[0].(*object.Channel)
+	if !ok {
+		return nil, object.NewError(object.TypeError,
+			"argument to `%s` not supported, must be %s, got %s", name, object.CHANNEL_OBJ, args[0].Type())
+	}
+	return ch, nil
+}
+
+func newChannel(args ...object.Object) object.Object {
+	if len(args) > 1 {
+		return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args), 1)
+	}
+	if len(args) == 0 {
+		return object.NewChannel(0)
+	}
+	capacity, ok := args[0].(*object.Integer)
+	if !ok {
+		return object.NewError(object.TypeError,
+			"argument to `chan` not supported, must be %s, got %s", object.INTEGER_OBJ, args[0].Type())
+	}
+	if capacity.Value < 0 {
+		return object.NewError(object.ArgumentError, "negative channel capacity: %d", capacity.Value)
+	}
+	return object.NewChannel(int(capacity.Value))
+}
+
+func channelSend(args ...object.Object) object.Object {
+	ch, err := channelArgument("send", args, 2)
+	if err != nil {
+		return err
+	}
+	if !ch.Send(args[1]) {
+		return object.NewError(object.ChannelError, "send on closed channel")
+	}
+	return NULL
+}
+
+// channelReceive returns null once the channel is closed and drained.
+func channelReceive(args ...object.Object) object.Object {
+	ch, err := channelArgument("recv", args, 1)
+	if err != nil {
+		return err
+	}
+	if value, ok := ch.Receive(); ok {
+		return value
+	}
+	return NULL
+}
+
+func channelClose(args ...object.Object) object.Object {
+	ch, err := channelArgument("close", args, 1)
+	if err != nil {
+		return err
+	}
+	if !ch.Close() {
+		return object.NewError(object.ChannelError, "close of closed channel")
+	}
+	return NULL
+}
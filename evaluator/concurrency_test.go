@@ -0,0 +1,127 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/wmolicki/go-monkey/object"
+)
+
+func TestConcurrency(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"recv(spawn fn() { 1 + 2 })", "3"},
+		{"let add = fn(a, b) { a + b }; recv(spawn add(1, 2))", "3"},
+		{"let c = spawn len([1]); recv(c); recv(c)", "null"},
+		{"recv(spawn fn() { puts() })", "null"},
+		{"let c = chan(2); send(c, 1); send(c, 2); [recv(c), recv(c)]", "[1, 2]"},
+		{"let c = chan(1); send(c, 1); close(c); [recv(c), recv(c)]", "[1, null]"},
+		{"let c = chan(); spawn fn() { send(c, 5) }; recv(c)", "5"},
+		{"type(chan())", "CHANNEL"},
+		// iterating a channel receives values until it's closed
+		{"let c = chan(3); send(c, 1); send(c, 2); close(c); to_array(c)", "[1, 2]"},
+		{"let c = chan(); spawn fn() { send(c, 1); send(c, 2); close(c) }; iter(c).map(fn(x) { x * 10 }).to_array()", "[10, 20]"},
+		// arguments are evaluated before spawning
+		{"let x = 1; let c = spawn fn(y) { y }(x); let x = 2; recv(c)", "1"},
+		// the result of each worker is received in order of the results channel
+		{`let jobs = chan(10);
+		let results = chan(10);
+		let worker = fn() {
+			for (let job = recv(jobs); job; let job = recv(jobs)) {
+				send(results, job * job);
+			}
+		};
+		let workers = [spawn worker(), spawn worker(), spawn worker()];
+		for (let i = 1; i < 11; let i = i + 1) { send(jobs, i) };
+		close(jobs);
+		for (let i = 0; i < 3; let i = i + 1) { recv(workers[i]) };
+		close(results);
+		let sum = 0;
+		for (let r = recv(results); r; let r = recv(results)) { let sum = sum + r };
+		sum`, "385"},
+		{"let c = chan(1); send(c, 1); select { let v = recv(c) => v + 1 }", "2"},
+		{"let c = chan(1); select { recv(c) => 1, _ => 2 }", "2"},
+		{"let c = chan(1); select { recv(c) => 1, send(c, 3) => recv(c) }", "3"},
+		{"let c = chan(); close(c); select { let v = recv(c) => v }", "null"},
+		{"let c = chan(); select { let v = recv(c) => v, _ => 1 }; v", "ERROR: identifier not found: v"},
+		{"let a = chan(); let b = chan(); spawn fn() { send(b, 7) }; select { recv(a) => 0, let v = recv(b) => v }", "7"},
+		{"let c = chan(1); let f = fn() { select { recv(c) => 1, _ => if (true) { return 2 } }; 4 }; f()", "2"},
+		{`struct Point { x };
+		let p = Point(0);
+		let c = chan();
+		let w = fn() { for (let i = 0; i < 100; let i = i + 1) { p.x = p.x + 1 }; send(c, 1) };
+		spawn w(); spawn w(); recv(c); recv(c); p.x > 0`, "true"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil {
+			t.Errorf("%s: nil result", tt.input)
+			continue
+		}
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestConcurrencyErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedKind    string
+		expectedMessage string
+	}{
+		{"recv(spawn fn() { foo })", object.NameError, "identifier not found: foo"},
+		{`recv(spawn fn() { throw "boom" })`, object.GenericError, "boom"},
+		{"spawn foo()", object.NameError, "identifier not found: foo"},
+		{"spawn f(foo)", object.NameError, "identifier not found: f"},
+		{"recv(spawn 1)", object.TypeError, "not a function: INTEGER"},
+		{"let c = chan(); close(c); send(c, 1)", object.ChannelError, "send on closed channel"},
+		{"let c = chan(); close(c); close(c)", object.ChannelError, "close of closed channel"},
+		{"recv(1)", object.TypeError, "argument to `recv` not supported, must be CHANNEL, got INTEGER"},
+		{"send(chan(1))", object.ArgumentError, "wrong number of arguments, got: 1, want: 2"},
+		{`chan("a")`, object.TypeError, "argument to `chan` not supported, must be INTEGER, got STRING"},
+		{"chan(-1)", object.ArgumentError, "negative channel capacity: -1"},
+		{"select { recv(1) => 1 }", object.TypeError, "argument to `recv` not supported, must be CHANNEL, got INTEGER"},
+		{"select { send(foo, 1) => 1 }", object.NameError, "identifier not found: foo"},
+		{"let c = chan(); close(c); select { send(c, 1) => 1 }", object.ChannelError, "send on closed channel"},
+		{"select { let v = recv(spawn fn() { foo }) => v }", object.NameError, "identifier not found: foo"},
+		{"select {}", object.ChannelError, "select without cases blocks forever"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%s: no error object returned. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("%s: wrong error message. expected=%q, got=%q", tt.input, tt.expectedMessage, errObj.Message)
+		}
+		if errObj.ErrorKind() != tt.expectedKind {
+			t.Errorf("%s: wrong kind. expected=%q, got=%q", tt.input, tt.expectedKind, errObj.ErrorKind())
+		}
+	}
+}
+
+func TestChannelErrorsAreCatchable(t *testing.T) {
+	input := `let c = chan(); close(c);
+	try { send(c, 1) } catch (e) { e.kind + ": " + e.message }`
+
+	evaluated := testEval(input)
+	if evaluated.Inspect() != "ChannelError: send on closed channel" {
+		t.Errorf("wrong result. got=%s", evaluated.Inspect())
+	}
+}
+
+func TestSpawnedErrorsAreCatchable(t *testing.T) {
+	input := `let c = spawn fn() { throw "boom" };
+	try { recv(c) } catch (e) { e.message }`
+
+	evaluated := testEval(input)
+	if evaluated.Inspect() != "boom" {
+		t.Errorf("wrong result. got=%s", evaluated.Inspect())
+	}
+}
@@ -1,8 +1,6 @@
 package evaluator
 
 import (
-	"fmt"
-
 	"github.com/wmolicki/go-monkey/ast"
 	"github.com/wmolicki/go-monkey/object"
 )
@@ -64,7 +62,15 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		params := node.Parameters
 		body := node.Body
 		return &object.Function{Parameters: params, Body: body, Env: env}
+	case *ast.MacroLiteral:
+		return &object.Macro{Parameters: node.Parameters, Body: node.Body, Env: env}
 	case *ast.CallExpression:
+		if node.Function.TokenLiteral() == "quote" {
+			if len(node.Arguments) != 1 {
+				return object.NewError(object.ArgumentError, "wrong number of arguments to `quote`, got: %d, want: %d", len(node.Arguments), 1)
+			}
+			return quote(node.Arguments[0], env)
+		}
 		function := Eval(node.Function, env)
 		if isError(function) {
 			return function
@@ -73,7 +79,11 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if len(args) == 1 && isError(args[0]) {
 			return args[0]
 		}
-		return applyFunction(function, args)
+		result := applyFunction(function, args)
+		if err, ok := result.(*object.Error); ok && isUserFunction(function) {
+			err.Stack = append(err.Stack, callFrame(node))
+		}
+		return result
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
 	case *ast.ArrayLiteral:
@@ -81,7 +91,7 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if len(elems) == 1 && isError(elems[0]) {
 			return elems[0]
 		}
-		return &object.Array{Elements: elems}
+		return object.NewArray(elems)
 	case *ast.IndexExpression:
 		left := Eval(node.Left, env)
 		if isError(left) {
@@ -94,8 +104,28 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return evalIndexExpression(left, index)
 	case *ast.HashLiteral:
 		return evalHashLiteral(node, env)
+	case *ast.SetLiteral:
+		return evalSetLiteral(node, env)
 	case *ast.ForExpression:
 		return evalForExpression(node, env)
+	case *ast.MatchExpression:
+		return evalMatchExpression(node, env)
+	case *ast.ThrowStatement:
+		return evalThrowStatement(node, env)
+	case *ast.YieldStatement:
+		return evalYieldStatement(node, env)
+	case *ast.TryExpression:
+		return evalTryExpression(node, env)
+	case *ast.SpawnExpression:
+		return evalSpawnExpression(node, env)
+	case *ast.SelectExpression:
+		return evalSelectExpression(node, env)
+	case *ast.StructStatement:
+		return evalStructStatement(node, env)
+	case *ast.MemberExpression:
+		return evalMemberExpression(node, env)
+	case *ast.AssignExpression:
+		return evalAssignExpression(node, env)
 	}
 
 	return nil
@@ -116,6 +146,11 @@ func evalForExpression(fe *ast.ForExpression, env *object.Environment) object.Ob
 		}
 
 		returnVal = evalBlockStatment(fe.Body, env)
+		if returnVal != nil {
+			if rt := returnVal.Type(); rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+				return returnVal
+			}
+		}
 		loop := Eval(fe.Loop, env)
 		if isError(loop) {
 			return loop
@@ -129,29 +164,27 @@ func evalHashLiteral(
 	node *ast.HashLiteral,
 	env *object.Environment,
 ) object.Object {
-	pairs := make(map[object.HashKey]object.HashPair)
+	hash := object.NewHash()
 
-	for keyNode, valueNode := range node.Pairs {
+	for _, keyNode := range node.Keys() {
 		key := Eval(keyNode, env)
 		if isError(key) {
 			return key
 		}
 
-		hashKey, ok := key.(object.Hashable)
-		if !ok {
-			return newError("unhashable object used as key: %s", key.Type())
+		if _, ok := object.HashKeyOf(key); !ok {
+			return object.NewError(object.TypeError, "unhashable object used as key: %s", key.Type())
 		}
 
-		value := Eval(valueNode, env)
+		value := Eval(node.Pairs[keyNode], env)
 		if isError(value) {
 			return value
 		}
 
-		realHash := hashKey.HashKey()
-		pairs[realHash] = object.HashPair{Key: key, Value: value}
+		hash.Set(key, value)
 	}
 
-	return &object.Hash{Pairs: pairs}
+	return hash
 }
 
 func evalIndexExpression(left, index object.Object) object.Object {
@@ -160,53 +193,77 @@ func evalIndexExpression(left, index object.Object) object.Object {
 		return evalArrayIndexExpression(left, index)
 	case left.Type() == object.HASH_OBJ:
 		return evalHashIndexExpression(left, index)
+	case left.Type() == object.ERROR_VALUE_OBJ:
+		return evalErrorValueIndexExpression(left, index)
 	default:
-		return newError("index operator not supported: %s", left.Type())
+		return object.NewError(object.TypeError, "index operator not supported: %s", left.Type())
 	}
 }
 
 func evalHashIndexExpression(left, index object.Object) object.Object {
 	hashObject := left.(*object.Hash)
 
-	key, ok := index.(object.Hashable)
-	if !ok {
-		return newError("unhashable object used as key: %s", index.Type())
+	if _, ok := object.HashKeyOf(index); !ok {
+		return object.NewError(object.TypeError, "unhashable object used as key: %s", index.Type())
 	}
 
-	pair, ok := hashObject.Pairs[key.HashKey()]
+	value, ok := hashObject.Get(index)
 	if !ok {
 		return NULL
 	}
 
-	return pair.Value
+	return value
 }
 
 func evalArrayIndexExpression(left, index object.Object) object.Object {
 	arrayObject := left.(*object.Array)
 	idx := index.(*object.Integer).Value
-	max := int64(len(arrayObject.Elements) - 1)
+	max := int64(arrayObject.Len() - 1)
 
 	if idx < -1 || idx > max {
 		return NULL
 	}
 
 	if idx == -1 {
-		return arrayObject.Elements[max]
+		return arrayObject.At(int(max))
 	}
 
-	return arrayObject.Elements[idx]
+	return arrayObject.At(int(idx))
 }
 
 func applyFunction(fun object.Object, args []object.Object) object.Object {
 	switch fun := fun.(type) {
 	case *object.Function:
+		if len(args) < len(fun.Parameters) {
+			return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d",
+				len(args), len(fun.Parameters))
+		}
 		extendedEnv := extendFunctionEnv(fun, args)
+		if isGenerator(fun.Body) {
+			return newGenerator(fun.Body, extendedEnv)
+		}
 		evaluated := Eval(fun.Body, extendedEnv)
 		return unwrapReturnValue(evaluated)
 	case *object.Builtin:
 		return fun.Fn(args...)
+	case *object.BoundMethod:
+		return applyFunction(fun.Method, append([]object.Object{fun.Receiver}, args...))
+	case *object.StructType:
+		return newStructInstance(fun, args)
 	default:
-		return newError("not a function: %s", fun.Type())
+		return object.NewError(object.TypeError, "not a function: %s", fun.Type())
+	}
+}
+
+// isUserFunction reports whether fun is defined in Monkey code.
+func isUserFunction(fun object.Object) bool {
+	switch fun := fun.(type) {
+	case *object.Function:
+		return true
+	case *object.BoundMethod:
+		return isUserFunction(fun.Method)
+	default:
+		return false
 	}
 }
 
@@ -249,7 +306,7 @@ func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
 	if builtin, ok := builtins[node.Value]; ok {
 		return builtin
 	}
-	return newError("identifier not found: " + node.Value)
+	return object.NewError(object.NameError, "identifier not found: %s", node.Value)
 }
 
 func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
@@ -281,42 +338,39 @@ func isTruthy(obj object.Object) bool {
 }
 
 func evalInfixExpression(operator string, left, right object.Object) object.Object {
+	if result, ok := evalOverloadedInfix(operator, left, right); ok {
+		return result
+	}
+
 	switch {
-	case left.Type() != right.Type():
-		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
 		return evalIntegerInfixExpression(operator, left, right)
-	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
-		return evalStringInfixExpression(operator, left, right)
 	case operator == "==":
-		// comparing pointers here works, because L and R are booleans,
-		// and we keep them as singleton objects TRUE FALSE
-		return nativeBoolToBooleanObject(left == right)
+		return nativeBoolToBooleanObject(object.Equal(left, right))
 	case operator == "!=":
-		return nativeBoolToBooleanObject(left != right)
-	default:
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
-	}
-}
-
-func evalStringInfixExpression(operator string, left, right object.Object) object.Object {
-	switch operator {
-	case "==":
-		leftVal := left.(*object.String).Value
-		rightVal := right.(*object.String).Value
-		return nativeBoolToBooleanObject(leftVal == rightVal)
-	case "!=":
-		leftVal := left.(*object.String).Value
-		rightVal := right.(*object.String).Value
-		return nativeBoolToBooleanObject(leftVal != rightVal)
-	case "+":
+		return nativeBoolToBooleanObject(!object.Equal(left, right))
+	case left.Type() != right.Type():
+		return object.NewError(object.TypeError, "type mismatch: %s %s %s", left.Type(), operator, right.Type())
+	case left.Type() == object.STRING_OBJ && operator == "+":
 		leftVal := left.(*object.String).Value
 		rightVal := right.(*object.String).Value
 		return &object.String{Value: leftVal + rightVal}
+	case operator == "<" || operator == ">":
+		return evalComparison(operator, left, right)
 	default:
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+		return object.NewError(object.TypeError, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
+}
 
+func evalComparison(operator string, left, right object.Object) object.Object {
+	result, ok := object.Compare(left, right)
+	if !ok {
+		return object.NewError(object.TypeError, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+	if operator == "<" {
+		return nativeBoolToBooleanObject(result < 0)
+	}
+	return nativeBoolToBooleanObject(result > 0)
 }
 
 func evalIntegerInfixExpression(operator string, left, right object.Object) object.Object {
@@ -331,6 +385,9 @@ func evalIntegerInfixExpression(operator string, left, right object.Object) obje
 	case "*":
 		return &object.Integer{Value: leftVal * rightVal}
 	case "/":
+		if rightVal == 0 {
+			return object.NewError(object.ZeroDivisionError, "division by zero")
+		}
 		return &object.Integer{Value: leftVal / rightVal}
 	case "<":
 		return nativeBoolToBooleanObject(leftVal < rightVal)
@@ -341,7 +398,7 @@ func evalIntegerInfixExpression(operator string, left, right object.Object) obje
 	case "!=":
 		return nativeBoolToBooleanObject(leftVal != rightVal)
 	default:
-		return newError("unknown operator: %s %s %s",
+		return object.NewError(object.TypeError, "unknown operator: %s %s %s",
 			left.Type(), operator, right.Type())
 	}
 }
@@ -353,13 +410,13 @@ func evalPrefixExpression(operator string, right object.Object) object.Object {
 	case "-":
 		return evalMinusPrefixOperatorExpression(right)
 	default:
-		return newError("unknown operator: %s%s", operator, right.Type())
+		return object.NewError(object.TypeError, "unknown operator: %s%s", operator, right.Type())
 	}
 }
 
 func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
 	if right.Type() != object.INTEGER_OBJ {
-		return newError("unknown operator: -%s", right.Type())
+		return object.NewError(object.TypeError, "unknown operator: -%s", right.Type())
 	}
 	value := right.(*object.Integer).Value
 	return &object.Integer{Value: -value}
@@ -394,7 +451,7 @@ func evalProgram(program *ast.Program, env *object.Environment) object.Object {
 		switch result := result.(type) {
 		case *object.ReturnValue:
 			return result.Value
-		case *object.Error:
+		case *object.Error, *Exit:
 			return result
 		}
 	}
@@ -419,10 +476,6 @@ func evalBlockStatment(block *ast.BlockStatement, env *object.Environment) objec
 	return result
 }
 
-func newError(format string, a ...interface{}) *object.Error {
-	return &object.Error{Message: fmt.Sprintf(format, a...)}
-}
-
 func isError(obj object.Object) bool {
 	if obj != nil {
 		return obj.Type() == object.ERROR_OBJ
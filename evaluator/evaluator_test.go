@@ -180,6 +180,7 @@ func TestErrorHandling(t *testing.T) {
 		{"5 + true;", "type mismatch: INTEGER + BOOLEAN"},
 		{"5 + true; 5;", "type mismatch: INTEGER + BOOLEAN"},
 		{"-true", "unknown operator: -BOOLEAN"},
+		{"10 / (5 - 5)", "division by zero"},
 		{"true + false", "unknown operator: BOOLEAN + BOOLEAN"},
 		{
 			"5; true + false; 5",
@@ -367,6 +368,12 @@ func TestBuiltinFunctions(t *testing.T) {
 		{`len(1)`, "argument to `len` not supported: INTEGER"},
 		{`len()`, "wrong number of arguments, got: 0, want: 1"},
 		{`len("one", "two")`, "wrong number of arguments, got: 2, want: 1"},
+		{`first(rest(rest([1, 2, 3])))`, 3},
+		{`len(rest([1]))`, 0},
+		{`last(push([1, 2], 3))`, 3},
+		// push and rest don't modify their argument
+		{`let a = [1, 2]; let b = push(a, 3); let c = push(a, 4); b[2] * 10 + c[2] + len(a) * 100`, 234},
+		{`let a = [1, 2]; rest(a); len(a)`, 2},
 	}
 
 	for _, tt := range tests {
@@ -395,13 +402,13 @@ func TestArrayLiterals(t *testing.T) {
 	if !ok {
 		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
 	}
-	if len(result.Elements) != 3 {
+	if result.Len() != 3 {
 		t.Fatalf("array has wrong num of elements. got=%d",
-			len(result.Elements))
+			result.Len())
 	}
-	testIntegerObject(t, result.Elements[0], 1)
-	testIntegerObject(t, result.Elements[1], 4)
-	testIntegerObject(t, result.Elements[2], 6)
+	testIntegerObject(t, result.At(0), 1)
+	testIntegerObject(t, result.At(1), 4)
+	testIntegerObject(t, result.At(2), 6)
 }
 
 func TestArrayIndexExpressions(t *testing.T) {
@@ -477,23 +484,30 @@ false: 6
 	if !ok {
 		t.Fatalf("Eval didn't return Hash. got=%T (%+v)", evaluated, evaluated)
 	}
-	expected := map[object.HashKey]int64{
-		(&object.String{Value: "one"}).HashKey():   1,
-		(&object.String{Value: "two"}).HashKey():   2,
-		(&object.String{Value: "three"}).HashKey(): 3,
-		(&object.Integer{Value: 4}).HashKey():      4,
-		TRUE.HashKey():                             5,
-		FALSE.HashKey():                            6,
-	}
-	if len(result.Pairs) != len(expected) {
-		t.Fatalf("Hash has wrong num of pairs. got=%d", len(result.Pairs))
-	}
-	for expectedKey, expectedValue := range expected {
-		pair, ok := result.Pairs[expectedKey]
+	expected := []struct {
+		key   object.Object
+		value int64
+	}{
+		{&object.String{Value: "one"}, 1},
+		{&object.String{Value: "two"}, 2},
+		{&object.String{Value: "three"}, 3},
+		{&object.Integer{Value: 4}, 4},
+		{TRUE, 5},
+		{FALSE, 6},
+	}
+	if result.Len() != len(expected) {
+		t.Fatalf("Hash has wrong num of pairs. got=%d", result.Len())
+	}
+	for i, pair := range result.Pairs() {
+		if !object.Equal(pair.Key, expected[i].key) {
+			t.Errorf("pairs[%d] has wrong key, expected %s, got=%s", i, expected[i].key.Inspect(), pair.Key.Inspect())
+		}
+		value, ok := result.Get(expected[i].key)
 		if !ok {
-			t.Errorf("no pair for given key in Pairs")
+			t.Errorf("no pair for key %s", expected[i].key.Inspect())
+			continue
 		}
-		testIntegerObject(t, pair.Value, expectedValue)
+		testIntegerObject(t, value, expected[i].value)
 	}
 }
 
@@ -551,6 +565,14 @@ func TestForLoopExpressions(t *testing.T) {
 			`let x = 0; for (let i = 0; i < 10; let i = i + 1) { let x = x + 1 }; x`,
 			10,
 		},
+		{
+			`let f = fn() { for (let i = 0; true; let i = i + 1) { if (i == 3) { return i } } }; f()`,
+			3,
+		},
+		{
+			`try { for (let i = 0; true; let i = i + 1) { throw i } } catch (e) { e.value + 5 }`,
+			5,
+		},
 	}
 
 	for _, tt := range tests {
@@ -567,3 +589,87 @@ func testEval(input string) object.Object {
 
 	return Eval(program, env)
 }
+
+func TestStructuralEquality(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"[1, 2] == [1, 2]", true},
+		{"[1, 2] != [1, 2]", false},
+		{"[1, [2, 3]] == [1, [2, 3]]", true},
+		{"[1, 2] == [2, 1]", false},
+		{`{"a": [1], "b": 2} == {"b": 2, "a": [1]}`, true},
+		{`{"a": 1} == {"a": 2}`, false},
+		{`1 == "1"`, false},
+		{`1 != "1"`, true},
+		{"[] == false", false},
+		{`"a" < "b"`, true},
+		{`"b" < "ab"`, false},
+		{`"abc" > "abb"`, true},
+		{"[1, 2] < [1, 3]", true},
+		{"[1, 2, 3] > [1, 2]", true},
+		{`["a"] < ["a", "b"]`, true},
+	}
+
+	for _, tt := range tests {
+		testBooleanObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestCompareAndSort(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"compare(1, 2)", "-1"},
+		{`compare("b", "a")`, "1"},
+		{"compare([1, 2], [1, 2])", "0"},
+		{"sort([3, 1, 2])", "[1, 2, 3]"},
+		{`sort(["b", "c", "a"])`, "[a, b, c]"},
+		{"[[2, 1], [1, 2], [1]].sort()", "[[1], [1, 2], [2, 1]]"},
+		{"sort([])", "[]"},
+		{`compare(1, "a")`, "ERROR: cannot compare INTEGER and STRING"},
+		{`sort([1, "a"])`, "ERROR: cannot compare STRING and INTEGER"},
+		{`[1, 2] < ["a"]`, "ERROR: unknown operator: ARRAY < ARRAY"},
+		{`{} < {}`, "ERROR: unknown operator: HASH < HASH"},
+		// values without a natural order are compared with their overloaded <
+		{"struct V { n fn __lt__(self, o) { self.n < o.n } }; sort([V(3), V(1), V(2)])", "[V{n: 1}, V{n: 2}, V{n: 3}]"},
+		{"struct V { n fn __lt__(self, o) { self.n < o.n } }; [V(2), V(1)].sort().map(fn(v) { v.n })", "[1, 2]"},
+		{"struct V { n fn __lt__(self, o) { self.n < o.n } }; [compare(V(1), V(2)), compare(V(2), V(1)), compare(V(1), V(1))]", "[-1, 1, 0]"},
+		{`struct V { n fn __lt__(self, o) { self.n < "a" } }; sort([V(1), V(2)])`, "ERROR: type mismatch: INTEGER < STRING"},
+		{"struct V { n }; compare(V(1), V(2))", "ERROR: cannot compare STRUCT and STRUCT"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestCompositeHashKeys(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{[1, 2]: "a"}[[1, 2]]`, "a"},
+		{`let x = 1; let y = 2; {[x, y]: "a"}[[1, 2]]`, "a"},
+		{`{[1, 2]: "a"}[[2, 1]]`, "null"},
+		{`{[1, [2, "b"]]: "a"}[[1, [2, "b"]]]`, "a"},
+		{`{[]: "empty"}[[]]`, "empty"},
+		{`let h = {[0, 0]: "origin", [1, 0]: "east"}; h[[1, 0]]`, "east"},
+		{`{1: "a", [1]: "b"}[[1]]`, "b"},
+		{`{"b": 1, "a": 2, 3: 3}.keys()`, "[b, a, 3]"},
+		{`{[fn(x) { x }]: 1}`, "ERROR: unhashable object used as key: ARRAY"},
+		{`{}[[{}]]`, "ERROR: unhashable object used as key: ARRAY"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
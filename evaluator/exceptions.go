@@ -0,0 +1,96 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/wmolicki/go-monkey/ast"
+	"github.com/wmolicki/go-monkey/object"
+)
+
+func evalThrowStatement(ts *ast.ThrowStatement, env *object.Environment) object.Object {
+	val := Eval(ts.Value, env)
+	if isError(val) {
+		return val
+	}
+
+	if ev, ok := val.(*object.ErrorValue); ok {
+		// rethrowing a caught error keeps calls it already unwound through
+		err := *ev.Err
+		err.Stack = append([]string{}, ev.Err.Stack...)
+		return &err
+	}
+
+	return &object.Error{Message: val.Inspect(), Value: val}
+}
+
+// evalTryExpression evaluates the catch block if the body raised an error,
+// the catch block gets its own scope with the error bound. The finally
+// block is always evaluated, an error or return from it replaces the result.
+func evalTryExpression(te *ast.TryExpression, env *object.Environment) object.Object {
+	result := Eval(te.Body, env)
+
+	if err, ok := result.(*object.Error); ok && te.Catch != nil {
+		catchEnv := object.NewEnclosedEnvironment(env)
+		if te.CatchParameter != nil {
+			catchEnv.Set(te.CatchParameter.Value, &object.ErrorValue{Err: err})
+		}
+		result = Eval(te.Catch, catchEnv)
+	}
+
+	if result == errGeneratorExit {
+		return result
+	}
+
+	if te.Finally != nil {
+		finally := Eval(te.Finally, env)
+		if finally != nil {
+			rt := finally.Type()
+			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+				return finally
+			}
+		}
+	}
+
+	return result
+}
+
+// callFrame describes a call for the stack of an error unwinding through it.
+func callFrame(call *ast.CallExpression) string {
+	name := "fn"
+	switch function := call.Function.(type) {
+	case *ast.Identifier:
+		name = function.Value
+	case *ast.MemberExpression:
+		name = function.Member.Value
+	}
+	return fmt.Sprintf("%s (%s)", name, call.Function.Pos())
+}
+
+func evalErrorValueIndexExpression(left, index object.Object) object.Object {
+	err := left.(*object.ErrorValue).Err
+
+	field, ok := index.(*object.String)
+	if !ok {
+		return object.NewError(object.TypeError, "error fields are accessed by name, got: %s", index.Type())
+	}
+
+	switch field.Value {
+	case "message":
+		return &object.String{Value: err.Message}
+	case "kind":
+		return &object.String{Value: err.ErrorKind()}
+	case "stack":
+		stack := []object.Object{}
+		for _, frame := range err.Stack {
+			stack = append(stack, &object.String{Value: frame})
+		}
+		return object.NewArray(stack)
+	case "value":
+		if err.Value == nil {
+			return NULL
+		}
+		return err.Value
+	default:
+		return NULL
+	}
+}
@@ -0,0 +1,132 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/wmolicki/go-monkey/object"
+)
+
+func TestTryCatch(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"try { 1 } catch (e) { 2 }", 1},
+		{"try { throw 1; 2 } catch (e) { 3 }", 3},
+		{"try { throw 5 } catch (e) { e[\"value\"] }", 5},
+		{`try { throw "boom" } catch (e) { e["message"] }`, "boom"},
+		{`try { throw "boom" } catch (e) { e["kind"] }`, "Error"},
+		{`try { throw error("bad", "ValueError") } catch (e) { e["kind"] + ": " + e["message"] }`, "ValueError: bad"},
+		{`try { 1 + true } catch (e) { e["kind"] }`, "TypeError"},
+		{`try { foo } catch (e) { e["kind"] }`, "NameError"},
+		{`try { len(1, 2) } catch (e) { e["kind"] }`, "ArgumentError"},
+		{`try { 1 / 0 } catch (e) { e["kind"] }`, "ZeroDivisionError"},
+		{`try { foo } catch (e) { e["value"] }`, nil},
+		{`try { foo } catch (e) { e["unknown"] }`, nil},
+		{"try { throw 1 } catch { 2 }", 2},
+		// errors unwind through function calls
+		{"let f = fn() { throw 1; 2 }; try { f() } catch (e) { 3 }", 3},
+		// the value of a try without an error is the value of its body
+		{"try { 1 } finally { 2 }", 1},
+		{"let x = 1; try { let x = 2 } finally { let x = x + 1 }; x", 3},
+		// the caught error is only visible in the catch block
+		{"let e = 1; try { throw 2 } catch (e) { e }; e", 1},
+		// rethrowing
+		{`try { try { throw "a" } catch (e) { throw e } } catch (e) { e["message"] }`, "a"},
+		{`try { try { throw "a" } finally { 1 } } catch (e) { e["message"] }`, "a"},
+		// an error in finally replaces the result
+		{`try { try { 1 } finally { throw "b" } } catch (e) { e["message"] }`, "b"},
+		{"let f = fn() { try { return 1 } finally { 2 }; 3 }; f()", 1},
+		{"let f = fn() { try { 1 } finally { return 2 }; 3 }; f()", 2},
+		// error values don't unwind
+		{`let e = error("x"); 1`, 1},
+		{`error("x")["message"]`, "x"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			str, ok := evaluated.(*object.String)
+			if !ok {
+				t.Errorf("%s: object is not String. got=%T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if str.Value != expected {
+				t.Errorf("%s: wrong value. expected=%q, got=%q", tt.input, expected, str.Value)
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestThrowUncaught(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedKind  string
+		expectedStack []string
+	}{
+		{`throw "boom"`, "Error", []string{}},
+		{`throw error("bad", "ValueError")`, "ValueError", []string{}},
+		{
+			"let inner = fn() { throw 1 };\nlet outer = fn() { inner() };\nouter()",
+			"Error",
+			[]string{"inner (2:20)", "outer (3:1)"},
+		},
+		{
+			"let f = fn() { try { throw 1 } catch (e) { throw e } };\nfn() { f() }()",
+			"Error",
+			[]string{"f (2:8)", "fn (2:1)"},
+		},
+		{
+			"let f = fn() { 1 + true };\nf()",
+			"TypeError",
+			[]string{"f (2:1)"},
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		err, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%q: no error object returned. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if err.ErrorKind() != tt.expectedKind {
+			t.Errorf("%q: wrong kind. expected=%q, got=%q", tt.input, tt.expectedKind, err.ErrorKind())
+		}
+		if len(err.Stack) != len(tt.expectedStack) {
+			t.Errorf("%q: wrong stack. expected=%q, got=%q", tt.input, tt.expectedStack, err.Stack)
+			continue
+		}
+		for i, frame := range tt.expectedStack {
+			if err.Stack[i] != frame {
+				t.Errorf("%q: wrong stack. expected=%q, got=%q", tt.input, tt.expectedStack, err.Stack)
+				break
+			}
+		}
+	}
+}
+
+func TestBuiltinTypedErrors(t *testing.T) {
+	builtins["fail"] = &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			return object.NewError("IOError", "cannot read %s", args[0].Inspect())
+		},
+	}
+	defer delete(builtins, "fail")
+
+	input := `try { fail("file.txt") } catch (e) { e["kind"] + ": " + e["message"] }`
+
+	evaluated := testEval(input)
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+	}
+	if str.Value != "IOError: cannot read file.txt" {
+		t.Errorf("wrong value. got=%q", str.Value)
+	}
+}
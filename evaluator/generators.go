@@ -0,0 +1,151 @@
+package evaluator
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/wmolicki/go-monkey/ast"
+	"github.com/wmolicki/go-monkey/object"
+)
+
+// generator runs the body of a generator function in its own goroutine.
+// The goroutine and the consumer hand control to each other over
+// channels, so only one of them runs at a time.
+//
+// It's bound to yield in the environment of the body, scripts can't
+// refer to it as yield is a keyword.
+type generator struct {
+	values chan object.Object
+	resume chan struct{}
+	// done is closed when the consumer won't read more values
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (g *generator) Type() object.ObjectType { return "GENERATOR" }
+func (g *generator) Inspect() string         { return "generator" }
+
+func (g *generator) close() {
+	g.closeOnce.Do(func() { close(g.done) })
+}
+
+func (g *generator) run(body *ast.BlockStatement, env *object.Environment) {
+	defer close(g.values)
+
+	result := Eval(body, env)
+	if isError(result) && result != errGeneratorExit {
+		select {
+		case g.values <- result:
+		case <-g.done:
+		}
+	}
+}
+
+// generatorExit unwinds the body of a generator closed before it
+// finished. It can't be caught, and finally blocks are not evaluated,
+// as the body may then run concurrently with its former consumer.
+type generatorExit struct{}
+
+func (ge *generatorExit) Type() object.ObjectType { return object.ERROR_OBJ }
+func (ge *generatorExit) Inspect() string         { return "ERROR: generator closed" }
+
+var errGeneratorExit = &generatorExit{}
+
+var generatorBodies sync.Map // *ast.BlockStatement -> bool
+
+// isGenerator reports whether body has a yield statement outside
+// of nested function literals.
+func isGenerator(body *ast.BlockStatement) bool {
+	if cached, ok := generatorBodies.Load(body); ok {
+		return cached.(bool)
+	}
+
+	found := false
+	ast.Inspect(body, func(node ast.Node) bool {
+		switch node.(type) {
+		case *ast.YieldStatement:
+			found = true
+		case *ast.FunctionLiteral, *ast.MacroLiteral:
+			return false
+		}
+		return !found
+	})
+
+	generatorBodies.Store(body, found)
+	return found
+}
+
+// newGenerator returns an iterator over values yielded by body, which
+// starts running on the first read.
+//
+// The goroutine running body is stopped when the iterator is closed,
+// or garbage collected while body waits at a yield. An iterator which
+// stays reachable from its own body, e.g. through a variable in the
+// scope where the generator function was defined, is only stopped by
+// closing it.
+func newGenerator(body *ast.BlockStatement, env *object.Environment) *object.Iterator {
+	g := &generator{
+		values: make(chan object.Object),
+		resume: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	env.Set("yield", g)
+
+	started, finished := false, false
+
+	next := func() (object.Object, bool) {
+		select {
+		case <-g.done:
+			return nil, false
+		default:
+		}
+		if finished {
+			return nil, false
+		}
+
+		if !started {
+			started = true
+			go g.run(body, env)
+		} else {
+			g.resume <- struct{}{}
+		}
+
+		value, ok := <-g.values
+		if !ok || isError(value) {
+			finished = true
+		}
+		return value, ok
+	}
+
+	it := object.NewIterator(next, g.close)
+	// the goroutine doesn't refer to it, so it can be collected while
+	// the goroutine waits
+	runtime.SetFinalizer(it, (*object.Iterator).Close)
+	return it
+}
+
+func evalYieldStatement(ys *ast.YieldStatement, env *object.Environment) object.Object {
+	val := Eval(ys.Value, env)
+	if isError(val) {
+		return val
+	}
+
+	obj, _ := env.Get("yield")
+	g, ok := obj.(*generator)
+	if !ok {
+		return object.NewError(object.SyntaxError, "yield outside generator")
+	}
+
+	select {
+	case g.values <- val:
+	case <-g.done:
+		return errGeneratorExit
+	}
+
+	select {
+	case <-g.resume:
+		return nil
+	case <-g.done:
+		return errGeneratorExit
+	}
+}
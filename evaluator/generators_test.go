@@ -0,0 +1,158 @@
+package evaluator
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/wmolicki/go-monkey/ast"
+	"github.com/wmolicki/go-monkey/object"
+)
+
+const naturals = `let naturals = fn() {
+	for (let i = 0; true; let i = i + 1) {
+		yield i;
+	}
+};
+`
+
+func TestGenerators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let g = fn() { yield 1; yield 2; }; to_array(g())", "[1, 2]"},
+		{"let g = fn(n) { yield n; yield n * 2; }; g(3).to_array()", "[3, 6]"},
+		{"let g = fn() { yield 1; return 5; yield 2; }; to_array(g())", "[1]"},
+		{"let g = fn() { if (false) { yield 1 } }; to_array(g())", "[]"},
+		{"let g = fn() { yield 1; yield 2; }; let it = g(); it.next(); it.next()", "2"},
+		{"let g = fn() { yield 1 }; let it = g(); it.next(); it.next()", "null"},
+		{"let g = fn() { yield 1 }; let it = g(); it.next(); it.next(); it.next()", "null"},
+		// the body doesn't run until the first value is read
+		{"let g = fn() { puts(x); yield 1 }; g(); 1", "1"},
+		// nested functions with yield are generators themselves
+		{"let g = fn() { let h = fn() { yield 1 }; yield h().next() + 1 }; to_array(g())", "[2]"},
+		// methods can be generators too
+		{"struct R { n fn count(self) { for (let i = 0; i < self.n; let i = i + 1) { yield i } } }; R(3).count().to_array()", "[0, 1, 2]"},
+		{"let g = fn() { let x = 1; yield x; let x = x + 1; yield x; }; to_array(g())", "[1, 2]"},
+		{"let g = fn() { try { yield 1; throw 2 } catch (e) { yield e.value } }; to_array(g())", "[1, 2]"},
+		{naturals + "naturals().take(5).to_array()", "[0, 1, 2, 3, 4]"},
+		{naturals + "naturals().map(fn(x) { x * x }).take(4).to_array()", "[0, 1, 4, 9]"},
+		{naturals + "naturals().filter(fn(x) { x > 2 }).take(2).to_array()", "[3, 4]"},
+		{naturals + "naturals().drop(3).take(2).to_array()", "[3, 4]"},
+		{naturals + "naturals().take_while(fn(x) { x < 3 }).to_array()", "[0, 1, 2]"},
+		{naturals + `naturals().zip(["a", "b"]).to_array()`, "[[0, a], [1, b]]"},
+		{naturals + "naturals().zip(naturals().drop(1)).take(2).to_array()", "[[0, 1], [1, 2]]"},
+		{naturals + "let it = naturals(); it.next(); it.close(); it.next()", "null"},
+		{"iter([1, 2, 3]).map(fn(x) { x + 1 }).to_array()", "[2, 3, 4]"},
+		{"to_array(iter(#{1, 2}))", "[1, 2]"},
+		{"to_array([1])", "[1]"},
+		{"to_array(#{1, 2})", "[1, 2]"},
+		{"iter([1, 2]).take(0).to_array()", "[]"},
+		{"iter([1, 2]).drop(5).to_array()", "[]"},
+		{"type(iter([]))", "ITERATOR"},
+		// combinators work on any iterable, arrays keep eager map and filter
+		{"[1, 2, 3, 4].take_while(fn(x) { x < 3 }).to_array()", "[1, 2]"},
+		{"[1, 2, 3].drop(1).take(1).to_array()", "[2]"},
+		{`[1, 2].zip(["a", "b", "c"]).to_array()`, "[[1, a], [2, b]]"},
+		{"#{1, 2}.map(fn(x) { x * 2 }).to_array()", "[2, 4]"},
+		{"#{1, 2}.filter(fn(x) { x > 1 }).to_array()", "[2]"},
+		{"type([1].map(fn(x) { x }))", "ARRAY"},
+		{"let c = chan(2); send(c, 1); send(c, 2); close(c); c.map(fn(x) { x * 3 }).to_array()", "[3, 6]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if isError(evaluated) {
+			t.Errorf("%s: unexpected error: %s", tt.input, evaluated.Inspect())
+			continue
+		}
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestGeneratorErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"let g = fn() { yield 1; foo }; to_array(g())", "identifier not found: foo"},
+		{"let g = fn() { yield foo }; g().next()", "identifier not found: foo"},
+		{"let g = fn() { throw 1; yield 2 }; g().next()", "1"},
+		{"iter([1, 2]).map(fn(x) { x + true }).to_array()", "type mismatch: INTEGER + BOOLEAN"},
+		{"iter([1, 2]).filter(fn(x) { foo }).to_array()", "identifier not found: foo"},
+		{`iter([1]).take("a")`, "argument to `take` not supported, must be INTEGER, got STRING"},
+		{"iter([1]).zip(1)", "argument to `zip` not supported, must be iterable, got INTEGER"},
+		{"iter(1)", "argument to `iter` not supported, must be iterable, got INTEGER"},
+		{"to_array(1)", "argument to `to_array` not supported, must be iterable, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%s: no error object returned. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("%s: wrong error message. expected=%q, got=%q", tt.input, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestGeneratorErrorsAreCatchable(t *testing.T) {
+	input := `let g = fn() { yield 1; throw "boom" };
+	let it = g();
+	it.next();
+	try { it.next() } catch (e) { e.message }`
+
+	evaluated := testEval(input)
+	if evaluated.Inspect() != "boom" {
+		t.Errorf("wrong result. got=%s", evaluated.Inspect())
+	}
+}
+
+// The parser rejects yield outside of functions, so it's reached only by
+// evaluating a statement of a generator on its own.
+func TestYieldOutsideGenerator(t *testing.T) {
+	program := testParseProgram("fn() { yield 1 }")
+	fn := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+
+	evaluated := Eval(fn.Body.Statements[0], object.NewEnvironment())
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+	if errObj.ErrorKind() != object.SyntaxError || errObj.Message != "yield outside generator" {
+		t.Errorf("wrong error. got=%s: %q", errObj.ErrorKind(), errObj.Message)
+	}
+}
+
+func TestAbandonedGeneratorsStop(t *testing.T) {
+	tests := []string{
+		// take closes the generator once it has enough values
+		naturals + "naturals().take(3).to_array()",
+		naturals + "naturals().take_while(fn(x) { x < 3 }).to_array()",
+		naturals + "let it = naturals(); it.next(); it.close()",
+		// the catch block doesn't catch closing
+		`let g = fn() { try { yield 1; yield 2 } catch (e) { yield 3 } };
+		let it = g(); it.next(); it.close()`,
+		// an iterator which is not reachable anymore is collected
+		naturals + "let f = fn() { let it = naturals(); it.next() }; f()",
+	}
+
+	for _, input := range tests {
+		before := runtime.NumGoroutine()
+		testEval(input)
+
+		for i := 0; i < 100 && runtime.NumGoroutine() > before; i++ {
+			runtime.GC()
+			time.Sleep(time.Millisecond)
+		}
+		if n := runtime.NumGoroutine(); n > before {
+			t.Errorf("%s: generator goroutine is still running, goroutines before=%d, after=%d", input, before, n)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package evaluator
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/wmolicki/go-monkey/object"
+)
+
+// stdin is shared by builtins reading standard input, so input buffered
+// by one of them is seen by the others.
+var stdin = struct {
+	sync.Mutex
+	r *bufio.Reader
+}{r: bufio.NewReader(os.Stdin)}
+
+// SetStdin makes builtins read input from r instead of standard input.
+func SetStdin(r io.Reader) {
+	stdin.Lock()
+	defer stdin.Unlock()
+	stdin.r = bufio.NewReader(r)
+}
+
+// stdout is where builtins such as puts write.
+var stdout = struct {
+	sync.Mutex
+	w io.Writer
+}{w: os.Stdout}
+
+// SetStdout makes builtins write output to w instead of standard output.
+func SetStdout(w io.Writer) {
+	stdout.Lock()
+	defer stdout.Unlock()
+	stdout.w = w
+}
+
+// puts writes its arguments followed by a newline, the whole line is
+// written at once, so lines printed by spawned functions don't mix.
+func puts(args ...object.Object) object.Object {
+	var out strings.Builder
+	for _, a := range args {
+		out.WriteString(a.Inspect())
+	}
+	out.WriteString("\n")
+
+	stdout.Lock()
+	defer stdout.Unlock()
+	io.WriteString(stdout.w, out.String())
+	return NULL
+}
+
+// readLine returns the next line of input without the line ending, or
+// null at the end of input.
+func readLine(args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args), 0)
+	}
+	stdin.Lock()
+	defer stdin.Unlock()
+
+	line, err := stdin.r.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return NULL
+	}
+	if err != nil && err != io.EOF {
+		return object.NewError(object.IOError, "error reading input: %v", err)
+	}
+	line = strings.TrimSuffix(line, "\n")
+	return &object.String{Value: strings.TrimSuffix(line, "\r")}
+}
+
+// readAll returns the rest of input.
+func readAll(args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args), 0)
+	}
+	stdin.Lock()
+	defer stdin.Unlock()
+
+	all, err := io.ReadAll(stdin.r)
+	if err != nil {
+		return object.NewError(object.IOError, "error reading input: %v", err)
+	}
+	return &object.String{Value: string(all)}
+}
+
+// Exit is returned by the exit builtin. It unwinds the program like an
+// error, except that it can't be caught, and whoever runs the program
+// should exit with Code.
+type Exit struct {
+	Code int
+}
+
+func (e *Exit) Type() object.ObjectType { return object.ERROR_OBJ }
+func (e *Exit) Inspect() string         { return fmt.Sprintf("exit(%d)", e.Code) }
+
+func exit(args ...object.Object) object.Object {
+	if len(args) > 1 {
+		return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args), 1)
+	}
+	if len(args) == 0 {
+		return &Exit{}
+	}
+	code, ok := args[0].(*object.Integer)
+	if !ok {
+		return object.NewError(object.TypeError,
+			"argument to `exit` not supported, must be %s, got %s", object.INTEGER_OBJ, args[0].Type())
+	}
+	return &Exit{Code: int(code.Value)}
+}
@@ -0,0 +1,88 @@
+package evaluator
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/wmolicki/go-monkey/object"
+)
+
+func TestReadInput(t *testing.T) {
+	tests := []struct {
+		stdin    string
+		input    string
+		expected string
+	}{
+		{"a\nb\n", "[read_line(), read_line(), read_line()]", "[a, b, null]"},
+		{"a\r\nb", "[read_line(), read_line(), read_line()]", "[a, b, null]"},
+		{"", "read_line()", "null"},
+		{"\n", "read_line()", ""},
+		{"a\nb\nc\n", "read_line(); read_all()", "b\nc\n"},
+		{"", "read_all()", ""},
+		{"ab\ncd\n", `let s = ""; for (let l = read_line(); l; let l = read_line()) { let s = s + l }; s`, "abcd"},
+		{"", "read_line(1)", "ERROR: wrong number of arguments, got: 1, want: 0"},
+		{"", "read_all(1)", "ERROR: wrong number of arguments, got: 1, want: 0"},
+	}
+	defer SetStdin(os.Stdin)
+
+	for _, tt := range tests {
+		SetStdin(strings.NewReader(tt.stdin))
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong result. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestReadInputErrors(t *testing.T) {
+	defer SetStdin(os.Stdin)
+
+	for _, input := range []string{"read_line()", "read_all()"} {
+		SetStdin(iotest.ErrReader(errors.New("broken pipe")))
+		err, ok := testEval(input).(*object.Error)
+		if !ok {
+			t.Errorf("%s: no error object returned. got=%v", input, testEval(input))
+			continue
+		}
+		if err.ErrorKind() != object.IOError || err.Message != "error reading input: broken pipe" {
+			t.Errorf("%s: wrong error. got=%s: %q", input, err.ErrorKind(), err.Message)
+		}
+	}
+}
+
+func TestExit(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"exit()", 0},
+		{"exit(3); 1", 3},
+		{"let f = fn() { exit(2) }; [f()]; 1", 2},
+		// exit can't be caught, but finally blocks still run
+		{"try { exit(4) } catch (e) { 1 }", 4},
+		{"try { exit(4) } finally { 1 }", 4},
+		{"[1, 2].map(fn(x) { exit(x) })", 1},
+		{"recv(spawn fn() { exit(5) })", 5},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		exit, ok := evaluated.(*Exit)
+		if !ok {
+			t.Errorf("%s: object is not Exit. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if exit.Code != tt.expected {
+			t.Errorf("%s: wrong code. expected=%d, got=%d", tt.input, tt.expected, exit.Code)
+		}
+	}
+
+	for _, input := range []string{`exit("1")`, "exit(1, 2)"} {
+		if _, ok := testEval(input).(*Exit); ok {
+			t.Errorf("%s: expected an error, got Exit", input)
+		}
+	}
+}
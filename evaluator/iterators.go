@@ -0,0 +1,225 @@
+package evaluator
+
+import (
+	"github.com/wmolicki/go-monkey/object"
+)
+
+// iterate returns an iterator over elements of obj, ok is false if
+// obj is not iterable.
+func iterate(obj object.Object) (it *object.Iterator, ok bool) {
+	switch obj := obj.(type) {
+	case *object.Iterator:
+		return obj, true
+	case *object.Array:
+		i := 0
+		return object.NewIterator(func() (object.Object, bool) {
+			if i >= obj.Len() {
+				return nil, false
+			}
+			i++
+			return obj.At(i - 1), true
+		}, nil), true
+	case *object.Set:
+		return iterate(object.NewArray(obj.Elements()))
+	case *object.Channel:
+		return object.NewIterator(obj.Receive, nil), true
+	default:
+		return nil, false
+	}
+}
+
+// toArray reads all values of it, an error stops reading and closes it.
+func toArray(it *object.Iterator) object.Object {
+	elements := []object.Object{}
+	for {
+		value, ok := it.Next()
+		if !ok {
+			return object.NewArray(elements)
+		}
+		if isError(value) {
+			it.Close()
+			return value
+		}
+		elements = append(elements, value)
+	}
+}
+
+func iteratorNext(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args)-1, 0)
+	}
+	it := args[0].(*object.Iterator)
+
+	if value, ok := it.Next(); ok {
+		return value
+	}
+	return NULL
+}
+
+func iteratorClose(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args)-1, 0)
+	}
+	args[0].(*object.Iterator).Close()
+	return NULL
+}
+
+func iteratorToArray(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args)-1, 0)
+	}
+	return toArray(args[0].(*object.Iterator))
+}
+
+func iteratorMap(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args)-1, 1)
+	}
+	it, f := args[0].(*object.Iterator), args[1]
+
+	return object.NewIterator(func() (object.Object, bool) {
+		value, ok := it.Next()
+		if !ok || isError(value) {
+			return value, ok
+		}
+		return applyFunction(f, []object.Object{value}), true
+	}, it.Close)
+}
+
+func iteratorFilter(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args)-1, 1)
+	}
+	it, f := args[0].(*object.Iterator), args[1]
+
+	return object.NewIterator(func() (object.Object, bool) {
+		for {
+			value, ok := it.Next()
+			if !ok || isError(value) {
+				return value, ok
+			}
+			keep := applyFunction(f, []object.Object{value})
+			if isError(keep) {
+				return keep, true
+			}
+			if isTruthy(keep) {
+				return value, true
+			}
+		}
+	}, it.Close)
+}
+
+// iteratorCount returns the iterator and the count passed to take or drop.
+func iteratorCount(name string, args []object.Object) (*object.Iterator, int64, *object.Error) {
+	if len(args) != 2 {
+		return nil, 0, object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args)-1, 1)
+	}
+	n, ok := args[1].(*object.Integer)
+	if !ok {
+		return nil, 0, object.NewError(object.TypeError,
+			"argument to `%s` not supported, must be %s, got %s", name, object.INTEGER_OBJ, args[1].Type())
+	}
+	return args[0].(*object.Iterator), n.Value, nil
+}
+
+// iteratorTake closes the iterator as soon as n values were read from it.
+func iteratorTake(args ...object.Object) object.Object {
+	it, n, err := iteratorCount("take", args)
+	if err != nil {
+		return err
+	}
+
+	taken := int64(0)
+	return object.NewIterator(func() (object.Object, bool) {
+		if taken >= n {
+			it.Close()
+			return nil, false
+		}
+		value, ok := it.Next()
+		taken++
+		if taken >= n {
+			it.Close()
+		}
+		return value, ok
+	}, it.Close)
+}
+
+func iteratorDrop(args ...object.Object) object.Object {
+	it, n, err := iteratorCount("drop", args)
+	if err != nil {
+		return err
+	}
+
+	dropped := int64(0)
+	return object.NewIterator(func() (object.Object, bool) {
+		for ; dropped < n; dropped++ {
+			value, ok := it.Next()
+			if !ok || isError(value) {
+				return value, ok
+			}
+		}
+		return it.Next()
+	}, it.Close)
+}
+
+// iteratorTakeWhile closes the iterator after reading the first value
+// which doesn't satisfy the predicate.
+func iteratorTakeWhile(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args)-1, 1)
+	}
+	it, f := args[0].(*object.Iterator), args[1]
+
+	finished := false
+	return object.NewIterator(func() (object.Object, bool) {
+		if finished {
+			return nil, false
+		}
+		value, ok := it.Next()
+		if !ok || isError(value) {
+			return value, ok
+		}
+		keep := applyFunction(f, []object.Object{value})
+		if isError(keep) {
+			return keep, true
+		}
+		if !isTruthy(keep) {
+			finished = true
+			it.Close()
+			return nil, false
+		}
+		return value, true
+	}, it.Close)
+}
+
+// iteratorZip pairs values of the iterator with values of another
+// iterable, until either of them runs out.
+func iteratorZip(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args)-1, 1)
+	}
+	it := args[0].(*object.Iterator)
+	other, ok := iterate(args[1])
+	if !ok {
+		return object.NewError(object.TypeError, "argument to `zip` not supported, must be iterable, got %s", args[1].Type())
+	}
+
+	closeBoth := func() {
+		it.Close()
+		other.Close()
+	}
+
+	return object.NewIterator(func() (object.Object, bool) {
+		a, ok := it.Next()
+		if !ok || isError(a) {
+			other.Close()
+			return a, ok
+		}
+		b, ok := other.Next()
+		if !ok || isError(b) {
+			it.Close()
+			return b, ok
+		}
+		return object.NewArray([]object.Object{a, b}), true
+	}, closeBoth)
+}
@@ -0,0 +1,121 @@
+package evaluator
+
+import (
+	"github.com/wmolicki/go-monkey/ast"
+	"github.com/wmolicki/go-monkey/object"
+)
+
+func evalMatchExpression(me *ast.MatchExpression, env *object.Environment) object.Object {
+	subject := Eval(me.Subject, env)
+	if isError(subject) {
+		return subject
+	}
+
+	for _, arm := range me.Arms {
+		// bindings of an arm are only visible in its guard and body
+		armEnv := object.NewEnclosedEnvironment(env)
+
+		matched, err := matchPattern(arm.Pattern, subject, armEnv)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			continue
+		}
+
+		if arm.Guard != nil {
+			guard := Eval(arm.Guard, armEnv)
+			if isError(guard) {
+				return guard
+			}
+			if !isTruthy(guard) {
+				continue
+			}
+		}
+
+		return Eval(arm.Body, armEnv)
+	}
+
+	return object.NewError(object.MatchError, "no match arm matched value: %s", subject.Inspect())
+}
+
+// matchPattern reports whether value matches pattern, binding names
+// of the pattern in env. Errors come from evaluating literals.
+func matchPattern(pattern ast.Pattern, value object.Object, env *object.Environment) (bool, *object.Error) {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		return true, nil
+	case *ast.BindingPattern:
+		env.Set(pattern.Name.Value, value)
+		return true, nil
+	case *ast.LiteralPattern:
+		literal := Eval(pattern.Value, env)
+		if err, ok := literal.(*object.Error); ok {
+			return false, err
+		}
+		return object.Equal(literal, value), nil
+	case *ast.ArrayPattern:
+		return matchArrayPattern(pattern, value, env)
+	case *ast.HashPattern:
+		return matchHashPattern(pattern, value, env)
+	default:
+		return false, object.NewError(object.MatchError, "unknown pattern: %T", pattern)
+	}
+}
+
+func matchArrayPattern(pattern *ast.ArrayPattern, value object.Object, env *object.Environment) (bool, *object.Error) {
+	array, ok := value.(*object.Array)
+	if !ok {
+		return false, nil
+	}
+
+	if array.Len() < len(pattern.Elements) {
+		return false, nil
+	}
+	if !pattern.HasRest && array.Len() != len(pattern.Elements) {
+		return false, nil
+	}
+
+	for i, el := range pattern.Elements {
+		matched, err := matchPattern(el, array.At(i), env)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	if pattern.Rest != nil {
+		rest := array
+		for range pattern.Elements {
+			rest = rest.Rest()
+		}
+		env.Set(pattern.Rest.Value, rest)
+	}
+
+	return true, nil
+}
+
+func matchHashPattern(pattern *ast.HashPattern, value object.Object, env *object.Environment) (bool, *object.Error) {
+	hash, ok := value.(*object.Hash)
+	if !ok {
+		return false, nil
+	}
+
+	for _, pair := range pattern.Pairs {
+		key := Eval(pair.Key, env)
+		if err, ok := key.(*object.Error); ok {
+			return false, err
+		}
+
+		found, ok := hash.Get(key)
+		if !ok {
+			return false, nil
+		}
+
+		matched, err := matchPattern(pair.Value, found, env)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
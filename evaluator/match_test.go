@@ -0,0 +1,110 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/wmolicki/go-monkey/object"
+)
+
+func TestMatchExpressions(t *testing.T) {
+	describe := `let describe = fn(x) {
+		match (x) {
+			0 => "zero",
+			-1 => "minus one",
+			true => "yes",
+			"hi" => "greeting",
+			[] => "empty",
+			[a] => "one",
+			[a, b, ...rest] if len(rest) > 0 => "long",
+			[a, b] => "pair",
+			{"name": name, "age": 1} => "baby " + name,
+			{"name": name} => "named " + name,
+			n if n == len("big") => "three",
+			_ => "other",
+		}
+	};`
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"describe(0)", "zero"},
+		{"describe(-1)", "minus one"},
+		{"describe(true)", "yes"},
+		{`describe("hi")`, "greeting"},
+		{"describe([])", "empty"},
+		{"describe([1])", "one"},
+		{"describe([1, 2])", "pair"},
+		{"describe([1, 2, 3])", "long"},
+		{`describe({"name": "Tom", "age": 1})`, "baby Tom"},
+		{`describe({"name": "Ann", "age": 30})`, "named Ann"},
+		{"describe(3)", "three"},
+		{"describe(5)", "other"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(describe + tt.input)
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("%s: object is not String. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if str.Value != tt.expected {
+			t.Errorf("%s: wrong value. expected=%q, got=%q", tt.input, tt.expected, str.Value)
+		}
+	}
+}
+
+func TestMatchBindings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"match (5) { x => x * 2 }", 10},
+		{"match ([1, 2, 3, 4]) { [a, ...rest] => a + len(rest) }", 4},
+		{"match ([1, [2, 3]]) { [a, [b, c]] => a + b + c }", 6},
+		{"match ([1, 2, 3]) { [_, ...rest] => rest[0] + rest[1] }", 5},
+		{`match ({"point": [3, 4]}) { {"point": [x, y]} => x * y }`, 12},
+		{"match (3) { x if x > 5 => 1, x if x > 2 => 2, _ => 3 }", 2},
+		// bindings don't leak out of arms
+		{"let x = 1; match (2) { x => x }; x", 1},
+		// bindings of an arm which didn't match are not visible in the next one
+		{"let a = 7; match ([1, 2]) { [a, 3] => 0, _ => a }", 7},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestMatchErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedKind    string
+		expectedMessage string
+	}{
+		{"match (5) { 1 => 1, 2 => 2 }", object.MatchError, "no match arm matched value: 5"},
+		{"match ([1]) { [a, b] => a }", object.MatchError, "no match arm matched value: [1]"},
+		{"match (foo) { _ => 1 }", object.NameError, "identifier not found: foo"},
+		{"match (1) { x if foo => 1 }", object.NameError, "identifier not found: foo"},
+		{"match (1) { x => x + true }", object.TypeError, "type mismatch: INTEGER + BOOLEAN"},
+		// guards are evaluated only for arms whose pattern matched
+		{"match (false) { true => 1, n if n > 10 => 2 }", object.TypeError, "type mismatch: BOOLEAN > INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q",
+				tt.expectedMessage, errObj.Message)
+		}
+		if errObj.ErrorKind() != tt.expectedKind {
+			t.Errorf("%q: wrong kind. expected=%q, got=%q", tt.input, tt.expectedKind, errObj.ErrorKind())
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package evaluator
+
+import (
+	"sync"
+
+	"github.com/wmolicki/go-monkey/object"
+)
+
+var (
+	methodsMu sync.RWMutex
+	methods   = map[object.ObjectType]map[string]*object.Builtin{}
+)
+
+// RegisterMethod makes fn callable as a method of objects of type t,
+// e.g. arr.name(x) calls fn with arr and x as arguments.
+func RegisterMethod(t object.ObjectType, name string, fn object.BuiltinFunction) {
+	methodsMu.Lock()
+	defer methodsMu.Unlock()
+
+	if methods[t] == nil {
+		methods[t] = map[string]*object.Builtin{}
+	}
+	methods[t][name] = &object.Builtin{Fn: fn}
+}
+
+func lookupMethod(t object.ObjectType, name string) (*object.Builtin, bool) {
+	methodsMu.RLock()
+	defer methodsMu.RUnlock()
+
+	method, ok := methods[t][name]
+	return method, ok
+}
+
+func init() {
+	for _, name := range []string{"len", "first", "last", "rest", "push", "sort"} {
+		RegisterMethod(object.ARRAY_OBJ, name, builtins[name].Fn)
+	}
+	RegisterMethod(object.ARRAY_OBJ, "map", arrayMap)
+	RegisterMethod(object.ARRAY_OBJ, "filter", arrayFilter)
+	RegisterMethod(object.ARRAY_OBJ, "reduce", arrayReduce)
+
+	RegisterMethod(object.STRING_OBJ, "len", builtins["len"].Fn)
+
+	RegisterMethod(object.HASH_OBJ, "keys", hashKeys)
+	RegisterMethod(object.HASH_OBJ, "values", hashValues)
+	RegisterMethod(object.HASH_OBJ, "put", hashPut)
+	RegisterMethod(object.HASH_OBJ, "delete", hashDelete)
+
+	RegisterMethod(object.SET_OBJ, "len", builtins["len"].Fn)
+	RegisterMethod(object.SET_OBJ, "contains", setContains)
+	RegisterMethod(object.SET_OBJ, "add", setAdd)
+	RegisterMethod(object.SET_OBJ, "remove", setRemove)
+	RegisterMethod(object.SET_OBJ, "union", setOperation("union", (*object.Set).Union))
+	RegisterMethod(object.SET_OBJ, "intersection", setOperation("intersection", (*object.Set).Intersection))
+	RegisterMethod(object.SET_OBJ, "difference", setOperation("difference", (*object.Set).Difference))
+	RegisterMethod(object.SET_OBJ, "is_subset", setPredicate("is_subset", (*object.Set).IsSubset))
+	RegisterMethod(object.SET_OBJ, "is_superset", setPredicate("is_superset", func(s, other *object.Set) bool {
+		return other.IsSubset(s)
+	}))
+	RegisterMethod(object.SET_OBJ, "to_array", setToArray)
+
+	RegisterMethod(object.ITERATOR_OBJ, "next", iteratorNext)
+	RegisterMethod(object.ITERATOR_OBJ, "close", iteratorClose)
+	RegisterMethod(object.ITERATOR_OBJ, "to_array", iteratorToArray)
+	RegisterMethod(object.ITERATOR_OBJ, "map", iteratorMap)
+	RegisterMethod(object.ITERATOR_OBJ, "filter", iteratorFilter)
+	RegisterMethod(object.ITERATOR_OBJ, "take", iteratorTake)
+	RegisterMethod(object.ITERATOR_OBJ, "drop", iteratorDrop)
+	RegisterMethod(object.ITERATOR_OBJ, "take_while", iteratorTakeWhile)
+	RegisterMethod(object.ITERATOR_OBJ, "zip", iteratorZip)
+
+	// other iterables share the lazy combinators, arrays keep their eager
+	// map and filter
+	for _, t := range []object.ObjectType{object.ARRAY_OBJ, object.SET_OBJ, object.CHANNEL_OBJ} {
+		for _, name := range []string{"map", "filter", "take", "drop", "take_while", "zip"} {
+			if _, ok := methods[t][name]; !ok {
+				RegisterMethod(t, name, iterableMethod(methods[object.ITERATOR_OBJ][name].Fn))
+			}
+		}
+	}
+}
+
+// iterableMethod makes an iterator method callable on any iterable,
+// which is iterated when the method is called.
+func iterableMethod(fn object.BuiltinFunction) object.BuiltinFunction {
+	return func(args ...object.Object) object.Object {
+		it, _ := iterate(args[0])
+		return fn(append([]object.Object{it}, args[1:]...)...)
+	}
+}
+
+func arrayMap(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args)-1, 1)
+	}
+	arr := args[0].(*object.Array)
+
+	result := make([]object.Object, 0, arr.Len())
+	for _, el := range arr.Elements() {
+		mapped := applyFunction(args[1], []object.Object{el})
+		if isError(mapped) {
+			return mapped
+		}
+		result = append(result, mapped)
+	}
+
+	return object.NewArray(result)
+}
+
+func arrayFilter(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args)-1, 1)
+	}
+	arr := args[0].(*object.Array)
+
+	result := []object.Object{}
+	for _, el := range arr.Elements() {
+		keep := applyFunction(args[1], []object.Object{el})
+		if isError(keep) {
+			return keep
+		}
+		if isTruthy(keep) {
+			result = append(result, el)
+		}
+	}
+
+	return object.NewArray(result)
+}
+
+func arrayReduce(args ...object.Object) object.Object {
+	if len(args) != 3 {
+		return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args)-1, 2)
+	}
+	arr := args[0].(*object.Array)
+
+	acc := args[2]
+	for _, el := range arr.Elements() {
+		acc = applyFunction(args[1], []object.Object{acc, el})
+		if isError(acc) {
+			return acc
+		}
+	}
+
+	return acc
+}
+
+func hashKeys(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args)-1, 0)
+	}
+	hash := args[0].(*object.Hash)
+
+	keys := []object.Object{}
+	for _, pair := range hash.Pairs() {
+		keys = append(keys, pair.Key)
+	}
+
+	return object.NewArray(keys)
+}
+
+func hashValues(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args)-1, 0)
+	}
+	hash := args[0].(*object.Hash)
+
+	values := []object.Object{}
+	for _, pair := range hash.Pairs() {
+		values = append(values, pair.Value)
+	}
+
+	return object.NewArray(values)
+}
+
+// hashPut returns the hash with a key mapped to a value, the hash
+// itself is not modified.
+func hashPut(args ...object.Object) object.Object {
+	if len(args) != 3 {
+		return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args)-1, 2)
+	}
+	hash := args[0].(*object.Hash)
+
+	result, ok := hash.With(args[1], args[2])
+	if !ok {
+		return object.NewError(object.TypeError, "unhashable object used as key: %s", args[1].Type())
+	}
+	return result
+}
+
+func hashDelete(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args)-1, 1)
+	}
+	hash := args[0].(*object.Hash)
+
+	return hash.Without(args[1])
+}
@@ -0,0 +1,119 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/wmolicki/go-monkey/object"
+)
+
+func TestMemberAccess(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`{"name": "Monkey"}.name`, "Monkey"},
+		{`let h = {"a": {"b": 2}}; h.a.b`, 2},
+		{`let h = {"len": 5}; h.len`, 5},
+		{`let h = {"f": fn(x) { x * 2 }}; h.f(4)`, 8},
+		{`try { throw error("bad", "ValueError") } catch (e) { e.kind + ": " + e.message }`, "ValueError: bad"},
+		{`try { throw 1 } catch (e) { e.stack.len() }`, 0},
+		// missing keys are null, as with h["b"]
+		{`{"a": 1}.b`, nil},
+		{`let h = {"a": {}}; h.a.b`, nil},
+	}
+
+	for _, tt := range tests {
+		testMemberResult(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestMethodCalls(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"[1, 2, 3].len()", 3},
+		{"[1, 2, 3].first()", 1},
+		{"[1, 2, 3].last()", 3},
+		{"[1, 2, 3].rest().first()", 2},
+		{"[1, 2].push(3).last()", 3},
+		{`"hello".len()`, 5},
+		{"[1, 2, 3].map(fn(x) { x * 2 }).last()", 6},
+		{"[1, 2, 3, 4].filter(fn(x) { x > 2 }).len()", 2},
+		{"[1, 2, 3, 4].reduce(fn(acc, x) { acc + x }, 0)", 10},
+		{"[1, 2, 3].map(fn(x) { x + 1 }).filter(fn(x) { x > 2 }).reduce(fn(a, x) { a + x }, 0)", 7},
+		{`{"a": 1, "b": 2}.keys().len()`, 2},
+		{`{"a": 1}.values().first()`, 1},
+		{`{"a": 1}.put("b", 2).b`, 2},
+		{`{"a": 1}.put("a", 2).values().len()`, 1},
+		{`{"a": 1, "b": 2}.delete("a").keys().first()`, "b"},
+		{`let h = {"a": 1}; h.put("a", 2); h.delete("a"); h.a`, 1},
+		// methods are values bound to their receiver
+		{"let l = [1, 2].len; l()", 2},
+	}
+
+	for _, tt := range tests {
+		testMemberResult(t, tt.input, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestMethodErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"[1].foo()", "ARRAY has no member foo"},
+		{`{"a": 1}.b()`, "not a function: NULL"},
+		{"true.len()", "BOOLEAN has no member len"},
+		{"[1, 2].map()", "wrong number of arguments, got: 0, want: 1"},
+		{"[1, 2].map(fn(x) { x + true })", "type mismatch: INTEGER + BOOLEAN"},
+		{"[1, 2].map(1)", "not a function: INTEGER"},
+		{"{}.put(fn() {}, 1)", "unhashable object used as key: FUNCTION"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%s: no error object returned. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q",
+				tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestRegisterMethod(t *testing.T) {
+	RegisterMethod(object.INTEGER_OBJ, "double", func(args ...object.Object) object.Object {
+		return &object.Integer{Value: args[0].(*object.Integer).Value * 2}
+	})
+	defer func() {
+		methodsMu.Lock()
+		delete(methods[object.INTEGER_OBJ], "double")
+		methodsMu.Unlock()
+	}()
+
+	testIntegerObject(t, testEval("let x = 21; x.double()"), 42)
+}
+
+func testMemberResult(t *testing.T, input string, evaluated object.Object, expected interface{}) {
+	t.Helper()
+
+	switch expected := expected.(type) {
+	case int:
+		testIntegerObject(t, evaluated, int64(expected))
+	case nil:
+		testNullObject(t, evaluated)
+	case string:
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("%s: object is not String. got=%T (%+v)", input, evaluated, evaluated)
+			return
+		}
+		if str.Value != expected {
+			t.Errorf("%s: wrong value. expected=%q, got=%q", input, expected, str.Value)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package evaluator
+
+import (
+	"github.com/wmolicki/go-monkey/object"
+)
+
+// operatorMethods are names of members which overload infix operators,
+// they are called with both operands, e.g. {"__add__": fn(self, other) {...}}.
+// A name prefixed with r, e.g. __radd__, is looked up on the right operand
+// if the left one doesn't define the operator.
+var operatorMethods = map[string]string{
+	"+":  "add",
+	"-":  "sub",
+	"*":  "mul",
+	"/":  "div",
+	"<":  "lt",
+	">":  "gt",
+	"==": "eq",
+	"!=": "ne",
+}
+
+// evalOverloadedInfix applies an operator defined by one of the operands,
+// ok is false if neither of them defines it.
+func evalOverloadedInfix(operator string, left, right object.Object) (result object.Object, ok bool) {
+	if operable, isOperable := left.(object.InfixOperable); isOperable {
+		if result, ok := operable.InfixOperation(operator, right, false); ok {
+			return result, true
+		}
+	}
+	if operable, isOperable := right.(object.InfixOperable); isOperable {
+		if result, ok := operable.InfixOperation(operator, left, true); ok {
+			return result, true
+		}
+	}
+
+	name, ok := operatorMethods[operator]
+	if !ok {
+		return nil, false
+	}
+
+	if method, ok := operatorMethod(left, "__"+name+"__"); ok {
+		return callOperatorMethod(method, left, right), true
+	}
+	if method, ok := operatorMethod(right, "__r"+name+"__"); ok {
+		return callOperatorMethod(method, right, left), true
+	}
+
+	// != is the negation of ==, unless it's defined separately
+	if operator == "!=" {
+		if method, ok := operatorMethod(left, "__eq__"); ok {
+			equal := callOperatorMethod(method, left, right)
+			if isError(equal) {
+				return equal, true
+			}
+			return nativeBoolToBooleanObject(!isTruthy(equal)), true
+		}
+	}
+
+	return nil, false
+}
+
+func operatorMethod(obj object.Object, name string) (object.Object, bool) {
+	accessor, ok := obj.(object.MemberAccessor)
+	if !ok {
+		return nil, false
+	}
+	return accessor.Member(name)
+}
+
+// callOperatorMethod calls method with self and other, methods of
+// struct instances are already bound to self.
+func callOperatorMethod(method, self, other object.Object) object.Object {
+	if _, ok := method.(*object.BoundMethod); ok {
+		return applyFunction(method, []object.Object{other})
+	}
+	return applyFunction(method, []object.Object{self, other})
+}
+
+// compareObjects orders a and b like object.Compare. Values it can't compare,
+// e.g. struct instances, are ordered by their overloaded < operator,
+// errors raised by it are returned.
+func compareObjects(a, b object.Object) (int, object.Object) {
+	if result, ok := object.Compare(a, b); ok {
+		return result, nil
+	}
+
+	less, ok := evalOverloadedInfix("<", a, b)
+	if !ok {
+		return 0, object.NewError(object.TypeError, "cannot compare %s and %s", a.Type(), b.Type())
+	}
+	if isError(less) {
+		return 0, less
+	}
+	if isTruthy(less) {
+		return -1, nil
+	}
+
+	// values which are not less are equal, unless b < a
+	greater, ok := evalOverloadedInfix("<", b, a)
+	if !ok {
+		return 0, nil
+	}
+	if isError(greater) {
+		return 0, greater
+	}
+	if isTruthy(greater) {
+		return 1, nil
+	}
+	return 0, nil
+}
@@ -0,0 +1,115 @@
+package evaluator
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/wmolicki/go-monkey/object"
+)
+
+// cents is a Go defined type supporting + with itself and * with integers.
+type cents struct {
+	value int64
+}
+
+func (c *cents) Type() object.ObjectType { return "CENTS" }
+func (c *cents) Inspect() string         { return fmt.Sprintf("%d.%02d", c.value/100, c.value%100) }
+
+func (c *cents) InfixOperation(operator string, other object.Object, reversed bool) (object.Object, bool) {
+	switch other := other.(type) {
+	case *cents:
+		switch operator {
+		case "+":
+			return &cents{value: c.value + other.value}, true
+		case "==":
+			return nativeBoolToBooleanObject(c.value == other.value), true
+		}
+	case *object.Integer:
+		if operator == "*" {
+			return &cents{value: c.value * other.Value}, true
+		}
+	}
+	return nil, false
+}
+
+func TestInfixOperable(t *testing.T) {
+	builtins["cents"] = &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			return &cents{value: args[0].(*object.Integer).Value}
+		},
+	}
+	defer delete(builtins, "cents")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"cents(150) + cents(275)", "4.25"},
+		{"cents(150) * 3", "4.50"},
+		// reversed operands
+		{"3 * cents(150)", "4.50"},
+		{"cents(1) == cents(1)", "true"},
+		{"cents(1) == cents(2)", "false"},
+		{"cents(1) - cents(2)", "ERROR: unknown operator: CENTS - CENTS"},
+		{"cents(1) + 1", "ERROR: type mismatch: CENTS + INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestOverloadedOperators(t *testing.T) {
+	vector := `
+	let vec = fn(x, y) {
+		{
+			"x": x,
+			"y": y,
+			"__add__": fn(self, other) { vec(self.x + other.x, self.y + other.y) },
+			"__mul__": fn(self, k) { vec(self.x * k, self.y * k) },
+			"__rmul__": fn(self, k) { vec(self.x * k, self.y * k) },
+			"__eq__": fn(self, other) { if (self.x == other.x) { self.y == other.y } else { false } },
+			"__lt__": fn(self, other) { self.x * self.x + self.y * self.y < other.x * other.x + other.y * other.y },
+		}
+	};
+	struct Point {
+		x, y
+		fn __add__(self, other) { Point(self.x + other.x, self.y + other.y) }
+		fn __sub__(self, other) { Point(self.x - other.x, self.y - other.y) }
+		fn __eq__(self, other) { self.x == other.x }
+		fn __ne__(self, other) { true }
+	}
+	`
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let v = vec(1, 2) + vec(3, 4); [v.x, v.y]", "[4, 6]"},
+		{"let v = vec(1, 2) * 3; [v.x, v.y]", "[3, 6]"},
+		{"let v = 3 * vec(1, 2); [v.x, v.y]", "[3, 6]"},
+		{"vec(1, 2) < vec(3, 4)", "true"},
+		{"vec(3, 4) < vec(1, 2)", "false"},
+		{"vec(1, 2) == vec(1, 2)", "true"},
+		{"vec(1, 2) != vec(1, 2)", "false"},
+		{"vec(1, 2) != vec(2, 2)", "true"},
+		{"vec(1, 2) > vec(3, 4)", "ERROR: unknown operator: HASH > HASH"},
+		{"Point(1, 2) + Point(3, 4)", "Point{x: 4, y: 6}"},
+		{"Point(1, 2) - Point(3, 4)", "Point{x: -2, y: -2}"},
+		{"Point(1, 2) == Point(1, 5)", "true"},
+		{"Point(1, 2) != Point(1, 2)", "true"},
+		{"Point(1, 2) * 2", "ERROR: type mismatch: STRUCT * INTEGER"},
+		// hashes without operator keys are unaffected
+		{`let h = {"a": 1}; h == h`, "true"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(vector + tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
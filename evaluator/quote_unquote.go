@@ -0,0 +1,143 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/wmolicki/go-monkey/ast"
+	"github.com/wmolicki/go-monkey/object"
+	"github.com/wmolicki/go-monkey/token"
+)
+
+// quote returns node unevaluated, except for arguments of unquote calls
+// inside of it, which are evaluated and put back into the tree.
+func quote(node ast.Node, env *object.Environment) object.Object {
+	node, err := evalUnquoteCalls(node, env)
+	if err != nil {
+		return err
+	}
+	return &object.Quote{Node: node}
+}
+
+// evalUnquoteCalls replaces unquote calls in quoted by their values. The
+// first error raised by an argument, or a value which can't be turned
+// into code, is returned and the calls after it are left in place.
+func evalUnquoteCalls(quoted ast.Node, env *object.Environment) (ast.Node, object.Object) {
+	var err object.Object
+
+	modified := ast.Modify(quoted, func(node ast.Node) ast.Node {
+		if err != nil || !isUnquoteCall(node) {
+			return node
+		}
+
+		call, ok := node.(*ast.CallExpression)
+		if !ok {
+			return node
+		}
+
+		if len(call.Arguments) != 1 {
+			return node
+		}
+
+		unquoted := Eval(call.Arguments[0], env)
+		if isError(unquoted) {
+			err = unquoted
+			return node
+		}
+		converted, ok := convertObjectToASTNode(unquoted)
+		if !ok {
+			err = object.NewError(object.TypeError, "cannot unquote %s", unquoted.Type())
+			return node
+		}
+		return converted
+	})
+
+	return modified, err
+}
+
+func isUnquoteCall(node ast.Node) bool {
+	callExpression, ok := node.(*ast.CallExpression)
+	if !ok {
+		return false
+	}
+
+	return callExpression.Function.TokenLiteral() == "unquote"
+}
+
+// convertObjectToASTNode turns a value back into a node, so it can be
+// inserted into quoted code. Only data can be converted, ok is false
+// for values such as functions, which have no literal.
+func convertObjectToASTNode(obj object.Object) (node ast.Expression, ok bool) {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		t := token.Token{
+			Type:    token.INT,
+			Literal: fmt.Sprintf("%d", obj.Value),
+		}
+		return &ast.IntegerLiteral{Token: t, Value: obj.Value}, true
+	case *object.Boolean:
+		return booleanNode(obj.Value), true
+	case *object.String:
+		t := token.Token{Type: token.STRING, Literal: obj.Value}
+		return &ast.StringLiteral{Token: t, Value: obj.Value}, true
+	case *object.Null:
+		// null has no literal, an if without a taken branch evaluates to it
+		return &ast.IfExpression{
+			Token:       token.Token{Type: token.IF, Literal: "if"},
+			Condition:   booleanNode(false),
+			Consequence: &ast.BlockStatement{Token: token.Token{Type: token.LBRACE, Literal: "{"}},
+		}, true
+	case *object.Array:
+		elements, ok := convertObjectsToASTNodes(obj.Elements())
+		if !ok {
+			return nil, false
+		}
+		t := token.Token{Type: token.LBRACKET, Literal: "["}
+		return &ast.ArrayLiteral{Token: t, Elements: elements}, true
+	case *object.Set:
+		elements, ok := convertObjectsToASTNodes(obj.Elements())
+		if !ok {
+			return nil, false
+		}
+		t := token.Token{Type: token.HASH_LBRACE, Literal: "#{"}
+		return &ast.SetLiteral{Token: t, Elements: elements}, true
+	case *object.Hash:
+		pairs := map[ast.Expression]ast.Expression{}
+		for _, pair := range obj.Pairs() {
+			key, ok := convertObjectToASTNode(pair.Key)
+			if !ok {
+				return nil, false
+			}
+			value, ok := convertObjectToASTNode(pair.Value)
+			if !ok {
+				return nil, false
+			}
+			pairs[key] = value
+		}
+		t := token.Token{Type: token.LBRACE, Literal: "{"}
+		return &ast.HashLiteral{Token: t, Pairs: pairs}, true
+	case *object.Quote:
+		expr, ok := obj.Node.(ast.Expression)
+		return expr, ok
+	default:
+		return nil, false
+	}
+}
+
+func convertObjectsToASTNodes(objs []object.Object) ([]ast.Expression, bool) {
+	nodes := []ast.Expression{}
+	for _, obj := range objs {
+		node, ok := convertObjectToASTNode(obj)
+		if !ok {
+			return nil, false
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, true
+}
+
+func booleanNode(value bool) *ast.Boolean {
+	if value {
+		return &ast.Boolean{Token: token.Token{Type: token.TRUE, Literal: "true"}, Value: true}
+	}
+	return &ast.Boolean{Token: token.Token{Type: token.FALSE, Literal: "false"}, Value: false}
+}
@@ -0,0 +1,131 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/wmolicki/go-monkey/object"
+)
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`quote(5)`, `5`},
+		{`quote(5 + 8)`, `(5 + 8)`},
+		{`quote(foobar)`, `foobar`},
+		{`quote(foobar + barfoo)`, `(foobar + barfoo)`},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		quote, ok := evaluated.(*object.Quote)
+		if !ok {
+			t.Fatalf("expected *object.Quote. got=%T (%+v)", evaluated, evaluated)
+		}
+
+		if quote.Node == nil {
+			t.Fatalf("quote.Node is nil")
+		}
+
+		if quote.Node.String() != tt.expected {
+			t.Errorf("not equal. got=%q, want=%q", quote.Node.String(), tt.expected)
+		}
+	}
+}
+
+func TestQuoteUnquote(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`quote(unquote(4))`, `4`},
+		{`quote(unquote(4 + 4))`, `8`},
+		{`quote(8 + unquote(4 + 4))`, `(8 + 8)`},
+		{`quote(unquote(4 + 4) + 8)`, `(8 + 8)`},
+		{`let foobar = 8; quote(foobar)`, `foobar`},
+		{`let foobar = 8; quote(unquote(foobar))`, `8`},
+		{`quote(unquote(true))`, `true`},
+		{`quote(unquote(true == false))`, `false`},
+		{`quote(unquote(quote(4 + 4)))`, `(4 + 4)`},
+		{`let quotedInfixExpression = quote(4 + 4);
+quote(unquote(4 + 4) + unquote(quotedInfixExpression))`, `(8 + (4 + 4))`},
+		{`quote(unquote("a" + "b"))`, `ab`},
+		{`quote(unquote([1, 2]))`, `[1, 2]`},
+		{`quote(unquote([1, [true, "a"]]))`, `[1, [true, a]]`},
+		{`quote(unquote({"a": [1]}))`, `{a:[1]}`},
+		{`quote(unquote(#{1}))`, `#{1}`},
+		{`quote(unquote(if (false) { 1 }))`, `iffalse `},
+		{`let x = quote(1); quote(unquote(x) + unquote([1]))`, `(1 + [1])`},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		quote, ok := evaluated.(*object.Quote)
+		if !ok {
+			t.Fatalf("expected *object.Quote. got=%T (%+v)", evaluated, evaluated)
+		}
+
+		if quote.Node == nil {
+			t.Fatalf("quote.Node is nil")
+		}
+
+		if quote.Node.String() != tt.expected {
+			t.Errorf("not equal. got=%q, want=%q", quote.Node.String(), tt.expected)
+		}
+	}
+}
+
+// Values unquoted into the code a macro returns evaluate to themselves.
+func TestUnquotedValuesInMacros(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let m = macro() { quote(unquote([1, 2])) }; m()`, `[1, 2]`},
+		{`let m = macro() { quote(unquote({"a": [1]})) }; m()`, `{a: [1]}`},
+		{`let m = macro() { quote(unquote(#{true})) }; m()`, `#{true}`},
+		{`let m = macro() { quote(unquote(if (false) { 1 })) }; m()`, `null`},
+		{`let m = macro() { quote(len(unquote([1, 2]))) }; m()`, `2`},
+	}
+
+	for _, tt := range tests {
+		program := testParseProgram(tt.input)
+		env := object.NewEnvironment()
+		DefineMacros(program, env)
+		expanded, err := ExpandMacros(program, env)
+		if err != nil {
+			t.Fatalf("%q: ExpandMacros returned an error: %v", tt.input, err)
+		}
+
+		evaluated := Eval(expanded, env)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: wrong value. want=%q, got=%v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestQuoteUnquoteErrors(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedKind string
+		expected     string
+	}{
+		{`let f = fn(x) { x }; quote(unquote(f))`, object.TypeError, "cannot unquote FUNCTION"},
+		{`quote(unquote([1, len]))`, object.TypeError, "cannot unquote ARRAY"},
+		{`quote(1 + unquote(error("boom")))`, object.TypeError, "cannot unquote ERROR_VALUE"},
+		{`quote(unquote(null))`, object.NameError, "identifier not found: null"},
+		{`quote(unquote(x) + unquote(y))`, object.NameError, "identifier not found: x"},
+	}
+
+	for _, tt := range tests {
+		err, ok := testEval(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("%q: expected an error, got %v", tt.input, testEval(tt.input))
+			continue
+		}
+		if err.ErrorKind() != tt.expectedKind || err.Message != tt.expected {
+			t.Errorf("%q: wrong error.\nexpected=%s: %q\ngot=     %s: %q", tt.input, tt.expectedKind, tt.expected, err.ErrorKind(), err.Message)
+		}
+	}
+}
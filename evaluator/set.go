@@ -0,0 +1,98 @@
+package evaluator
+
+import (
+	"github.com/wmolicki/go-monkey/ast"
+	"github.com/wmolicki/go-monkey/object"
+)
+
+func evalSetLiteral(node *ast.SetLiteral, env *object.Environment) object.Object {
+	elements := evalExpressions(node.Elements, env)
+	if len(elements) == 1 && isError(elements[0]) {
+		return elements[0]
+	}
+	return newSet(elements)
+}
+
+// newSet returns a set of elements, or an error if any of them is not hashable.
+func newSet(elements []object.Object) object.Object {
+	set := object.NewSet()
+	for _, el := range elements {
+		if !set.Add(el) {
+			return object.NewError(object.TypeError, "unhashable object used as set element: %s", el.Type())
+		}
+	}
+	return set
+}
+
+func setContains(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args)-1, 1)
+	}
+	set := args[0].(*object.Set)
+
+	return nativeBoolToBooleanObject(set.Contains(args[1]))
+}
+
+func setAdd(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args)-1, 1)
+	}
+	set := args[0].(*object.Set)
+
+	result, ok := set.With(args[1])
+	if !ok {
+		return object.NewError(object.TypeError, "unhashable object used as set element: %s", args[1].Type())
+	}
+	return result
+}
+
+func setRemove(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args)-1, 1)
+	}
+	set := args[0].(*object.Set)
+
+	return set.Without(args[1])
+}
+
+func setToArray(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args)-1, 0)
+	}
+	set := args[0].(*object.Set)
+
+	return object.NewArray(set.Elements())
+}
+
+// setOperands checks that method name was called with another set.
+func setOperands(name string, args []object.Object) (*object.Set, *object.Set, *object.Error) {
+	if len(args) != 2 {
+		return nil, nil, object.NewError(object.ArgumentError, "wrong number of arguments, got: %d, want: %d", len(args)-1, 1)
+	}
+	other, ok := args[1].(*object.Set)
+	if !ok {
+		return nil, nil, object.NewError(object.TypeError,
+			"argument to `%s` not supported, must be %s, got %s", name, object.SET_OBJ, args[1].Type())
+	}
+	return args[0].(*object.Set), other, nil
+}
+
+func setOperation(name string, op func(s, other *object.Set) *object.Set) object.BuiltinFunction {
+	return func(args ...object.Object) object.Object {
+		set, other, err := setOperands(name, args)
+		if err != nil {
+			return err
+		}
+		return op(set, other)
+	}
+}
+
+func setPredicate(name string, pred func(s, other *object.Set) bool) object.BuiltinFunction {
+	return func(args ...object.Object) object.Object {
+		set, other, err := setOperands(name, args)
+		if err != nil {
+			return err
+		}
+		return nativeBoolToBooleanObject(pred(set, other))
+	}
+}
@@ -0,0 +1,86 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/wmolicki/go-monkey/object"
+)
+
+func TestSets(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"#{}", "#{}"},
+		{"#{3, 1, 3, 2, 1}", "#{3, 1, 2}"},
+		{`#{[1, "a"], [1, "a"], #{2}, #{2}}`, "#{[1, a], #{2}}"},
+		{"set()", "#{}"},
+		{"set([2, 1, 2])", "#{2, 1}"},
+		{"len(#{1, 2, 2})", "2"},
+		{"#{1, 2}.len()", "2"},
+		{"#{1, 2}.contains(2)", "true"},
+		{"#{1, 2}.contains(3)", "false"},
+		{"#{[1]}.contains([1])", "true"},
+		{"#{1, 2}.add(3)", "#{1, 2, 3}"},
+		{"#{1, 2}.add(1)", "#{1, 2}"},
+		{"#{1, 2, 3}.remove(2)", "#{1, 3}"},
+		// add and remove don't modify the set
+		{"let s = #{1}; s.add(2); s.remove(1); s", "#{1}"},
+		{"#{1, 2}.union(#{3, 2})", "#{1, 2, 3}"},
+		{"#{1, 2, 3}.intersection(#{3, 2, 4})", "#{2, 3}"},
+		{"#{1, 2, 3}.difference(#{2})", "#{1, 3}"},
+		{"#{1, 2} + #{3}", "#{1, 2, 3}"},
+		{"#{1, 2} - #{1}", "#{2}"},
+		{"#{1}.is_subset(#{1, 2})", "true"},
+		{"#{1, 3}.is_subset(#{1, 2})", "false"},
+		{"#{1, 2}.is_superset(#{2})", "true"},
+		{"#{1, 2}.to_array()", "[1, 2]"},
+		{"#{1, 2} == #{2, 1}", "true"},
+		{"#{1, 2} == #{1}", "false"},
+		{"#{1} != [1]", "true"},
+		{"{#{1, 2}: 1}[#{2, 1}]", "1"},
+		// a replacement for building a hash of key => true
+		{`let words = ["a", "b", "a"]; set(words).len()`, "2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if _, ok := evaluated.(*object.Error); ok {
+			t.Errorf("%s: unexpected error: %s", tt.input, evaluated.Inspect())
+			continue
+		}
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: wrong result. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestSetErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"#{fn() {}}", "unhashable object used as set element: FUNCTION"},
+		{"#{1}.add({})", "unhashable object used as set element: HASH"},
+		{"set([[fn() {}]])", "unhashable object used as set element: ARRAY"},
+		{"set(1)", "argument to `set` not supported, must be ARRAY, got INTEGER"},
+		{"#{1}.union([1])", "argument to `union` not supported, must be SET, got ARRAY"},
+		{"#{1}.is_subset()", "wrong number of arguments, got: 0, want: 1"},
+		{"#{1} * #{1}", "unknown operator: SET * SET"},
+		{"#{1} + [1]", "type mismatch: SET + ARRAY"},
+		{"#{foo}", "identifier not found: foo"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%s: no error object returned. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q",
+				tt.expectedMessage, errObj.Message)
+		}
+	}
+}
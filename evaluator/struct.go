@@ -0,0 +1,94 @@
+package evaluator
+
+import (
+	"github.com/wmolicki/go-monkey/ast"
+	"github.com/wmolicki/go-monkey/object"
+)
+
+func evalStructStatement(ss *ast.StructStatement, env *object.Environment) object.Object {
+	st := &object.StructType{Name: ss.Name.Value, Methods: map[string]*object.Function{}}
+
+	for _, field := range ss.Fields {
+		st.Fields = append(st.Fields, field.Value)
+	}
+	for _, method := range ss.Methods {
+		st.Methods[method.Name.Value] = &object.Function{
+			Parameters: method.Function.Parameters,
+			Body:       method.Function.Body,
+			Env:        env,
+		}
+	}
+
+	env.Set(st.Name, st)
+
+	return nil
+}
+
+func newStructInstance(st *object.StructType, args []object.Object) object.Object {
+	if len(args) != len(st.Fields) {
+		return object.NewError(object.ArgumentError, "wrong number of arguments to %s, got: %d, want: %d",
+			st.Name, len(args), len(st.Fields))
+	}
+	return object.NewStructInstance(st, args)
+}
+
+// evalMemberExpression looks the member up on the object itself, then
+// among methods registered for its type.
+func evalMemberExpression(me *ast.MemberExpression, env *object.Environment) object.Object {
+	obj := Eval(me.Object, env)
+	if isError(obj) {
+		return obj
+	}
+
+	name := me.Member.Value
+
+	if ev, ok := obj.(*object.ErrorValue); ok {
+		return evalErrorValueIndexExpression(ev, &object.String{Value: name})
+	}
+
+	if accessor, ok := obj.(object.MemberAccessor); ok {
+		if val, ok := accessor.Member(name); ok {
+			return val
+		}
+	}
+
+	if method, ok := lookupMethod(obj.Type(), name); ok {
+		return &object.BoundMethod{Receiver: obj, Method: method}
+	}
+
+	switch obj := obj.(type) {
+	case *object.StructInstance:
+		return object.NewError(object.NameError, "%s has no field or method %s", obj.StructType.Name, name)
+	case *object.Hash:
+		// missing keys are null, as they are when indexing
+		return NULL
+	}
+	return object.NewError(object.NameError, "%s has no member %s", obj.Type(), name)
+}
+
+func evalAssignExpression(ae *ast.AssignExpression, env *object.Environment) object.Object {
+	target, ok := ae.Target.(*ast.MemberExpression)
+	if !ok {
+		return object.NewError(object.TypeError, "cannot assign to %s", ae.Target)
+	}
+
+	obj := Eval(target.Object, env)
+	if isError(obj) {
+		return obj
+	}
+
+	val := Eval(ae.Value, env)
+	if isError(val) {
+		return val
+	}
+
+	instance, ok := obj.(*object.StructInstance)
+	if !ok {
+		return object.NewError(object.TypeError, "field assignment not supported: %s", obj.Type())
+	}
+	if !instance.Set(target.Member.Value, val) {
+		return object.NewError(object.NameError, "%s has no field %s", instance.StructType.Name, target.Member.Value)
+	}
+
+	return val
+}
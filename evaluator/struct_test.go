@@ -0,0 +1,105 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/wmolicki/go-monkey/object"
+)
+
+const pointStruct = `struct Point {
+	x, y
+	fn add(self, other) { Point(self.x + other.x, self.y + other.y) }
+	fn move(self, dx) { self.x = self.x + dx; self }
+	fn getX(self) { self.x }
+}
+`
+
+func TestStructs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"Point(1, 2).x", 1},
+		{"Point(1, 2).y", 2},
+		{"Point(1, 2).add(Point(3, 4)).y", 6},
+		{"let p = Point(1, 2); p.x = 5; p.x", 5},
+		{"let p = Point(1, 2); p.x = p.y = 7; p.x + p.y", 14},
+		{"let p = Point(1, 2); p.move(3); p.x", 4},
+		{"let p = Point(1, 2); let m = p.getX; p.x = 9; m()", 9},
+		{"let p = Point(1, 2); let q = p; q.x = 3; p.x", 3},
+		{"type(Point(1, 2))", "Point"},
+		{"type(Point)", "STRUCT_TYPE"},
+		{"type(1)", "INTEGER"},
+		{`type("a")`, "STRING"},
+		{"Point(1, 2).x = 3", 3},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(pointStruct + tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			str, ok := evaluated.(*object.String)
+			if !ok {
+				t.Errorf("%s: object is not String. got=%T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if str.Value != expected {
+				t.Errorf("%s: wrong value. expected=%q, got=%q", tt.input, expected, str.Value)
+			}
+		}
+	}
+}
+
+func TestStructInspect(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Point(1, Point(2, 3))", "Point{x: 1, y: Point{x: 2, y: 3}}"},
+		{"Point", "struct Point { x, y }"},
+		{"struct Empty {}; Empty()", "Empty{}"},
+		// fields referring back to an instance being printed are elided
+		{"struct N { next }; let n = N(0); n.next = n; n", "N{next: N{...}}"},
+		{"struct N { next }; let n = N(0); n.next = [n, {\"n\": n}]; n", "N{next: [N{...}, {n: N{...}}]}"},
+		{"struct N { next }; let a = N(0); let b = N(a); a.next = b; [a, b]", "[N{next: N{next: N{...}}}, N{next: N{next: N{...}}}]"},
+		// an instance referred to twice without a cycle is printed in full
+		{"let p = Point(1, 2); Point(p, p)", "Point{x: Point{x: 1, y: 2}, y: Point{x: 1, y: 2}}"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(pointStruct + tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: wrong Inspect. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestStructErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"Point(1)", "wrong number of arguments to Point, got: 1, want: 2"},
+		{"Point(1, 2).z", "Point has no field or method z"},
+		{"let p = Point(1, 2); p.z = 1", "Point has no field z"},
+		{"let p = Point(1, 2); p.add()", "wrong number of arguments, got: 1, want: 2"},
+		{"1.x", "INTEGER has no member x"},
+		{"let a = [1]; a.x = 1", "field assignment not supported: ARRAY"},
+		{"Point(1, 2).x = foo", "identifier not found: foo"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(pointStruct + tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%s: no error object returned. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q",
+				tt.expectedMessage, errObj.Message)
+		}
+	}
+}
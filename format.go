@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/wmolicki/go-monkey/format"
+)
+
+// runFmt implements `monke fmt [-w] files...`, without files it formats
+// standard input to standard output.
+func runFmt(args []string) int {
+	fs := flag.NewFlagSet("fmt", flag.ExitOnError)
+	write := fs.Bool("w", false, "write result to (source) file instead of stdout")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: monke fmt [-w] [files...]\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error reading stdin: %v\n", err)
+			return exitError
+		}
+		formatted, err := format.Source(src)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "<stdin>: %v\n", err)
+			return exitError
+		}
+		os.Stdout.Write(formatted)
+		return exitOK
+	}
+
+	status := exitOK
+	for _, filename := range fs.Args() {
+		if err := fmtFile(filename, *write); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", filename, err)
+			status = exitError
+		}
+	}
+	return status
+}
+
+func fmtFile(filename string, write bool) error {
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	formatted, err := format.Source(src)
+	if err != nil {
+		return err
+	}
+
+	if !write {
+		_, err = os.Stdout.Write(formatted)
+		return err
+	}
+	if bytes.Equal(src, formatted) {
+		return nil
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, formatted, info.Mode().Perm())
+}
@@ -0,0 +1,693 @@
+// Package format prints Monkey programs in the canonical style used by
+// `monke fmt`: four space indentation, one statement per line terminated
+// with a semicolon, only the parentheses the parser needs, comments and
+// single blank lines between statements preserved.
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/wmolicki/go-monkey/ast"
+	"github.com/wmolicki/go-monkey/lexer"
+	"github.com/wmolicki/go-monkey/parser"
+	"github.com/wmolicki/go-monkey/token"
+)
+
+const indent = "    "
+
+// atom is the precedence of expressions which never need parentheses.
+const atom = parser.INDEX + 1
+
+// endOfFile is a position after any token in the source.
+var endOfFile = token.Position{Line: math.MaxInt32, Column: math.MaxInt32}
+
+// Source formats Monkey source code, it returns an error if src
+// does not parse.
+func Source(src []byte) ([]byte, error) {
+	l := lexer.New(string(src))
+	p := parser.New(l)
+
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, fmt.Errorf("parse error: %s", strings.Join(p.Errors(), "; "))
+	}
+
+	pr := &printer{
+		lines:    strings.Split(string(src), "\n"),
+		comments: l.Comments(),
+		fresh:    true,
+	}
+	pr.statements(program.Statements, endOfFile)
+
+	return pr.buf.Bytes(), nil
+}
+
+// Node formats a single node in the canonical style, without comments.
+func Node(node ast.Node) string {
+	pr := &printer{fresh: true}
+
+	switch node := node.(type) {
+	case *ast.Program:
+		pr.statements(node.Statements, endOfFile)
+	case ast.Statement:
+		pr.statement(node, nil)
+	case ast.Expression:
+		pr.expression(node, parser.LOWEST)
+	}
+
+	return strings.TrimSuffix(pr.buf.String(), "\n")
+}
+
+type printer struct {
+	buf   bytes.Buffer
+	depth int
+	// atLineStart is set after a newline, indentation is written lazily
+	// so blank lines don't get trailing whitespace.
+	atLineStart bool
+	// fresh is set at the start of a statement list, no blank line
+	// is preserved before its first item.
+	fresh bool
+
+	lines    []string      // source lines, used to find blank lines
+	comments []token.Token // comments from the source, in order
+	next     int           // index of the next comment to print
+	lastLine int           // source line of the last token printed
+}
+
+func (p *printer) write(s string) {
+	if p.atLineStart {
+		p.buf.WriteString(strings.Repeat(indent, p.depth))
+		p.atLineStart = false
+	}
+	p.buf.WriteString(s)
+}
+
+func (p *printer) newline() {
+	p.buf.WriteString("\n")
+	p.atLineStart = true
+}
+
+// mark records that a token at pos has been printed.
+func (p *printer) mark(pos token.Position) {
+	if pos.Line > p.lastLine {
+		p.lastLine = pos.Line
+	}
+}
+
+// blankLineBefore writes an empty line if the source had one before line.
+func (p *printer) blankLineBefore(line int) {
+	if p.fresh {
+		p.fresh = false
+		return
+	}
+	if line >= 2 && line-2 < len(p.lines) && strings.TrimSpace(p.lines[line-2]) == "" {
+		p.newline()
+	}
+}
+
+// commentsBefore prints, each on its own line, all comments which
+// appear before pos and were not printed yet.
+func (p *printer) commentsBefore(pos token.Position) {
+	for p.hasCommentBefore(pos) {
+		c := p.comments[p.next]
+		p.next++
+
+		p.blankLineBefore(c.Line)
+		p.write(strings.TrimRight(c.Literal, " \t"))
+		p.newline()
+	}
+}
+
+func (p *printer) hasCommentBefore(pos token.Position) bool {
+	return p.next < len(p.comments) && p.comments[p.next].Pos().Before(pos)
+}
+
+// trailingComment prints a comment which follows the last printed token
+// on the same source line, next is the position of the following item.
+func (p *printer) trailingComment(next token.Position) {
+	if !p.hasCommentBefore(next) {
+		return
+	}
+	c := p.comments[p.next]
+	if c.Line != p.lastLine {
+		return
+	}
+	p.next++
+	p.write(" " + strings.TrimRight(c.Literal, " \t"))
+}
+
+func (p *printer) statements(stmts []ast.Statement, end token.Position) {
+	for i, s := range stmts {
+		pos := s.Pos()
+		p.commentsBefore(pos)
+		p.blankLineBefore(pos.Line)
+
+		var next ast.Statement
+		nextPos := end
+		if i+1 < len(stmts) {
+			next = stmts[i+1]
+			nextPos = next.Pos()
+		}
+
+		p.statement(s, next)
+		p.trailingComment(nextPos)
+		p.newline()
+	}
+	p.commentsBefore(end)
+}
+
+// statement prints s followed by a semicolon, next is the statement
+// following s and is needed to decide whether the semicolon may be omitted.
+func (p *printer) statement(s ast.Statement, next ast.Statement) {
+	p.simpleStatement(s)
+
+	if es, ok := s.(*ast.ExpressionStatement); ok && endsWithBlock(es.Expression) && !continuesExpression(next) {
+		return
+	}
+	if _, ok := s.(*ast.StructStatement); ok {
+		return
+	}
+	p.write(";")
+}
+
+// simpleStatement prints s without the terminating semicolon.
+func (p *printer) simpleStatement(s ast.Statement) {
+	if s == nil {
+		return
+	}
+	p.mark(s.Pos())
+
+	switch s := s.(type) {
+	case *ast.LetStatement:
+		p.write("let ")
+		p.write(s.Name.Value)
+		p.write(" = ")
+		p.expression(s.Value, parser.LOWEST)
+	case *ast.ReturnStatement:
+		p.write("return ")
+		p.expression(s.ReturnValue, parser.LOWEST)
+	case *ast.ThrowStatement:
+		p.write("throw ")
+		p.expression(s.Value, parser.LOWEST)
+	case *ast.YieldStatement:
+		p.write("yield ")
+		p.expression(s.Value, parser.LOWEST)
+	case *ast.StructStatement:
+		p.structStatement(s)
+	case *ast.ExpressionStatement:
+		p.expression(s.Expression, parser.LOWEST)
+	case *ast.BlockStatement:
+		p.block(s)
+	}
+}
+
+func (p *printer) block(b *ast.BlockStatement) {
+	p.write("{")
+	p.mark(b.Pos())
+
+	if len(b.Statements) != 0 || p.hasCommentBefore(b.EndToken.Pos()) {
+		p.depth++
+		p.newline()
+		p.fresh = true
+		p.statements(b.Statements, b.EndToken.Pos())
+		p.depth--
+	}
+
+	p.write("}")
+	p.mark(b.EndToken.Pos())
+}
+
+// expression prints e, wrapping it in parentheses if it binds less
+// tightly than required by its parent.
+func (p *printer) expression(e ast.Expression, parentPrecedence int) {
+	if e == nil {
+		return
+	}
+	p.mark(e.Pos())
+
+	if precedence(e) < parentPrecedence {
+		p.write("(")
+		defer p.write(")")
+	}
+
+	switch e := e.(type) {
+	case *ast.Identifier:
+		p.write(e.Value)
+	case *ast.IntegerLiteral:
+		p.write(integerLiteral(e))
+	case *ast.Boolean:
+		p.write(strconv.FormatBool(e.Value))
+	case *ast.StringLiteral:
+		p.write(`"` + e.Value + `"`)
+	case *ast.PrefixExpression:
+		p.write(e.Operator)
+		p.expression(e.Right, parser.PREFIX)
+	case *ast.SpawnExpression:
+		p.write("spawn ")
+		p.expression(e.Value, parser.PREFIX)
+	case *ast.InfixExpression:
+		prec := precedence(e)
+		p.expression(e.Left, prec)
+		p.write(" " + e.Operator + " ")
+		// operators are left associative, so right operand
+		// of the same precedence needs parentheses
+		p.expression(e.Right, prec+1)
+	case *ast.IfExpression:
+		p.write("if (")
+		p.expression(e.Condition, parser.LOWEST)
+		p.write(") ")
+		p.block(e.Consequence)
+		if e.Alternative != nil {
+			p.write(" else ")
+			p.block(e.Alternative)
+		}
+	case *ast.ForExpression:
+		p.write("for (")
+		p.simpleStatement(e.Initializer)
+		p.write("; ")
+		p.expression(e.Condition, parser.LOWEST)
+		p.write("; ")
+		p.simpleStatement(e.Loop)
+		p.write(") ")
+		p.block(e.Body)
+	case *ast.FunctionLiteral:
+		params := []string{}
+		for _, param := range e.Parameters {
+			params = append(params, param.Value)
+		}
+		p.write("fn(" + strings.Join(params, ", ") + ") ")
+		p.block(e.Body)
+	case *ast.MacroLiteral:
+		params := []string{}
+		for _, param := range e.Parameters {
+			params = append(params, param.Value)
+		}
+		p.write("macro(" + strings.Join(params, ", ") + ") ")
+		p.block(e.Body)
+	case *ast.CallExpression:
+		p.expression(e.Function, parser.CALL)
+		p.write("(")
+		p.expressionList(e.Arguments)
+		p.write(")")
+	case *ast.IndexExpression:
+		p.expression(e.Left, parser.CALL)
+		p.write("[")
+		p.expression(e.Index, parser.LOWEST)
+		p.write("]")
+	case *ast.MemberExpression:
+		p.expression(e.Object, parser.CALL)
+		p.write("." + e.Member.Value)
+	case *ast.AssignExpression:
+		p.expression(e.Target, parser.ASSIGN+1)
+		p.write(" = ")
+		// assignment is right associative
+		p.expression(e.Value, parser.ASSIGN)
+	case *ast.ArrayLiteral:
+		p.arrayLiteral(e)
+	case *ast.HashLiteral:
+		p.hashLiteral(e)
+	case *ast.SetLiteral:
+		p.setLiteral(e)
+	case *ast.MatchExpression:
+		p.matchExpression(e)
+	case *ast.SelectExpression:
+		p.selectExpression(e)
+	case *ast.TryExpression:
+		p.write("try ")
+		p.block(e.Body)
+		if e.Catch != nil {
+			p.write(" catch ")
+			if e.CatchParameter != nil {
+				p.write("(" + e.CatchParameter.Value + ") ")
+			}
+			p.block(e.Catch)
+		}
+		if e.Finally != nil {
+			p.write(" finally ")
+			p.block(e.Finally)
+		}
+	}
+}
+
+// structStatement prints fields on a single line, followed by
+// methods, each on its own line.
+func (p *printer) structStatement(s *ast.StructStatement) {
+	p.write("struct " + s.Name.Value + " {")
+
+	end := s.EndToken.Pos()
+	if len(s.Fields) == 0 && len(s.Methods) == 0 && !p.hasCommentBefore(end) {
+		p.write("}")
+		p.mark(end)
+		return
+	}
+
+	p.depth++
+	p.newline()
+	p.fresh = true
+
+	if len(s.Fields) > 0 {
+		pos := s.Fields[0].Pos()
+		p.commentsBefore(pos)
+		p.blankLineBefore(pos.Line)
+
+		names := []string{}
+		for _, field := range s.Fields {
+			names = append(names, field.Value)
+		}
+		p.write(strings.Join(names, ", "))
+		p.mark(s.Fields[len(s.Fields)-1].Pos())
+
+		next := end
+		if len(s.Methods) > 0 {
+			next = s.Methods[0].Function.Pos()
+		}
+		p.trailingComment(next)
+		p.newline()
+	}
+
+	for i, method := range s.Methods {
+		pos := method.Function.Pos()
+		p.commentsBefore(pos)
+		p.blankLineBefore(pos.Line)
+
+		params := []string{}
+		for _, param := range method.Function.Parameters {
+			params = append(params, param.Value)
+		}
+		p.mark(pos)
+		p.write("fn " + method.Name.Value + "(" + strings.Join(params, ", ") + ") ")
+		p.block(method.Function.Body)
+
+		next := end
+		if i+1 < len(s.Methods) {
+			next = s.Methods[i+1].Function.Pos()
+		}
+		p.trailingComment(next)
+		p.newline()
+	}
+	p.commentsBefore(end)
+	p.depth--
+
+	p.write("}")
+	p.mark(end)
+}
+
+// matchExpression prints each arm on its own line, followed by a comma.
+func (p *printer) matchExpression(m *ast.MatchExpression) {
+	p.write("match (")
+	p.expression(m.Subject, parser.LOWEST)
+	p.write(") {")
+
+	end := m.EndToken.Pos()
+	if len(m.Arms) == 0 && !p.hasCommentBefore(end) {
+		p.write("}")
+		p.mark(end)
+		return
+	}
+
+	p.depth++
+	p.newline()
+	p.fresh = true
+	for i, arm := range m.Arms {
+		pos := arm.Pattern.Pos()
+		p.commentsBefore(pos)
+		p.blankLineBefore(pos.Line)
+
+		p.pattern(arm.Pattern)
+		if arm.Guard != nil {
+			p.write(" if ")
+			p.expression(arm.Guard, parser.LOWEST)
+		}
+		p.write(" => ")
+		p.expression(arm.Body, parser.LOWEST)
+		p.write(",")
+
+		next := end
+		if i+1 < len(m.Arms) {
+			next = m.Arms[i+1].Pattern.Pos()
+		}
+		p.trailingComment(next)
+		p.newline()
+	}
+	p.commentsBefore(end)
+	p.depth--
+
+	p.write("}")
+	p.mark(end)
+}
+
+// selectExpression prints cases like arms of a match expression.
+func (p *printer) selectExpression(s *ast.SelectExpression) {
+	p.write("select {")
+
+	end := s.EndToken.Pos()
+	if len(s.Cases) == 0 && !p.hasCommentBefore(end) {
+		p.write("}")
+		p.mark(end)
+		return
+	}
+
+	p.depth++
+	p.newline()
+	p.fresh = true
+	for i, c := range s.Cases {
+		pos := c.Token.Pos()
+		p.commentsBefore(pos)
+		p.blankLineBefore(pos.Line)
+		p.mark(pos)
+
+		if c.Binding != nil {
+			p.write("let " + c.Binding.Value + " = ")
+		}
+		if c.Call != nil {
+			p.expression(c.Call, parser.LOWEST)
+		} else {
+			p.write("_")
+		}
+		p.write(" => ")
+		p.expression(c.Body, parser.LOWEST)
+		p.write(",")
+
+		next := end
+		if i+1 < len(s.Cases) {
+			next = s.Cases[i+1].Token.Pos()
+		}
+		p.trailingComment(next)
+		p.newline()
+	}
+	p.commentsBefore(end)
+	p.depth--
+
+	p.write("}")
+	p.mark(end)
+}
+
+func (p *printer) pattern(pattern ast.Pattern) {
+	p.mark(pattern.Pos())
+
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		p.write("_")
+	case *ast.BindingPattern:
+		p.write(pattern.Name.Value)
+	case *ast.LiteralPattern:
+		p.expression(pattern.Value, parser.LOWEST)
+	case *ast.ArrayPattern:
+		p.write("[")
+		for i, el := range pattern.Elements {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.pattern(el)
+		}
+		if pattern.HasRest {
+			if len(pattern.Elements) > 0 {
+				p.write(", ")
+			}
+			p.write("...")
+			if pattern.Rest != nil {
+				p.write(pattern.Rest.Value)
+			}
+		}
+		p.write("]")
+	case *ast.HashPattern:
+		p.write("{")
+		for i, pair := range pattern.Pairs {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.expression(pair.Key, parser.LOWEST)
+			p.write(": ")
+			p.pattern(pair.Value)
+		}
+		p.write("}")
+	}
+}
+
+func (p *printer) expressionList(list []ast.Expression) {
+	for i, e := range list {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.expression(e, parser.LOWEST)
+	}
+}
+
+// arrayLiteral prints an array on one line, unless its first element
+// was on a separate line in the source; then each element gets a line.
+func (p *printer) arrayLiteral(a *ast.ArrayLiteral) {
+	if !startsOnNextLine(a.Token, a.Elements) {
+		p.write("[")
+		p.expressionList(a.Elements)
+		p.write("]")
+		return
+	}
+
+	p.write("[")
+	p.multiline(len(a.Elements), func(i int) ast.Expression { return a.Elements[i] }, func(i int) {
+		p.expression(a.Elements[i], parser.LOWEST)
+	})
+	p.write("]")
+}
+
+// setLiteral follows the same line breaking rule as arrayLiteral.
+func (p *printer) setLiteral(s *ast.SetLiteral) {
+	p.write("#{")
+	if !startsOnNextLine(s.Token, s.Elements) {
+		p.expressionList(s.Elements)
+	} else {
+		p.multiline(len(s.Elements), func(i int) ast.Expression { return s.Elements[i] }, func(i int) {
+			p.expression(s.Elements[i], parser.LOWEST)
+		})
+	}
+	p.write("}")
+}
+
+// hashLiteral prints pairs in source order, following the same line
+// breaking rule as arrayLiteral.
+func (p *printer) hashLiteral(h *ast.HashLiteral) {
+	keys := h.Keys()
+	pair := func(i int) {
+		p.expression(keys[i], parser.LOWEST)
+		p.write(": ")
+		p.expression(h.Pairs[keys[i]], parser.LOWEST)
+	}
+
+	if !startsOnNextLine(h.Token, keys) {
+		p.write("{")
+		for i := range keys {
+			if i > 0 {
+				p.write(", ")
+			}
+			pair(i)
+		}
+		p.write("}")
+		return
+	}
+
+	p.write("{")
+	p.multiline(len(keys), func(i int) ast.Expression { return keys[i] }, pair)
+	p.write("}")
+}
+
+// multiline prints n items of a literal one per line, each followed
+// by a comma, item(i) returns the expression the i-th item starts with.
+func (p *printer) multiline(n int, item func(i int) ast.Expression, print func(i int)) {
+	p.depth++
+	p.newline()
+	p.fresh = true
+	for i := 0; i < n; i++ {
+		pos := item(i).Pos()
+		p.commentsBefore(pos)
+		p.blankLineBefore(pos.Line)
+		print(i)
+		p.write(",")
+
+		next := endOfFile
+		if i+1 < n {
+			next = item(i + 1).Pos()
+		}
+		p.trailingComment(next)
+		p.newline()
+	}
+	p.depth--
+}
+
+func startsOnNextLine(open token.Token, items []ast.Expression) bool {
+	if len(items) == 0 || open.Line == 0 {
+		return false
+	}
+	return items[0].Pos().Line > open.Line
+}
+
+func integerLiteral(il *ast.IntegerLiteral) string {
+	if il.Token.Literal != "" {
+		return il.Token.Literal
+	}
+	return strconv.FormatInt(il.Value, 10)
+}
+
+// precedence returns how tightly e binds, using the parser's levels.
+func precedence(e ast.Expression) int {
+	switch e := e.(type) {
+	case *ast.InfixExpression:
+		return parser.Precedence(token.TokenType(e.Operator))
+	case *ast.PrefixExpression, *ast.SpawnExpression:
+		return parser.PREFIX
+	case *ast.CallExpression:
+		return parser.CALL
+	case *ast.IndexExpression, *ast.MemberExpression:
+		return parser.INDEX
+	case *ast.AssignExpression:
+		return parser.ASSIGN
+	default:
+		return atom
+	}
+}
+
+// endsWithBlock reports whether e is a statement-like expression, whose
+// closing brace makes a terminating semicolon unnecessary.
+func endsWithBlock(e ast.Expression) bool {
+	switch e.(type) {
+	case *ast.IfExpression, *ast.ForExpression, *ast.MatchExpression, *ast.TryExpression, *ast.SelectExpression:
+		return true
+	default:
+		return false
+	}
+}
+
+// continuesExpression reports whether the printed form of s starts with
+// a token that would continue the previous expression if it was not
+// terminated, e.g. `-` or `(`.
+func continuesExpression(s ast.Statement) bool {
+	es, ok := s.(*ast.ExpressionStatement)
+	if !ok {
+		return false
+	}
+
+	e, parentPrecedence := es.Expression, parser.LOWEST
+	for e != nil {
+		if precedence(e) < parentPrecedence {
+			return true // starts with "("
+		}
+		switch node := e.(type) {
+		case *ast.InfixExpression:
+			e, parentPrecedence = node.Left, precedence(node)
+		case *ast.CallExpression:
+			e, parentPrecedence = node.Function, parser.CALL
+		case *ast.IndexExpression:
+			e, parentPrecedence = node.Left, parser.CALL
+		case *ast.PrefixExpression:
+			return node.Operator == "-"
+		case *ast.ArrayLiteral:
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package format
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wmolicki/go-monkey/lexer"
+	"github.com/wmolicki/go-monkey/parser"
+)
+
+func TestSourceGolden(t *testing.T) {
+	inputs, err := filepath.Glob("testdata/*.input")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no test inputs found in testdata")
+	}
+
+	for _, input := range inputs {
+		src, err := os.ReadFile(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		golden, err := os.ReadFile(strings.TrimSuffix(input, ".input") + ".golden")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		formatted, err := Source(src)
+		if err != nil {
+			t.Errorf("%s: %v", input, err)
+			continue
+		}
+		if string(formatted) != string(golden) {
+			t.Errorf("%s: wrong output, expected:\n%s\ngot:\n%s", input, golden, formatted)
+		}
+	}
+}
+
+// TestIdempotency checks that formatting the corpus twice gives the same
+// result as formatting it once, and that the formatted program parses
+// into the same tree as the original.
+func TestIdempotency(t *testing.T) {
+	files, err := filepath.Glob("testdata/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files = append(files, "../test.monke")
+
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		once, err := Source(src)
+		if err != nil {
+			t.Errorf("%s: %v", file, err)
+			continue
+		}
+		twice, err := Source(once)
+		if err != nil {
+			t.Errorf("%s: formatted output does not parse: %v", file, err)
+			continue
+		}
+		if string(once) != string(twice) {
+			t.Errorf("%s: formatting is not idempotent, first:\n%s\nsecond:\n%s", file, once, twice)
+		}
+
+		if parse(t, string(src)) != parse(t, string(once)) {
+			t.Errorf("%s: formatting changed the program, before:\n%s\nafter:\n%s",
+				file, parse(t, string(src)), parse(t, string(once)))
+		}
+	}
+}
+
+func TestNode(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 + 2 * 3", "1 + 2 * 3;"},
+		{"(1 + 2) * 3", "(1 + 2) * 3;"},
+		{"let x = (5)", "let x = 5;"},
+		{"a + -b", "a + -b;"},
+		{"f(x)(y)", "f(x)(y);"},
+		{"(a + b)[0]", "(a + b)[0];"},
+		{"fn() { 1 }", "fn() {\n    1;\n};"},
+		{"if (x) { 1 }", "if (x) {\n    1;\n}"},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Fatalf("parser errors for %q: %v", tt.input, p.Errors())
+		}
+
+		if actual := Node(program); actual != tt.expected {
+			t.Errorf("expected %q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestSourceParseError(t *testing.T) {
+	if _, err := Source([]byte("let = 5;")); err == nil {
+		t.Errorf("expected an error for invalid source")
+	}
+}
+
+func parse(t *testing.T, src string) string {
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	return program.String()
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// JUnit XML report, as read by most CI servers. Each test file is a
+// test suite.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnitFile writes results to filename as a JUnit XML report.
+func writeJUnitFile(filename string, results []testFileResult) error {
+	report := junitTestSuites{}
+	var elapsed float64
+	for _, result := range results {
+		suite := junitTestSuite{Name: result.filename, Time: fmt.Sprintf("%.3f", result.elapsed.Seconds())}
+		for _, test := range result.tests {
+			tc := junitTestCase{Name: test.name, Classname: result.filename, Time: fmt.Sprintf("%.3f", test.elapsed.Seconds())}
+			if test.failure != "" {
+				tc.Failure = &junitFailure{Message: strings.SplitN(test.failure, "\n", 2)[0], Text: test.failure}
+				suite.Failures++
+			}
+			suite.Cases = append(suite.Cases, tc)
+		}
+		suite.Tests = len(suite.Cases)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Suites = append(report.Suites, suite)
+		elapsed += result.elapsed.Seconds()
+	}
+	report.Time = fmt.Sprintf("%.3f", elapsed)
+
+	out, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	out = append([]byte(xml.Header), out...)
+	if err := os.WriteFile(filename, append(out, '\n'), 0644); err != nil {
+		return fmt.Errorf("error writing JUnit report: %v", err)
+	}
+	return nil
+}
@@ -1,21 +1,44 @@
 package lexer
 
-import "github.com/wmolicki/go-monkey/token"
+import (
+	"strings"
+
+	"github.com/wmolicki/go-monkey/token"
+)
 
 type Lexer struct {
 	input        string
 	position     int  // points to current ch
 	readPosition int  // current reading position (after ch)
 	ch           byte // current char
+	line         int  // line of current ch
+	column       int  // column of current ch
+
+	comments []token.Token
 }
 
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	l := &Lexer{input: input, line: 1}
 	l.readChar()
+	// a #! line on top of an executable script is kept as a comment
+	if strings.HasPrefix(input, "#!") {
+		l.readComment()
+	}
 	return l
 }
 
+// Comments returns comments the lexer skipped so far, in source order.
+func (l *Lexer) Comments() []token.Token {
+	return l.comments
+}
+
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line += 1
+		l.column = 0
+	}
+	l.column += 1
+
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
@@ -29,6 +52,7 @@ func (l *Lexer) NextToken() token.Token {
 	var t token.Token
 
 	l.skipWhitespace()
+	line, column := l.line, l.column
 
 	switch l.ch {
 	case '=':
@@ -36,6 +60,10 @@ func (l *Lexer) NextToken() token.Token {
 			ch := l.ch
 			l.readChar()
 			t = token.Token{Type: token.EQ, Literal: string(ch) + string(l.ch)}
+		} else if l.peekChar() == '>' {
+			ch := l.ch
+			l.readChar()
+			t = token.Token{Type: token.FAT_ARROW, Literal: string(ch) + string(l.ch)}
 		} else {
 			t = newToken(token.ASSIGN, l.ch)
 		}
@@ -76,6 +104,21 @@ func (l *Lexer) NextToken() token.Token {
 	case '"':
 		t.Type = token.STRING
 		t.Literal = l.readString()
+	case '.':
+		if l.peekChar() == '.' && l.peekCharAt(2) == '.' {
+			l.readChar()
+			l.readChar()
+			t = token.Token{Type: token.ELLIPSIS, Literal: "..."}
+		} else {
+			t = newToken(token.DOT, l.ch)
+		}
+	case '#':
+		if l.peekChar() == '{' {
+			l.readChar()
+			t = token.Token{Type: token.HASH_LBRACE, Literal: "#{"}
+		} else {
+			t = newToken(token.ILLEGAL, l.ch)
+		}
 	case '[':
 		t = newToken(token.LBRACKET, l.ch)
 	case ']':
@@ -87,16 +130,19 @@ func (l *Lexer) NextToken() token.Token {
 		if isLetter(l.ch) {
 			t.Literal = l.readIdentifier()
 			t.Type = token.LookupIdentifier(t.Literal)
+			t.Line, t.Column = line, column
 			return t
 		} else if isDigit(l.ch) {
 			t.Literal = l.readNumber()
 			t.Type = token.INT
+			t.Line, t.Column = line, column
 			return t
 		} else {
 			t = newToken(token.ILLEGAL, l.ch)
 		}
 	}
 
+	t.Line, t.Column = line, column
 	l.readChar()
 	return t
 }
@@ -119,10 +165,31 @@ func isLetter(ch byte) bool {
 	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
 }
 
+// skipWhitespace skips whitespace and comments, comments are
+// recorded so tools like the formatter can put them back.
 func (l *Lexer) skipWhitespace() {
-	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
+	for {
+		switch {
+		case l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r':
+			l.readChar()
+		case l.ch == '/' && l.peekChar() == '/':
+			l.readComment()
+		default:
+			return
+		}
+	}
+}
+
+// readComment reads a // comment, or a #! line, up to (but not including)
+// the end of line.
+func (l *Lexer) readComment() {
+	t := token.Token{Type: token.COMMENT, Line: l.line, Column: l.column}
+	position := l.position
+	for l.ch != '\n' && l.ch != 0 {
 		l.readChar()
 	}
+	t.Literal = strings.TrimRight(l.input[position:l.position], "\r")
+	l.comments = append(l.comments, t)
 }
 
 func (l *Lexer) readNumber() string {
@@ -138,10 +205,16 @@ func isDigit(ch byte) bool {
 }
 
 func (l *Lexer) peekChar() byte {
-	if l.readPosition >= len(l.input) {
+	return l.peekCharAt(1)
+}
+
+// peekCharAt returns the char n positions after the current one.
+func (l *Lexer) peekCharAt(n int) byte {
+	position := l.position + n
+	if position >= len(l.input) {
 		return 0
 	}
-	return l.input[l.readPosition]
+	return l.input[position]
 }
 
 func (l *Lexer) readString() string {
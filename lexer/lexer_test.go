@@ -1,6 +1,7 @@
 package lexer
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/wmolicki/go-monkey/token"
@@ -30,6 +31,9 @@ if ( 5 < 10) {
 "foo bar"
 [1, 2];
 {"foo": bar};
+macro(x, y) { x + y; };
+p.x = [a, ...b] => c;
+#{1};
 `
 
 	tests := []struct {
@@ -133,6 +137,37 @@ if ( 5 < 10) {
 		{token.RBRACE, "}"},
 		{token.SEMICOLON, ";"},
 
+		{token.MACRO, "macro"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x"},
+		{token.COMMA, ","},
+		{token.IDENT, "y"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.IDENT, "x"},
+		{token.PLUS, "+"},
+		{token.IDENT, "y"},
+		{token.SEMICOLON, ";"},
+		{token.RBRACE, "}"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "p"},
+		{token.DOT, "."},
+		{token.IDENT, "x"},
+		{token.ASSIGN, "="},
+		{token.LBRACKET, "["},
+		{token.IDENT, "a"},
+		{token.COMMA, ","},
+		{token.ELLIPSIS, "..."},
+		{token.IDENT, "b"},
+		{token.RBRACKET, "]"},
+		{token.FAT_ARROW, "=>"},
+		{token.IDENT, "c"},
+		{token.SEMICOLON, ";"},
+		{token.HASH_LBRACE, "#{"},
+		{token.INT, "1"},
+		{token.RBRACE, "}"},
+		{token.SEMICOLON, ";"},
+
 		{token.EOF, ""},
 	}
 
@@ -150,3 +185,116 @@ if ( 5 < 10) {
 		}
 	}
 }
+
+func TestTokenPositions(t *testing.T) {
+	input := `let x = 5;
+  x + "ab";`
+
+	tests := []struct {
+		expectedType   token.TokenType
+		expectedLine   int
+		expectedColumn int
+	}{
+		{token.LET, 1, 1},
+		{token.IDENT, 1, 5},
+		{token.ASSIGN, 1, 7},
+		{token.INT, 1, 9},
+		{token.SEMICOLON, 1, 10},
+		{token.IDENT, 2, 3},
+		{token.PLUS, 2, 5},
+		{token.STRING, 2, 7},
+		{token.SEMICOLON, 2, 11},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokenType wrong, expected: %q, got: %q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Line != tt.expectedLine || tok.Column != tt.expectedColumn {
+			t.Fatalf("tests[%d] - position wrong, expected: %d:%d, got: %d:%d",
+				i, tt.expectedLine, tt.expectedColumn, tok.Line, tok.Column)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// first
+let x = 5; // second
+x / 2 //third`
+
+	l := New(input)
+
+	expectedTypes := []token.TokenType{
+		token.LET, token.IDENT, token.ASSIGN, token.INT, token.SEMICOLON,
+		token.IDENT, token.SLASH, token.INT, token.EOF,
+	}
+	for i, expected := range expectedTypes {
+		tok := l.NextToken()
+		if tok.Type != expected {
+			t.Fatalf("tests[%d] - tokenType wrong, expected: %q, got: %q", i, expected, tok.Type)
+		}
+	}
+
+	expectedComments := []token.Token{
+		{Type: token.COMMENT, Literal: "// first", Line: 1, Column: 1},
+		{Type: token.COMMENT, Literal: "// second", Line: 2, Column: 12},
+		{Type: token.COMMENT, Literal: "//third", Line: 3, Column: 7},
+	}
+	comments := l.Comments()
+	if len(comments) != len(expectedComments) {
+		t.Fatalf("wrong number of comments, expected: %d, got: %d", len(expectedComments), len(comments))
+	}
+	for i, expected := range expectedComments {
+		if comments[i] != expected {
+			t.Errorf("comments[%d] wrong, expected: %+v, got: %+v", i, expected, comments[i])
+		}
+	}
+}
+
+func TestShebang(t *testing.T) {
+	tests := []struct {
+		input            string
+		expectedTokens   []token.Token
+		expectedComments []token.Token
+	}{
+		{
+			"#!/usr/bin/env monke\nx",
+			[]token.Token{{Type: token.IDENT, Literal: "x", Line: 2, Column: 1}},
+			[]token.Token{{Type: token.COMMENT, Literal: "#!/usr/bin/env monke", Line: 1, Column: 1}},
+		},
+		{
+			"#!monke\r\n",
+			nil,
+			[]token.Token{{Type: token.COMMENT, Literal: "#!monke", Line: 1, Column: 1}},
+		},
+		{
+			// only the first line can be a #! line
+			"x\n#!y",
+			[]token.Token{
+				{Type: token.IDENT, Literal: "x", Line: 1, Column: 1},
+				{Type: token.ILLEGAL, Literal: "#", Line: 2, Column: 1},
+				{Type: token.BANG, Literal: "!", Line: 2, Column: 2},
+				{Type: token.IDENT, Literal: "y", Line: 2, Column: 3},
+			},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		var tokens []token.Token
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+			tokens = append(tokens, tok)
+		}
+		if !reflect.DeepEqual(tokens, tt.expectedTokens) {
+			t.Errorf("%q: wrong tokens.\nwant=%+v\ngot= %+v", tt.input, tt.expectedTokens, tokens)
+		}
+		if !reflect.DeepEqual(l.Comments(), tt.expectedComments) {
+			t.Errorf("%q: wrong comments.\nwant=%+v\ngot= %+v", tt.input, tt.expectedComments, l.Comments())
+		}
+	}
+}
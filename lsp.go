@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/wmolicki/go-monkey/lsp"
+)
+
+// runLsp implements `monke lsp`, it speaks the Language Server Protocol
+// with an editor over standard input and output.
+func runLsp(args []string) int {
+	fs := flag.NewFlagSet("lsp", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: monke lsp\n")
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	if err := lsp.NewServer().Serve(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	return exitOK
+}
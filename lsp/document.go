@@ -0,0 +1,192 @@
+package lsp
+
+import (
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/wmolicki/go-monkey/ast"
+	"github.com/wmolicki/go-monkey/evaluator"
+	"github.com/wmolicki/go-monkey/lexer"
+	"github.com/wmolicki/go-monkey/parser"
+	"github.com/wmolicki/go-monkey/resolver"
+	"github.com/wmolicki/go-monkey/token"
+)
+
+// document is an open file analysed after each change. A program with
+// syntax errors is still resolved, so features keep working as far as
+// the parser got while it's being edited.
+type document struct {
+	uri   string
+	lines []string
+
+	program        *ast.Program
+	parseErrors    []string
+	parsePositions []token.Position
+	resolved       *resolver.Result
+
+	// idents are identifiers which name variables, in source order
+	idents []*ast.Identifier
+	// declared holds identifiers defining names
+	declared map[*ast.Identifier]bool
+	// definitions maps declarations to the node defining them, e.g. a
+	// let statement or the function of a parameter
+	definitions map[*ast.Identifier]ast.Node
+}
+
+// universe holds names defined everywhere, args is defined by `monke run`.
+func universe() []string {
+	return append(evaluator.BuiltinNames(), "args")
+}
+
+func newDocument(uri, text string) *document {
+	d := &document{
+		uri:         uri,
+		lines:       strings.Split(text, "\n"),
+		declared:    map[*ast.Identifier]bool{},
+		definitions: map[*ast.Identifier]ast.Node{},
+	}
+
+	p := parser.New(lexer.New(text))
+	d.program = p.ParseProgram()
+	d.parseErrors = p.Errors()
+	d.parsePositions = p.ErrorPositions()
+	d.resolved = resolver.Resolve(d.program, universe())
+
+	for _, decl := range d.resolved.Declarations {
+		d.declared[decl] = true
+	}
+
+	// names of struct fields and methods are not variables
+	members := map[*ast.Identifier]bool{}
+	ast.Inspect(d.program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.LetStatement:
+			d.definitions[node.Name] = node
+		case *ast.StructStatement:
+			d.definitions[node.Name] = node
+			for _, field := range node.Fields {
+				members[field] = true
+			}
+			for _, method := range node.Methods {
+				members[method.Name] = true
+			}
+		case *ast.FunctionLiteral:
+			for _, param := range node.Parameters {
+				d.definitions[param] = node
+			}
+		case *ast.MacroLiteral:
+			for _, param := range node.Parameters {
+				d.definitions[param] = node
+			}
+		case *ast.TryExpression:
+			if node.CatchParameter != nil {
+				d.definitions[node.CatchParameter] = node
+			}
+		case *ast.SelectExpression:
+			for _, c := range node.Cases {
+				if c.Binding != nil {
+					d.definitions[c.Binding] = node
+				}
+			}
+		case *ast.MatchExpression:
+			match := node
+			for _, arm := range node.Arms {
+				ast.Inspect(arm.Pattern, func(node ast.Node) bool {
+					if ident, ok := node.(*ast.Identifier); ok {
+						d.definitions[ident] = match
+					}
+					return true
+				})
+			}
+		case *ast.Identifier:
+			if !members[node] {
+				d.idents = append(d.idents, node)
+			}
+		}
+		return true
+	})
+	return d
+}
+
+// identifierAt returns the identifier at pos, including the position
+// right after it, or nil if there is none.
+func (d *document) identifierAt(pos Position) *ast.Identifier {
+	at := d.tokenPosition(pos)
+	for _, ident := range d.idents {
+		start := ident.Pos()
+		if start.Line == at.Line && start.Column <= at.Column && at.Column <= start.Column+len(ident.Value) {
+			return ident
+		}
+	}
+	return nil
+}
+
+// declaration returns the identifier declaring the name ident refers
+// to, ident itself if it's a declaration, or nil for undefined names
+// and builtins.
+func (d *document) declaration(ident *ast.Identifier) *ast.Identifier {
+	if d.declared[ident] {
+		return ident
+	}
+	return d.resolved.Definitions[ident]
+}
+
+// references returns identifiers referring to decl in source order,
+// decl itself is included if includeDeclaration is set.
+func (d *document) references(decl *ast.Identifier, includeDeclaration bool) []*ast.Identifier {
+	var refs []*ast.Identifier
+	for _, ident := range d.idents {
+		if (ident == decl && includeDeclaration) || d.resolved.Definitions[ident] == decl {
+			refs = append(refs, ident)
+		}
+	}
+	return refs
+}
+
+// position converts a position of a token to an LSP position.
+func (d *document) position(pos token.Position) Position {
+	line := pos.Line - 1
+	if line < 0 {
+		return Position{}
+	}
+	if line >= len(d.lines) {
+		return Position{Line: line}
+	}
+	text := d.lines[line]
+	column := pos.Column - 1
+	if column > len(text) {
+		column = len(text)
+	}
+	return Position{Line: line, Character: utf16Len(text[:column])}
+}
+
+// tokenPosition converts an LSP position to a position of a token.
+func (d *document) tokenPosition(pos Position) token.Position {
+	if pos.Line < 0 || pos.Line >= len(d.lines) {
+		return token.Position{Line: pos.Line + 1, Column: 1}
+	}
+	text := d.lines[pos.Line]
+	offset, units := 0, 0
+	for offset < len(text) && units < pos.Character {
+		r, size := utf8.DecodeRuneInString(text[offset:])
+		offset += size
+		units += len(utf16.Encode([]rune{r}))
+	}
+	return token.Position{Line: pos.Line + 1, Column: offset + 1}
+}
+
+// identRange returns the range of ident in the document.
+func (d *document) identRange(ident *ast.Identifier) Range {
+	start := ident.Pos()
+	end := token.Position{Line: start.Line, Column: start.Column + len(ident.Value)}
+	return Range{Start: d.position(start), End: d.position(end)}
+}
+
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += len(utf16.Encode([]rune{r}))
+	}
+	return n
+}
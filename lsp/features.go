@@ -0,0 +1,252 @@
+package lsp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/wmolicki/go-monkey/ast"
+	"github.com/wmolicki/go-monkey/lexer"
+	"github.com/wmolicki/go-monkey/token"
+)
+
+// diagnostics returns syntax errors of the document, or if there are
+// none, uses of undefined names.
+func (d *document) diagnostics() []Diagnostic {
+	diagnostics := []Diagnostic{}
+	for i, msg := range d.parseErrors {
+		pos := d.position(d.parsePositions[i])
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    Range{Start: pos, End: pos},
+			Severity: severityError,
+			Source:   "monke",
+			Message:  msg,
+		})
+	}
+	if len(diagnostics) > 0 {
+		return diagnostics
+	}
+
+	// names may be defined in ways the resolver can't see, e.g. by
+	// macros, so these are only warnings
+	for _, ident := range d.resolved.Undefined {
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    d.identRange(ident),
+			Severity: severityWarning,
+			Source:   "monke",
+			Message:  "identifier not found: " + ident.Value,
+		})
+	}
+	return diagnostics
+}
+
+func (d *document) definition(pos Position) *Location {
+	ident := d.identifierAt(pos)
+	if ident == nil {
+		return nil
+	}
+	decl := d.declaration(ident)
+	if decl == nil {
+		return nil
+	}
+	return &Location{URI: d.uri, Range: d.identRange(decl)}
+}
+
+func (d *document) referenceLocations(pos Position, includeDeclaration bool) []Location {
+	ident := d.identifierAt(pos)
+	if ident == nil {
+		return nil
+	}
+	decl := d.declaration(ident)
+	if decl == nil {
+		return nil
+	}
+
+	locations := []Location{}
+	for _, ref := range d.references(decl, includeDeclaration) {
+		locations = append(locations, Location{URI: d.uri, Range: d.identRange(ref)})
+	}
+	return locations
+}
+
+// hover describes the name at pos with the kind of its value, if it
+// can be inferred.
+func (d *document) hover(pos Position) *Hover {
+	ident := d.identifierAt(pos)
+	if ident == nil {
+		return nil
+	}
+	kind := string(d.infer(ident, 0))
+
+	var text string
+	decl := d.declaration(ident)
+	switch def := d.definitions[decl].(type) {
+	case nil:
+		switch {
+		case decl != nil:
+			text = ident.Value
+		case ident.Value == "args":
+			text = "args: " + kind
+		case isBuiltin(ident.Value):
+			text = "builtin " + ident.Value
+			if result, ok := builtinResults[ident.Value]; ok {
+				text += " returns " + string(result)
+			}
+		default:
+			return nil
+		}
+	case *ast.LetStatement:
+		text = "let " + ident.Value
+		if kind != "" {
+			text += ": " + kind
+		}
+		if fn, ok := def.Value.(*ast.FunctionLiteral); ok {
+			text += parameters(fn.Parameters)
+		}
+	case *ast.StructStatement:
+		fields := []string{}
+		for _, field := range def.Fields {
+			fields = append(fields, field.Value)
+		}
+		text = "struct " + def.Name.Value + " { " + strings.Join(fields, ", ") + " }"
+	case *ast.FunctionLiteral, *ast.MacroLiteral:
+		text = "parameter " + ident.Value
+	case *ast.TryExpression:
+		text = "catch " + ident.Value + ": " + kind
+	default:
+		text = ident.Value
+	}
+
+	return &Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: "```monke\n" + text + "\n```"},
+		Range:    d.identRange(ident),
+	}
+}
+
+func parameters(params []*ast.Identifier) string {
+	names := []string{}
+	for _, param := range params {
+		names = append(names, param.Value)
+	}
+	return "(" + strings.Join(names, ", ") + ")"
+}
+
+// completion returns names usable at pos, followed by builtins which
+// are not shadowed and keywords. Clients filter them by what was typed.
+func (d *document) completion(pos Position) []CompletionItem {
+	items := []CompletionItem{}
+	seen := map[string]bool{}
+	for _, name := range d.resolved.NamesAt(d.tokenPosition(pos)) {
+		seen[name] = true
+		items = append(items, CompletionItem{Label: name, Kind: completionVariable})
+	}
+	for _, name := range universe() {
+		if !seen[name] {
+			items = append(items, CompletionItem{Label: name, Kind: completionFunction, Detail: "builtin"})
+		}
+	}
+	for _, keyword := range token.Keywords() {
+		items = append(items, CompletionItem{Label: keyword, Kind: completionKeyword})
+	}
+	return items
+}
+
+// symbols returns let bindings and structs of the document, bindings
+// inside of functions are children of the function.
+func (d *document) symbols() []DocumentSymbol {
+	return d.blockSymbols(d.program.Statements)
+}
+
+func (d *document) blockSymbols(statements []ast.Statement) []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+	for _, stmt := range statements {
+		switch stmt := stmt.(type) {
+		case *ast.LetStatement:
+			if stmt.Name == nil {
+				continue
+			}
+			symbol := DocumentSymbol{
+				Name:           stmt.Name.Value,
+				Detail:         string(d.infer(stmt.Value, 0)),
+				Kind:           symbolVariable,
+				Range:          Range{Start: d.position(stmt.Pos()), End: d.identRange(stmt.Name).End},
+				SelectionRange: d.identRange(stmt.Name),
+			}
+			if fn, ok := stmt.Value.(*ast.FunctionLiteral); ok && fn.Body != nil {
+				symbol.Kind = symbolFunction
+				symbol.Detail = "fn" + parameters(fn.Parameters)
+				symbol.Range.End = d.position(after(fn.Body.EndToken))
+				symbol.Children = d.blockSymbols(fn.Body.Statements)
+			}
+			symbols = append(symbols, symbol)
+		case *ast.StructStatement:
+			symbol := DocumentSymbol{
+				Name:           stmt.Name.Value,
+				Kind:           symbolStruct,
+				Range:          Range{Start: d.position(stmt.Pos()), End: d.position(after(stmt.EndToken))},
+				SelectionRange: d.identRange(stmt.Name),
+			}
+			for _, field := range stmt.Fields {
+				symbol.Children = append(symbol.Children, DocumentSymbol{
+					Name: field.Value, Kind: symbolField, Range: d.identRange(field), SelectionRange: d.identRange(field),
+				})
+			}
+			for _, method := range stmt.Methods {
+				symbol.Children = append(symbol.Children, DocumentSymbol{
+					Name: method.Name.Value, Detail: "fn" + parameters(method.Function.Parameters), Kind: symbolMethod,
+					Range: d.identRange(method.Name), SelectionRange: d.identRange(method.Name),
+				})
+			}
+			symbols = append(symbols, symbol)
+		}
+	}
+	return symbols
+}
+
+// after returns the position following a token.
+func after(t token.Token) token.Position {
+	return token.Position{Line: t.Line, Column: t.Column + len(t.Literal)}
+}
+
+// rename returns edits renaming the binding at pos and its uses to newName.
+func (d *document) rename(pos Position, newName string) (*WorkspaceEdit, error) {
+	l := lexer.New(newName)
+	if tok := l.NextToken(); tok.Type != token.IDENT || tok.Literal != newName || l.NextToken().Type != token.EOF {
+		return nil, fmt.Errorf("%q is not a valid name", newName)
+	}
+
+	ident := d.identifierAt(pos)
+	if ident == nil {
+		return nil, fmt.Errorf("no name to rename")
+	}
+	decl := d.declaration(ident)
+	if decl == nil {
+		return nil, fmt.Errorf("%s is not defined in this file", ident.Value)
+	}
+
+	refs := d.references(decl, true)
+	for _, ref := range refs {
+		// the new name must not be shadowed where the binding is used,
+		// nor capture uses of an existing binding
+		if ref != decl && contains(d.resolved.NamesAt(ref.Pos()), newName) {
+			return nil, fmt.Errorf("%s is already defined where %s is used at %s", newName, ident.Value, ref.Pos())
+		}
+	}
+	if isBuiltin(newName) || contains(d.resolved.NamesAt(decl.Pos()), newName) {
+		return nil, fmt.Errorf("%s is already defined", newName)
+	}
+
+	edits := []TextEdit{}
+	for _, ref := range refs {
+		edits = append(edits, TextEdit{Range: d.identRange(ref), NewText: newName})
+	}
+	return &WorkspaceEdit{Changes: map[string][]TextEdit{d.uri: edits}}, nil
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,276 @@
+package lsp
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const source = `let add = fn(a, b) { a + b };
+let total = add(1, 2);
+let name = "monke" + "!";
+struct Point { x, y fn norm(self) { self.x * self.x } };
+let p = Point(1, 2);
+let f = fn(xs) { let n = len(xs); n };
+try { f(total) } catch (e) { e };
+let k = "🐒"; k + total`
+
+func pos(line, character int) Position {
+	return Position{Line: line, Character: character}
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Character)
+}
+
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []Diagnostic
+	}{
+		{source, []Diagnostic{}},
+		{"let x = 1;\nlet = 2", []Diagnostic{
+			{Range: Range{Start: pos(1, 4), End: pos(1, 4)}, Severity: severityError, Source: "monke", Message: "expected next token to be IDENT, got = instead"},
+			{Range: Range{Start: pos(1, 4), End: pos(1, 4)}, Severity: severityError, Source: "monke", Message: "no prefix parse function for '=' found"},
+		}},
+		{"let x = 1;\nx + yy", []Diagnostic{
+			{Range: Range{Start: pos(1, 4), End: pos(1, 6)}, Severity: severityWarning, Source: "monke", Message: "identifier not found: yy"},
+		}},
+		{"puts(args)", []Diagnostic{}},
+	}
+
+	for _, tt := range tests {
+		got := newDocument("file:///a.monke", tt.input).diagnostics()
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%q: wrong diagnostics.\nwant=%+v\ngot= %+v", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestDefinition(t *testing.T) {
+	tests := []struct {
+		pos Position
+		// start of the definition, empty if there is none
+		expected string
+	}{
+		{pos(1, 12), "0:4"},  // add
+		{pos(1, 15), "0:4"},  // right after add
+		{pos(0, 21), "0:13"}, // a in the body of add
+		{pos(0, 4), "0:4"},   // the definition itself
+		{pos(4, 8), "3:7"},   // Point
+		{pos(5, 34), "5:21"}, // n
+		{pos(6, 29), "6:24"}, // e
+		{pos(7, 14), "7:4"},  // k
+		{pos(7, 18), "1:4"},  // total following an emoji
+		{pos(5, 25), ""},     // len is a builtin
+		{pos(3, 41), ""},     // x is a field
+		{pos(2, 13), ""},     // in a string
+		{pos(100, 0), ""},    // no such line
+	}
+
+	d := newDocument("file:///a.monke", source)
+	for _, tt := range tests {
+		got := ""
+		if loc := d.definition(tt.pos); loc != nil {
+			got = loc.Range.Start.String()
+		}
+		if got != tt.expected {
+			t.Errorf("%s: wrong definition. expected=%q, got=%q", tt.pos, tt.expected, got)
+		}
+	}
+}
+
+func TestReferences(t *testing.T) {
+	tests := []struct {
+		pos                Position
+		includeDeclaration bool
+		expected           []string
+	}{
+		{pos(0, 4), true, []string{"0:4", "1:12"}},
+		{pos(1, 13), false, []string{"1:12"}},
+		{pos(1, 4), true, []string{"1:4", "6:8", "7:18"}},
+		{pos(3, 36), true, []string{"3:28", "3:36", "3:45"}},
+		{pos(5, 25), true, nil},
+	}
+
+	d := newDocument("file:///a.monke", source)
+	for _, tt := range tests {
+		var got []string
+		for _, loc := range d.referenceLocations(tt.pos, tt.includeDeclaration) {
+			got = append(got, loc.Range.Start.String())
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: wrong references.\nwant=%q\ngot= %q", tt.pos, tt.expected, got)
+		}
+	}
+}
+
+func TestHover(t *testing.T) {
+	tests := []struct {
+		pos      Position
+		expected string
+	}{
+		{pos(0, 4), "let add: FUNCTION(a, b)"},
+		// the result of add depends on its arguments
+		{pos(1, 4), "let total"},
+		{pos(2, 4), "let name: STRING"},
+		{pos(3, 7), "struct Point { x, y }"},
+		{pos(4, 4), "let p: STRUCT"},
+		{pos(0, 21), "parameter a"},
+		{pos(5, 21), "let n: INTEGER"},
+		{pos(5, 25), "builtin len returns INTEGER"},
+		{pos(6, 29), "catch e: ERROR_VALUE"},
+		{pos(7, 14), "let k: STRING"},
+		{pos(2, 13), ""},
+	}
+
+	d := newDocument("file:///a.monke", source)
+	for _, tt := range tests {
+		got := ""
+		if hover := d.hover(tt.pos); hover != nil {
+			got = strings.TrimSuffix(strings.TrimPrefix(hover.Contents.Value, "```monke\n"), "\n```")
+		}
+		if got != tt.expected {
+			t.Errorf("%s: wrong hover. expected=%q, got=%q", tt.pos, tt.expected, got)
+		}
+	}
+
+	hover := newDocument("file:///a.monke", "args").hover(pos(0, 0))
+	if hover == nil || !strings.Contains(hover.Contents.Value, "args: ARRAY") {
+		t.Errorf("wrong hover for args: %+v", hover)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	tests := []struct {
+		pos      Position
+		included []string
+		excluded []string
+	}{
+		{pos(0, 0), []string{"len", "puts", "args", "let", "fn"}, []string{"add", "total"}},
+		{pos(1, 12), []string{"add", "len"}, []string{"name", "a"}},
+		{pos(0, 21), []string{"a", "b", "add", "total", "k"}, []string{"xs"}},
+		{pos(5, 34), []string{"n", "xs", "f"}, []string{"a"}},
+	}
+
+	d := newDocument("file:///a.monke", source)
+	for _, tt := range tests {
+		labels := map[string]bool{}
+		for _, item := range d.completion(tt.pos) {
+			if labels[item.Label] {
+				t.Errorf("%s: duplicate completion %s", tt.pos, item.Label)
+			}
+			labels[item.Label] = true
+		}
+		for _, name := range tt.included {
+			if !labels[name] {
+				t.Errorf("%s: %s is not completed", tt.pos, name)
+			}
+		}
+		for _, name := range tt.excluded {
+			if labels[name] {
+				t.Errorf("%s: %s is completed", tt.pos, name)
+			}
+		}
+	}
+}
+
+func TestSymbols(t *testing.T) {
+	var describe func(symbols []DocumentSymbol) []string
+	describe = func(symbols []DocumentSymbol) []string {
+		var out []string
+		for _, s := range symbols {
+			line := fmt.Sprintf("%s %d %s %s-%s", s.Name, s.Kind, s.Detail, s.Range.Start, s.Range.End)
+			for _, child := range describe(s.Children) {
+				line += " {" + child + "}"
+			}
+			out = append(out, line)
+		}
+		return out
+	}
+
+	expected := []string{
+		"add 12 fn(a, b) 0:0-0:28",
+		"total 13  1:0-1:9",
+		"name 13 STRING 2:0-2:8",
+		"Point 23  3:0-3:55 {x 8  3:15-3:16} {y 8  3:18-3:19} {norm 6 fn(self) 3:23-3:27}",
+		"p 13 STRUCT 4:0-4:5",
+		"f 12 fn(xs) 5:0-5:37 {n 13 INTEGER 5:17-5:22}",
+		"k 13 STRING 7:0-7:5",
+	}
+	got := describe(newDocument("file:///a.monke", source).symbols())
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("wrong symbols.\nwant=%q\ngot= %q", expected, got)
+	}
+}
+
+func TestRename(t *testing.T) {
+	tests := []struct {
+		pos      Position
+		newName  string
+		expected []string
+		err      string
+	}{
+		{pos(1, 4), "sum", []string{"1:4", "6:8", "7:18"}, ""},
+		{pos(6, 8), "sum", []string{"1:4", "6:8", "7:18"}, ""},
+		{pos(7, 14), "monkey", []string{"7:4", "7:14"}, ""},
+		{pos(2, 4), "title", []string{"2:4"}, ""},
+		{pos(1, 4), "1x", nil, `"1x" is not a valid name`},
+		{pos(1, 4), "let", nil, `"let" is not a valid name`},
+		{pos(1, 4), "a b", nil, `"a b" is not a valid name`},
+		{pos(1, 4), "len", nil, "len is already defined"},
+		{pos(1, 4), "add", nil, "add is already defined where total is used at 7:9"},
+		{pos(6, 24), "f", nil, "f is already defined where e is used at 7:30"},
+		{pos(2, 4), "add", nil, "add is already defined"},
+		{pos(0, 13), "b", nil, "b is already defined where a is used at 1:22"},
+		{pos(5, 25), "length", nil, "len is not defined in this file"},
+		{pos(2, 13), "x", nil, "no name to rename"},
+	}
+
+	d := newDocument("file:///a.monke", source)
+	for _, tt := range tests {
+		edit, err := d.rename(tt.pos, tt.newName)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%s to %s: wrong error. expected=%q, got=%v", tt.pos, tt.newName, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s to %s: unexpected error: %v", tt.pos, tt.newName, err)
+			continue
+		}
+
+		var got []string
+		for _, e := range edit.Changes["file:///a.monke"] {
+			if e.NewText != tt.newName {
+				t.Errorf("%s to %s: wrong new text %q", tt.pos, tt.newName, e.NewText)
+			}
+			got = append(got, e.Range.Start.String())
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s to %s: wrong edits.\nwant=%q\ngot= %q", tt.pos, tt.newName, tt.expected, got)
+		}
+	}
+}
+
+// Documents are analysed as they're typed, so no prefix of a program
+// may break any feature.
+func TestIncompleteDocuments(t *testing.T) {
+	for i := range source {
+		d := newDocument("file:///a.monke", source[:i])
+		for line := range d.lines {
+			for character := 0; character < 40; character += 3 {
+				p := pos(line, character)
+				d.diagnostics()
+				d.definition(p)
+				d.referenceLocations(p, true)
+				d.hover(p)
+				d.completion(p)
+				d.rename(p, "renamed")
+			}
+		}
+		d.symbols()
+	}
+}
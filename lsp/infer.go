@@ -0,0 +1,143 @@
+package lsp
+
+import (
+	"github.com/wmolicki/go-monkey/ast"
+	"github.com/wmolicki/go-monkey/object"
+)
+
+// builtinResults are kinds of values returned by builtins, builtins
+// returning values of different kinds are left out.
+var builtinResults = map[string]object.ObjectType{
+	"len":       object.INTEGER_OBJ,
+	"rest":      object.ARRAY_OBJ,
+	"push":      object.ARRAY_OBJ,
+	"puts":      object.NULL_OBJ,
+	"error":     object.ERROR_VALUE_OBJ,
+	"type":      object.STRING_OBJ,
+	"compare":   object.INTEGER_OBJ,
+	"sort":      object.ARRAY_OBJ,
+	"iter":      object.ITERATOR_OBJ,
+	"to_array":  object.ARRAY_OBJ,
+	"set":       object.SET_OBJ,
+	"chan":      object.CHANNEL_OBJ,
+	"read_all":  object.STRING_OBJ,
+	"assert_eq": object.NULL_OBJ,
+}
+
+// maxInferDepth limits how many definitions are followed when inferring
+// a kind, so cyclic definitions terminate.
+const maxInferDepth = 16
+
+// infer returns the kind of the value expr evaluates to, or "" if it
+// can't be told without running the program.
+func (d *document) infer(expr ast.Expression, depth int) object.ObjectType {
+	if depth > maxInferDepth {
+		return ""
+	}
+
+	switch expr := expr.(type) {
+	case *ast.IntegerLiteral:
+		return object.INTEGER_OBJ
+	case *ast.StringLiteral:
+		return object.STRING_OBJ
+	case *ast.Boolean:
+		return object.BOOLEAN_OBJ
+	case *ast.ArrayLiteral:
+		return object.ARRAY_OBJ
+	case *ast.HashLiteral:
+		return object.HASH_OBJ
+	case *ast.SetLiteral:
+		return object.SET_OBJ
+	case *ast.FunctionLiteral:
+		return object.FUNCTION_OBJ
+	case *ast.MacroLiteral:
+		return object.MACRO_OBJ
+	case *ast.SpawnExpression:
+		return object.CHANNEL_OBJ
+	case *ast.PrefixExpression:
+		switch expr.Operator {
+		case "!":
+			return object.BOOLEAN_OBJ
+		case "-":
+			return object.INTEGER_OBJ
+		}
+	case *ast.InfixExpression:
+		switch expr.Operator {
+		case "==", "!=", "<", ">":
+			return object.BOOLEAN_OBJ
+		}
+		left, right := d.infer(expr.Left, depth+1), d.infer(expr.Right, depth+1)
+		if expr.Operator == "+" && (left == object.STRING_OBJ || right == object.STRING_OBJ) {
+			return object.STRING_OBJ
+		}
+		if left == object.INTEGER_OBJ && right == object.INTEGER_OBJ {
+			return object.INTEGER_OBJ
+		}
+	case *ast.Identifier:
+		return d.inferIdentifier(expr, depth)
+	case *ast.CallExpression:
+		return d.inferCall(expr, depth)
+	}
+	return ""
+}
+
+func (d *document) inferIdentifier(ident *ast.Identifier, depth int) object.ObjectType {
+	decl := d.declaration(ident)
+	if decl == nil {
+		switch {
+		case ident.Value == "args":
+			return object.ARRAY_OBJ
+		case isBuiltin(ident.Value):
+			return object.BUILTIN_OBJ
+		}
+		return ""
+	}
+	switch def := d.definitions[decl].(type) {
+	case *ast.LetStatement:
+		return d.infer(def.Value, depth+1)
+	case *ast.StructStatement:
+		return object.STRUCT_TYPE_OBJ
+	case *ast.TryExpression:
+		return object.ERROR_VALUE_OBJ
+	}
+	return ""
+}
+
+// inferCall infers results of builtins, struct constructors and functions
+// whose last statement is an expression or a return.
+func (d *document) inferCall(call *ast.CallExpression, depth int) object.ObjectType {
+	ident, ok := call.Function.(*ast.Identifier)
+	if !ok {
+		return ""
+	}
+	decl := d.declaration(ident)
+	if decl == nil {
+		return builtinResults[ident.Value]
+	}
+
+	switch def := d.definitions[decl].(type) {
+	case *ast.StructStatement:
+		return object.STRUCT_OBJ
+	case *ast.LetStatement:
+		fn, ok := def.Value.(*ast.FunctionLiteral)
+		if !ok || fn.Body == nil || len(fn.Body.Statements) == 0 {
+			return ""
+		}
+		switch last := fn.Body.Statements[len(fn.Body.Statements)-1].(type) {
+		case *ast.ExpressionStatement:
+			return d.infer(last.Expression, depth+1)
+		case *ast.ReturnStatement:
+			return d.infer(last.ReturnValue, depth+1)
+		}
+	}
+	return ""
+}
+
+func isBuiltin(name string) bool {
+	for _, builtin := range universe() {
+		if builtin == name {
+			return true
+		}
+	}
+	return false
+}
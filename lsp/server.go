@@ -0,0 +1,296 @@
+// Package lsp implements a Language Server Protocol server for Monkey
+// programs. Documents are analysed with the parser and the resolver on
+// each change, the server doesn't run them.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+)
+
+// ErrNoShutdown is returned by Serve if the client exits, or the
+// connection is closed, without asking the server to shut down first.
+var ErrNoShutdown = errors.New("exit without shutdown")
+
+// Server answers requests of a single client.
+type Server struct {
+	out      io.Writer
+	docs     map[string]*document
+	shutdown bool
+}
+
+// NewServer returns a server without open documents.
+func NewServer() *Server {
+	return &Server{docs: map[string]*document{}}
+}
+
+type handler func(s *Server, params json.RawMessage) (interface{}, error)
+
+// handlers of requests and notifications, results of notifications
+// are not sent.
+var handlers = map[string]handler{
+	"initialize":                  (*Server).initialize,
+	"initialized":                 ignore,
+	"shutdown":                    (*Server).shutdownRequest,
+	"textDocument/didOpen":        (*Server).didOpen,
+	"textDocument/didChange":      (*Server).didChange,
+	"textDocument/didClose":       (*Server).didClose,
+	"textDocument/didSave":        ignore,
+	"textDocument/definition":     (*Server).definition,
+	"textDocument/references":     (*Server).references,
+	"textDocument/hover":          (*Server).hover,
+	"textDocument/completion":     (*Server).completion,
+	"textDocument/documentSymbol": (*Server).documentSymbol,
+	"textDocument/rename":         (*Server).rename,
+}
+
+// Serve answers messages read from in until the client sends exit.
+func (s *Server) Serve(in io.Reader, out io.Writer) error {
+	s.out = out
+	r := textproto.NewReader(bufio.NewReader(in))
+	for {
+		body, err := readMessage(r)
+		if err == io.EOF {
+			return ErrNoShutdown
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			s.reply(nil, nil, &responseError{Code: codeParseError, Message: err.Error()})
+			continue
+		}
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return ErrNoShutdown
+			}
+			return nil
+		}
+		s.handle(req)
+	}
+}
+
+func (s *Server) handle(req request) {
+	h, ok := handlers[req.Method]
+	switch {
+	case s.shutdown:
+		s.reply(req.ID, nil, &responseError{Code: codeInvalidRequest, Message: "server is shut down"})
+	case !ok:
+		// notifications which are not supported are ignored
+		s.reply(req.ID, nil, &responseError{Code: codeMethodNotFound, Message: "method not supported: " + req.Method})
+	default:
+		result, err := s.call(h, req.Params)
+		if err == nil {
+			s.reply(req.ID, result, nil)
+			return
+		}
+		var rerr *responseError
+		if !errors.As(err, &rerr) {
+			rerr = &responseError{Code: codeRequestFailed, Message: err.Error()}
+		}
+		s.reply(req.ID, nil, rerr)
+	}
+}
+
+// call runs h, a panic fails the request instead of stopping the server.
+func (s *Server) call(h handler, params json.RawMessage) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("internal error: %v", r)
+		}
+	}()
+	return h(s, params)
+}
+
+// reply sends a response to a request, nothing is sent for
+// notifications, which have no id.
+func (s *Server) reply(id *json.RawMessage, result interface{}, err *responseError) {
+	if id == nil {
+		return
+	}
+	writeMessage(s.out, response{JSONRPC: "2.0", ID: id, Result: result, Error: err})
+}
+
+func (s *Server) notify(method string, params interface{}) {
+	writeMessage(s.out, notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (e *responseError) Error() string { return e.Message }
+
+// readMessage reads the body of a message, which is preceded by headers.
+func readMessage(r *textproto.Reader) ([]byte, error) {
+	header, err := r.ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length: %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r.R, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func writeMessage(out io.Writer, msg interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(out, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = out.Write(body)
+	return err
+}
+
+func decode(params json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(params, v); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func ignore(*Server, json.RawMessage) (interface{}, error) {
+	return nil, nil
+}
+
+func (s *Server) document(uri string) (*document, error) {
+	d, ok := s.docs[uri]
+	if !ok {
+		return nil, fmt.Errorf("unknown document %s", uri)
+	}
+	return d, nil
+}
+
+func (s *Server) initialize(json.RawMessage) (interface{}, error) {
+	return map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			// documents are sent whole on each change
+			"textDocumentSync":       1,
+			"definitionProvider":     true,
+			"referencesProvider":     true,
+			"hoverProvider":          true,
+			"completionProvider":     map[string]interface{}{},
+			"documentSymbolProvider": true,
+			"renameProvider":         true,
+		},
+		"serverInfo": map[string]string{"name": "monke"},
+	}, nil
+}
+
+func (s *Server) shutdownRequest(json.RawMessage) (interface{}, error) {
+	s.shutdown = true
+	return nil, nil
+}
+
+func (s *Server) open(uri, text string) {
+	d := newDocument(uri, text)
+	s.docs[uri] = d
+	s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: uri, Diagnostics: d.diagnostics()})
+}
+
+func (s *Server) didOpen(params json.RawMessage) (interface{}, error) {
+	var p DidOpenTextDocumentParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+	s.open(p.TextDocument.URI, p.TextDocument.Text)
+	return nil, nil
+}
+
+func (s *Server) didChange(params json.RawMessage) (interface{}, error) {
+	var p DidChangeTextDocumentParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+	if len(p.ContentChanges) > 0 {
+		s.open(p.TextDocument.URI, p.ContentChanges[len(p.ContentChanges)-1].Text)
+	}
+	return nil, nil
+}
+
+func (s *Server) didClose(params json.RawMessage) (interface{}, error) {
+	var p DidCloseTextDocumentParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+	delete(s.docs, p.TextDocument.URI)
+	s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: p.TextDocument.URI, Diagnostics: []Diagnostic{}})
+	return nil, nil
+}
+
+// positionRequest decodes params of a request at a position into p
+// and returns the document it's about.
+func (s *Server) positionRequest(params json.RawMessage, p interface{}, at *TextDocumentPositionParams) (*document, error) {
+	if err := decode(params, p); err != nil {
+		return nil, err
+	}
+	return s.document(at.TextDocument.URI)
+}
+
+func (s *Server) definition(params json.RawMessage) (interface{}, error) {
+	var p TextDocumentPositionParams
+	d, err := s.positionRequest(params, &p, &p)
+	if err != nil {
+		return nil, err
+	}
+	return d.definition(p.Position), nil
+}
+
+func (s *Server) references(params json.RawMessage) (interface{}, error) {
+	var p ReferenceParams
+	d, err := s.positionRequest(params, &p, &p.TextDocumentPositionParams)
+	if err != nil {
+		return nil, err
+	}
+	return d.referenceLocations(p.Position, p.Context.IncludeDeclaration), nil
+}
+
+func (s *Server) hover(params json.RawMessage) (interface{}, error) {
+	var p TextDocumentPositionParams
+	d, err := s.positionRequest(params, &p, &p)
+	if err != nil {
+		return nil, err
+	}
+	return d.hover(p.Position), nil
+}
+
+func (s *Server) completion(params json.RawMessage) (interface{}, error) {
+	var p TextDocumentPositionParams
+	d, err := s.positionRequest(params, &p, &p)
+	if err != nil {
+		return nil, err
+	}
+	return d.completion(p.Position), nil
+}
+
+func (s *Server) documentSymbol(params json.RawMessage) (interface{}, error) {
+	var p DocumentSymbolParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+	d, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	return d.symbols(), nil
+}
+
+func (s *Server) rename(params json.RawMessage) (interface{}, error) {
+	var p RenameParams
+	d, err := s.positionRequest(params, &p, &p.TextDocumentPositionParams)
+	if err != nil {
+		return nil, err
+	}
+	return d.rename(p.Position, p.NewName)
+}
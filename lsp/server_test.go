@@ -0,0 +1,92 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+func message(t *testing.T, msg interface{}) string {
+	var buf bytes.Buffer
+	if err := writeMessage(&buf, msg); err != nil {
+		t.Fatalf("writeMessage: %v", err)
+	}
+	return buf.String()
+}
+
+func TestServe(t *testing.T) {
+	open := map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": "file:///a.monke", "text": "let x = 1;\nx + y"},
+	}
+	at := map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": "file:///a.monke"},
+		"position":     pos(1, 0),
+	}
+	input := message(t, map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": map[string]interface{}{}}) +
+		message(t, map[string]interface{}{"jsonrpc": "2.0", "method": "textDocument/didOpen", "params": open}) +
+		message(t, map[string]interface{}{"jsonrpc": "2.0", "id": 2, "method": "textDocument/definition", "params": at}) +
+		message(t, map[string]interface{}{"jsonrpc": "2.0", "id": 3, "method": "textDocument/unknown", "params": at}) +
+		message(t, map[string]interface{}{"jsonrpc": "2.0", "id": 4, "method": "shutdown"}) +
+		message(t, map[string]interface{}{"jsonrpc": "2.0", "method": "exit"})
+
+	var out bytes.Buffer
+	if err := NewServer().Serve(strings.NewReader(input), &out); err != nil {
+		t.Fatalf("Serve returned %v", err)
+	}
+
+	var got []string
+	r := textproto.NewReader(bufio.NewReader(&out))
+	for {
+		body, err := readMessage(r)
+		if err != nil {
+			break
+		}
+		var msg struct {
+			ID     *int
+			Method string
+			Result json.RawMessage
+			Error  *responseError
+		}
+		if err := json.Unmarshal(body, &msg); err != nil {
+			t.Fatalf("invalid message %s: %v", body, err)
+		}
+		switch {
+		case msg.Method != "":
+			got = append(got, msg.Method)
+		case msg.Error != nil:
+			got = append(got, "error")
+		case msg.ID != nil && *msg.ID == 2:
+			got = append(got, string(msg.Result))
+		default:
+			got = append(got, "result")
+		}
+	}
+
+	expected := []string{
+		"result",
+		"textDocument/publishDiagnostics",
+		`{"uri":"file:///a.monke","range":{"start":{"line":0,"character":4},"end":{"line":0,"character":5}}}`,
+		"error",
+		"result",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("wrong messages.\nwant=%q\ngot= %q", expected, got)
+	}
+}
+
+func TestServeWithoutShutdown(t *testing.T) {
+	tests := []string{
+		"",
+		"Content-Length: 33\r\n\r\n" + `{"jsonrpc":"2.0","method":"exit"}`,
+	}
+
+	for _, input := range tests {
+		var out bytes.Buffer
+		if err := NewServer().Serve(strings.NewReader(input), &out); err != ErrNoShutdown {
+			t.Errorf("%q: expected ErrNoShutdown, got %v", input, err)
+		}
+	}
+}
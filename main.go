@@ -1,54 +1,142 @@
 package main
 
 import (
-	"flag"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"sort"
 
-	"github.com/wmolicki/go-monkey/evaluator"
+	"github.com/wmolicki/go-monkey/ast"
 	"github.com/wmolicki/go-monkey/lexer"
-	"github.com/wmolicki/go-monkey/object"
+	"github.com/wmolicki/go-monkey/optimizer"
 	"github.com/wmolicki/go-monkey/parser"
-	"github.com/wmolicki/go-monkey/repl"
 )
 
+// Exit codes of all commands.
+const (
+	exitOK = 0
+	// exitError means the program or its input has errors, e.g. it
+	// doesn't parse or its tests fail.
+	exitError = 1
+	// exitUsage means monke was called with wrong arguments.
+	exitUsage = 2
+)
+
+type command struct {
+	run     func(args []string) int
+	summary string
+}
+
+// commands are subcommands of monke, e.g. `monke fmt file.monke`,
+// each returns the exit code.
+var commands = map[string]command{
+	"run":     {runRun, "run a program"},
+	"repl":    {runRepl, "start an interactive session"},
+	"fmt":     {runFmt, "format source files"},
+	"check":   {runCheck, "report syntax errors and undefined names"},
+	"tokens":  {runTokens, "print tokens of a program"},
+	"ast":     {runAst, "print the syntax tree of a program"},
+	"test":    {runTest, "run tests in *_test.monke files"},
+	"version": {runVersion, "print the version of monke"},
+	"lsp":     {runLsp, "run a language server on standard input and output"},
+}
+
 func main() {
-	flag.Parse()
-	files := flag.Args()
-
-	switch len(files) {
-	case 0:
-		fmt.Println("Monke REPL!")
-		repl.Start(os.Stdin, os.Stdout)
-	case 1:
-		filename := files[0]
-		script, err := os.ReadFile(filename)
-		if err != nil {
-			fmt.Printf("error reading script: %v\n", err)
-			os.Exit(1)
-		}
+	os.Exit(monke(os.Args[1:]))
+}
 
-		l := lexer.New(string(script))
-		p := parser.New(l)
+// monke runs the command given by args and returns the exit code.
+// Without a command, it runs the REPL or the program given by args.
+func monke(args []string) int {
+	if len(args) == 0 {
+		return runRepl(nil)
+	}
+	if cmd, ok := commands[args[0]]; ok {
+		return cmd.run(args[1:])
+	}
 
-		env := object.NewEnvironment()
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		usage(os.Stdout)
+		return exitOK
+	}
+	// `monke file.monke` and `monke -e expr` are short for `monke run ...`
+	return runRun(args)
+}
 
-		program := p.ParseProgram()
-		if len(p.Errors()) != 0 {
-			printParserErrors(os.Stdout, p.Errors())
-			os.Exit(2)
-		}
+func usage(out io.Writer) {
+	fmt.Fprintf(out, "usage: monke <command> [arguments]\n")
+	fmt.Fprintf(out, "       monke [run flags] (-e expr | files... | -) [args...]\n\n")
+	fmt.Fprintf(out, "commands:\n")
 
-		evaluated := evaluator.Eval(program, env)
-		if evaluated != nil {
-			io.WriteString(os.Stdout, evaluated.Inspect())
-			io.WriteString(os.Stdout, "\n")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "  %-8s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintf(out, "\nsee `monke <command> --help` for arguments of a command\n")
+}
+
+// errUsage is returned when a command got wrong arguments.
+var errUsage = errors.New("wrong arguments")
+
+// programSource returns the program given with -e as expr, or read
+// from the file which is the only one in files, - is standard input.
+func programSource(expr string, files []string) (name, src string, err error) {
+	if expr != "" {
+		if len(files) != 0 {
+			return "", "", errUsage
 		}
-	default:
-		fmt.Printf("unsupported amount of arguments: %d\n", len(files))
-		os.Exit(1)
+		return "-e", expr, nil
+	}
+
+	if len(files) != 1 {
+		return "", "", errUsage
+	}
+	script, err := readScript(files[0])
+	if err != nil {
+		return "", "", err
+	}
+	return files[0], script, nil
+}
+
+// readScript returns the content of filename, or of standard input
+// if filename is -.
+func readScript(filename string) (string, error) {
+	var script []byte
+	var err error
+	if filename == "-" {
+		script, err = io.ReadAll(os.Stdin)
+	} else {
+		script, err = os.ReadFile(filename)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading script: %v", err)
+	}
+	return string(script), nil
+}
+
+// parse parses src, parser errors are written to stderr.
+func parse(src string) (*ast.Program, bool) {
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		printParserErrors(os.Stderr, p.Errors())
+		return nil, false
+	}
+	return program, true
+}
+
+// optimizerPasses returns passes named in names, or all of them if names is empty.
+func optimizerPasses(names string) ([]optimizer.Pass, error) {
+	if names == "" {
+		return optimizer.DefaultPasses, nil
 	}
+	return optimizer.ParsePasses(names)
 }
 
 func printParserErrors(out io.Writer, errors []string) {
@@ -0,0 +1,133 @@
+package object
+
+import (
+	"reflect"
+	"sync"
+)
+
+const CHANNEL_OBJ = "CHANNEL"
+
+// Channel passes values between functions started with spawn. Closing
+// it doesn't close the underlying Go channel, so a send racing with
+// close fails instead of panicking.
+type Channel struct {
+	values    chan Object
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// NewChannel returns a channel buffering up to capacity values.
+func NewChannel(capacity int) *Channel {
+	return &Channel{values: make(chan Object, capacity), closed: make(chan struct{})}
+}
+
+// Send blocks until value is received or buffered, it reports false
+// if the channel is closed.
+func (c *Channel) Send(value Object) bool {
+	select {
+	case <-c.closed:
+		return false
+	default:
+	}
+
+	select {
+	case c.values <- value:
+		return true
+	case <-c.closed:
+		return false
+	}
+}
+
+// Receive blocks until there is a value, ok is false if the channel
+// is closed and all values sent before closing were received.
+func (c *Channel) Receive() (value Object, ok bool) {
+	select {
+	case value := <-c.values:
+		return value, true
+	case <-c.closed:
+		return c.drain()
+	}
+}
+
+func (c *Channel) drain() (Object, bool) {
+	select {
+	case value := <-c.values:
+		return value, true
+	default:
+		return nil, false
+	}
+}
+
+// Close closes the channel, it reports false if it was already closed.
+func (c *Channel) Close() bool {
+	closed := false
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		closed = true
+	})
+	return closed
+}
+
+func (c *Channel) Type() ObjectType { return CHANNEL_OBJ }
+func (c *Channel) Inspect() string  { return "channel" }
+
+var _ Object = &Channel{}
+
+// SelectCase receives from Channel, or sends Send to it if Send is not nil.
+type SelectCase struct {
+	Channel *Channel
+	Send    Object
+}
+
+// Select blocks until one of cases can proceed and returns its index,
+// or -1 if hasDefault is true and none of them can proceed right away.
+// For receives, value is the received value. ok is false if the channel
+// of the chosen case is closed, as in Receive and Send.
+func Select(cases []SelectCase, hasDefault bool) (chosen int, value Object, ok bool) {
+	// each case is selected together with closing of its channel
+	selectCases := make([]reflect.SelectCase, 0, 2*len(cases)+1)
+	for i, c := range cases {
+		if c.Send != nil {
+			select {
+			case <-c.Channel.closed:
+				return i, nil, false
+			default:
+			}
+			selectCases = append(selectCases, reflect.SelectCase{
+				Dir:  reflect.SelectSend,
+				Chan: reflect.ValueOf(c.Channel.values),
+				Send: reflect.ValueOf(c.Send),
+			})
+		} else {
+			selectCases = append(selectCases, reflect.SelectCase{
+				Dir:  reflect.SelectRecv,
+				Chan: reflect.ValueOf(c.Channel.values),
+			})
+		}
+		selectCases = append(selectCases, reflect.SelectCase{
+			Dir:  reflect.SelectRecv,
+			Chan: reflect.ValueOf(c.Channel.closed),
+		})
+	}
+	if hasDefault {
+		selectCases = append(selectCases, reflect.SelectCase{Dir: reflect.SelectDefault})
+	}
+
+	i, received, _ := reflect.Select(selectCases)
+	if i == 2*len(cases) {
+		return -1, nil, false
+	}
+
+	c := cases[i/2]
+	switch {
+	case i%2 == 0 && c.Send != nil:
+		return i / 2, nil, true
+	case i%2 == 0:
+		return i / 2, received.Interface().(Object), true
+	case c.Send != nil:
+		return i / 2, nil, false
+	default:
+		value, ok := c.Channel.drain()
+		return i / 2, value, ok
+	}
+}
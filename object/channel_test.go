@@ -0,0 +1,60 @@
+package object
+
+import "testing"
+
+func TestChannel(t *testing.T) {
+	c := NewChannel(2)
+	if !c.Send(integer(1)) || !c.Send(integer(2)) {
+		t.Fatalf("send to buffered channel failed")
+	}
+	if !c.Close() {
+		t.Fatalf("first Close returned false")
+	}
+	if c.Close() {
+		t.Errorf("second Close returned true")
+	}
+	if c.Send(integer(3)) {
+		t.Errorf("send to closed channel succeeded")
+	}
+
+	// values sent before closing are still received
+	for _, want := range []int64{1, 2} {
+		value, ok := c.Receive()
+		if !ok || !Equal(value, integer(want)) {
+			t.Errorf("wrong value received. want %d, got=%v (ok=%t)", want, value, ok)
+		}
+	}
+	if value, ok := c.Receive(); ok {
+		t.Errorf("receive from drained channel returned %v", value)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	empty, full, closed := NewChannel(1), NewChannel(1), NewChannel(1)
+	full.Send(integer(1))
+	closed.Close()
+
+	tests := []struct {
+		cases      []SelectCase
+		hasDefault bool
+		chosen     int
+		value      Object
+		ok         bool
+	}{
+		{[]SelectCase{{Channel: empty}}, true, -1, nil, false},
+		{[]SelectCase{{Channel: empty}, {Channel: empty, Send: integer(2)}}, false, 1, nil, true},
+		{[]SelectCase{{Channel: full, Send: integer(2)}, {Channel: full}}, false, 1, integer(1), true},
+		{[]SelectCase{{Channel: closed}}, false, 0, nil, false},
+		{[]SelectCase{{Channel: closed, Send: integer(1)}}, true, 0, nil, false},
+	}
+
+	for i, tt := range tests {
+		chosen, value, ok := Select(tt.cases, tt.hasDefault)
+		if chosen != tt.chosen || ok != tt.ok {
+			t.Errorf("%d: wrong case chosen. want %d (ok=%t), got=%d (ok=%t)", i, tt.chosen, tt.ok, chosen, ok)
+		}
+		if (value == nil) != (tt.value == nil) || (value != nil && !Equal(value, tt.value)) {
+			t.Errorf("%d: wrong value. want %v, got=%v", i, tt.value, value)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package object
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Equal reports whether a and b are structurally equal. Arrays, hashes
+// and struct instances are equal if their elements are, objects of
+// different types are never equal and functions are only equal to
+// themselves. Cyclic values are handled, a pair of objects which is
+// already being compared is assumed to be equal.
+func Equal(a, b Object) bool {
+	return equal(a, b, map[[2]Object]bool{})
+}
+
+func equal(a, b Object, visiting map[[2]Object]bool) bool {
+	if a == b {
+		return true
+	}
+	if a == nil || b == nil || a.Type() != b.Type() {
+		return false
+	}
+
+	switch a := a.(type) {
+	case *Integer:
+		return a.Value == b.(*Integer).Value
+	case *Boolean:
+		return a.Value == b.(*Boolean).Value
+	case *String:
+		return a.Value == b.(*String).Value
+	case *Null:
+		return true
+	case *Array:
+		b := b.(*Array)
+		if a.Len() != b.Len() {
+			return false
+		}
+		if !enter(a, b, visiting) {
+			return true
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !equal(a.At(i), b.At(i), visiting) {
+				return false
+			}
+		}
+		return true
+	case *Hash:
+		b := b.(*Hash)
+		if a.Len() != b.Len() {
+			return false
+		}
+		if !enter(a, b, visiting) {
+			return true
+		}
+		for _, pair := range a.Pairs() {
+			other, ok := b.Get(pair.Key)
+			if !ok || !equal(pair.Value, other, visiting) {
+				return false
+			}
+		}
+		return true
+	case *StructInstance:
+		b := b.(*StructInstance)
+		if a.StructType != b.StructType {
+			return false
+		}
+		if !enter(a, b, visiting) {
+			return true
+		}
+		for _, name := range a.StructType.Fields {
+			av, _ := a.Get(name)
+			bv, _ := b.Get(name)
+			if !equal(av, bv, visiting) {
+				return false
+			}
+		}
+		return true
+	case *Set:
+		b := b.(*Set)
+		return a.Len() == b.Len() && a.IsSubset(b)
+	case *ErrorValue:
+		b := b.(*ErrorValue)
+		return a.Err.ErrorKind() == b.Err.ErrorKind() && a.Err.Message == b.Err.Message
+	default:
+		return false
+	}
+}
+
+// enter marks a and b as being compared, it returns false if they already are.
+func enter(a, b Object, visiting map[[2]Object]bool) bool {
+	pair := [2]Object{a, b}
+	if visiting[pair] {
+		return false
+	}
+	visiting[pair] = true
+	return true
+}
+
+// Diff describes how got differs from want, one difference per line.
+// Arrays, hashes and struct instances are compared element by element,
+// each difference starts with the path of the element, e.g. [1]["a"].
+// It returns nil if got and want are Equal.
+func Diff(got, want Object) []string {
+	d := &differ{visiting: map[[2]Object]bool{}}
+	d.diff(got, want, "")
+	return d.lines
+}
+
+type differ struct {
+	lines    []string
+	visiting map[[2]Object]bool
+}
+
+func (d *differ) add(path, format string, a ...interface{}) {
+	line := fmt.Sprintf(format, a...)
+	if path != "" {
+		line = path + ": " + line
+	}
+	d.lines = append(d.lines, line)
+}
+
+func (d *differ) diff(got, want Object, path string) {
+	if Equal(got, want) {
+		return
+	}
+
+	switch got := got.(type) {
+	case *Array:
+		if want, ok := want.(*Array); ok {
+			if enter(got, want, d.visiting) {
+				d.diffArrays(got, want, path)
+			}
+			return
+		}
+	case *Hash:
+		if want, ok := want.(*Hash); ok {
+			if enter(got, want, d.visiting) {
+				d.diffHashes(got, want, path)
+			}
+			return
+		}
+	case *StructInstance:
+		if want, ok := want.(*StructInstance); ok && got.StructType == want.StructType {
+			if enter(got, want, d.visiting) {
+				for _, name := range got.StructType.Fields {
+					gv, _ := got.Get(name)
+					wv, _ := want.Get(name)
+					d.diff(gv, wv, path+"."+name)
+				}
+			}
+			return
+		}
+	}
+	d.add(path, "got %s, want %s", describe(got), describe(want))
+}
+
+func (d *differ) diffArrays(got, want *Array, path string) {
+	for i := 0; i < got.Len() || i < want.Len(); i++ {
+		elementPath := path + "[" + strconv.Itoa(i) + "]"
+		switch {
+		case i >= want.Len():
+			d.add(elementPath, "unexpected %s", describe(got.At(i)))
+		case i >= got.Len():
+			d.add(elementPath, "missing %s", describe(want.At(i)))
+		default:
+			d.diff(got.At(i), want.At(i), elementPath)
+		}
+	}
+}
+
+func (d *differ) diffHashes(got, want *Hash, path string) {
+	for _, pair := range got.Pairs() {
+		keyPath := path + "[" + describe(pair.Key) + "]"
+		if other, ok := want.Get(pair.Key); ok {
+			d.diff(pair.Value, other, keyPath)
+		} else {
+			d.add(keyPath, "unexpected %s", describe(pair.Value))
+		}
+	}
+	for _, pair := range want.Pairs() {
+		if _, ok := got.Get(pair.Key); !ok {
+			d.add(path+"["+describe(pair.Key)+"]", "missing %s", describe(pair.Value))
+		}
+	}
+}
+
+// describe returns obj as it's written in a diff, strings are quoted
+// so they can be told apart from other values.
+func describe(obj Object) string {
+	if s, ok := obj.(*String); ok {
+		return strconv.Quote(s.Value)
+	}
+	if obj == nil {
+		return "nil"
+	}
+	return obj.Inspect()
+}
+
+// Compare orders a and b, returning -1, 0 or 1 if a is less than, equal to
+// or greater than b. Integers are ordered by value, booleans false first,
+// strings and arrays lexicographically. ok is false if a and b can't be
+// ordered, e.g. they are of different types.
+func Compare(a, b Object) (result int, ok bool) {
+	if a.Type() != b.Type() {
+		return 0, false
+	}
+
+	switch a := a.(type) {
+	case *Integer:
+		return compareInts(a.Value, b.(*Integer).Value), true
+	case *Boolean:
+		return compareInts(boolToInt(a.Value), boolToInt(b.(*Boolean).Value)), true
+	case *String:
+		return strings.Compare(a.Value, b.(*String).Value), true
+	case *Array:
+		// arrays can't contain themselves without going through
+		// a struct, which is not ordered, so this always terminates
+		b := b.(*Array)
+		for i := 0; i < a.Len() && i < b.Len(); i++ {
+			result, ok := Compare(a.At(i), b.At(i))
+			if !ok || result != 0 {
+				return result, ok
+			}
+		}
+		return compareInts(int64(a.Len()), int64(b.Len())), true
+	default:
+		return 0, false
+	}
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func boolToInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,157 @@
+package object
+
+import (
+	"strings"
+	"testing"
+)
+
+func arr(elements ...Object) *Array { return NewArray(elements) }
+func integer(v int64) *Integer      { return &Integer{Value: v} }
+func str(v string) *String          { return &String{Value: v} }
+
+func hash(pairs ...Object) *Hash {
+	h := NewHash()
+	for i := 0; i < len(pairs); i += 2 {
+		h.Set(pairs[i], pairs[i+1])
+	}
+	return h
+}
+
+func TestEqual(t *testing.T) {
+	point := &StructType{Name: "Point", Fields: []string{"x", "y"}}
+	other := &StructType{Name: "Other", Fields: []string{"x", "y"}}
+	fn := &Function{}
+
+	tests := []struct {
+		a, b     Object
+		expected bool
+	}{
+		{integer(1), integer(1), true},
+		{integer(1), integer(2), false},
+		{integer(1), str("1"), false},
+		{str("a"), str("a"), true},
+		{&Boolean{Value: true}, &Boolean{Value: true}, true},
+		{&Null{}, &Null{}, true},
+		{arr(integer(1), integer(2)), arr(integer(1), integer(2)), true},
+		{arr(integer(1), integer(2)), arr(integer(1)), false},
+		{arr(arr(str("a"))), arr(arr(str("a"))), true},
+		{arr(arr(str("a"))), arr(arr(str("b"))), false},
+		{hash(str("a"), integer(1)), hash(str("a"), integer(1)), true},
+		{hash(str("a"), integer(1)), hash(str("a"), integer(2)), false},
+		{hash(str("a"), integer(1)), hash(str("b"), integer(1)), false},
+		{hash(str("a"), arr(integer(1))), hash(str("a"), arr(integer(1))), true},
+		{NewStructInstance(point, []Object{integer(1), integer(2)}), NewStructInstance(point, []Object{integer(1), integer(2)}), true},
+		{NewStructInstance(point, []Object{integer(1), integer(2)}), NewStructInstance(point, []Object{integer(1), integer(3)}), false},
+		{NewStructInstance(point, []Object{integer(1), integer(2)}), NewStructInstance(other, []Object{integer(1), integer(2)}), false},
+		{fn, fn, true},
+		{fn, &Function{}, false},
+	}
+
+	for i, tt := range tests {
+		if got := Equal(tt.a, tt.b); got != tt.expected {
+			t.Errorf("tests[%d] Equal(%s, %s) wrong. expected=%t, got=%t", i, tt.a.Inspect(), tt.b.Inspect(), tt.expected, got)
+		}
+	}
+}
+
+func TestEqualCycles(t *testing.T) {
+	node := &StructType{Name: "Node", Fields: []string{"value", "next"}}
+
+	// a -> a and b -> b
+	a := NewStructInstance(node, []Object{integer(1), &Null{}})
+	a.Set("next", a)
+	b := NewStructInstance(node, []Object{integer(1), &Null{}})
+	b.Set("next", b)
+
+	if !Equal(a, b) {
+		t.Errorf("equal cyclic structs are not equal")
+	}
+
+	c := NewStructInstance(node, []Object{integer(2), &Null{}})
+	c.Set("next", c)
+
+	if Equal(a, c) {
+		t.Errorf("different cyclic structs are equal")
+	}
+
+	// cycles through arrays: a -> [a]
+	d := NewStructInstance(node, []Object{integer(1), &Null{}})
+	d.Set("next", arr(d))
+	e := NewStructInstance(node, []Object{integer(1), &Null{}})
+	e.Set("next", arr(e))
+
+	if !Equal(d, e) {
+		t.Errorf("equal cyclic structs through arrays are not equal")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	point := &StructType{Name: "Point", Fields: []string{"x", "y"}}
+
+	tests := []struct {
+		got, want Object
+		expected  []string
+	}{
+		{integer(1), integer(1), nil},
+		{integer(1), integer(2), []string{"got 1, want 2"}},
+		{integer(1), str("1"), []string{`got 1, want "1"`}},
+		{arr(integer(1), integer(2)), arr(integer(1), integer(3)), []string{"[1]: got 2, want 3"}},
+		{arr(integer(1)), arr(integer(1), str("b")), []string{`[1]: missing "b"`}},
+		{arr(integer(1), integer(2)), arr(), []string{"[0]: unexpected 1", "[1]: unexpected 2"}},
+		{arr(arr(integer(1))), arr(arr(integer(2))), []string{"[0][0]: got 1, want 2"}},
+		{arr(integer(1)), hash(), []string{"got [1], want {}"}},
+		{
+			hash(str("a"), integer(1), str("b"), integer(2)),
+			hash(str("a"), integer(2), str("c"), integer(3)),
+			[]string{`["a"]: got 1, want 2`, `["b"]: unexpected 2`, `["c"]: missing 3`},
+		},
+		{hash(integer(1), arr(str("x"))), hash(integer(1), arr(str("y"))), []string{`[1][0]: got "x", want "y"`}},
+		{
+			NewStructInstance(point, []Object{integer(1), arr()}),
+			NewStructInstance(point, []Object{integer(1), arr(integer(2))}),
+			[]string{".y[0]: missing 2"},
+		},
+	}
+
+	for i, tt := range tests {
+		got := Diff(tt.got, tt.want)
+		if strings.Join(got, "\n") != strings.Join(tt.expected, "\n") {
+			t.Errorf("tests[%d] Diff(%s, %s) wrong.\nexpected=%q\ngot=     %q", i, tt.got.Inspect(), tt.want.Inspect(), tt.expected, got)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b     Object
+		expected int
+		ok       bool
+	}{
+		{integer(1), integer(2), -1, true},
+		{integer(2), integer(2), 0, true},
+		{integer(3), integer(2), 1, true},
+		{str("a"), str("b"), -1, true},
+		{str("b"), str("ab"), 1, true},
+		{str("ab"), str("ab"), 0, true},
+		{&Boolean{Value: false}, &Boolean{Value: true}, -1, true},
+		{arr(integer(1), integer(2)), arr(integer(1), integer(3)), -1, true},
+		{arr(integer(1), integer(2)), arr(integer(1)), 1, true},
+		{arr(), arr(), 0, true},
+		{arr(str("a")), arr(str("a"), integer(1)), -1, true},
+		{integer(1), str("1"), 0, false},
+		{arr(integer(1)), arr(str("1")), 0, false},
+		{hash(), hash(), 0, false},
+		{&Null{}, &Null{}, 0, false},
+	}
+
+	for i, tt := range tests {
+		got, ok := Compare(tt.a, tt.b)
+		if ok != tt.ok {
+			t.Errorf("tests[%d] Compare(%s, %s) ok wrong. expected=%t, got=%t", i, tt.a.Inspect(), tt.b.Inspect(), tt.ok, ok)
+			continue
+		}
+		if ok && got != tt.expected {
+			t.Errorf("tests[%d] Compare(%s, %s) wrong. expected=%d, got=%d", i, tt.a.Inspect(), tt.b.Inspect(), tt.expected, got)
+		}
+	}
+}
@@ -1,17 +1,28 @@
 package object
 
+import (
+	"sort"
+	"sync"
+)
+
 func NewEnvironment() *Environment {
 	s := make(map[string]Object)
 	return &Environment{store: s, outer: nil}
 }
 
+// Environment binds names to values. It's safe for concurrent use, as
+// functions started with spawn share environments of their closures.
 type Environment struct {
+	mu    sync.RWMutex
 	store map[string]Object
 	outer *Environment
 }
 
 func (e *Environment) Get(name string) (Object, bool) {
+	e.mu.RLock()
 	obj, ok := e.store[name]
+	e.mu.RUnlock()
+
 	if !ok && e.outer != nil {
 		obj, ok = e.outer.Get(name)
 	}
@@ -19,10 +30,26 @@ func (e *Environment) Get(name string) (Object, bool) {
 }
 
 func (e *Environment) Set(name string, val Object) Object {
+	e.mu.Lock()
 	e.store[name] = val
+	e.mu.Unlock()
 	return val
 }
 
+// Names returns the names bound in e, without names of its outer
+// environments, in sorted order.
+func (e *Environment) Names() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	names := make([]string, 0, len(e.store))
+	for name := range e.store {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func NewEnclosedEnvironment(outer *Environment) *Environment {
 	env := NewEnvironment()
 	env.outer = outer
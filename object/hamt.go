@@ -0,0 +1,156 @@
+package object
+
+import (
+	"hash/fnv"
+	"math/bits"
+)
+
+// hamtNode is a node of a persistent hash array mapped trie, which maps
+// keys to indexes. Each level consumes 5 bits of the hash, nodes store
+// children only for bits which are in use. Updates copy the path to the changed leaf and
+// share the rest of the trie. A nil *hamtNode is an empty trie.
+type hamtNode struct {
+	bitmap uint32
+	// children are *hamtNode or *hamtLeaf
+	children []interface{}
+}
+
+const (
+	hamtBits = 5
+	hamtMask = 1<<hamtBits - 1
+)
+
+// hamtLeaf holds entries whose keys have the same hash, keys are
+// told apart by Equal.
+type hamtLeaf struct {
+	hash    uint64
+	entries []hamtEntry
+}
+
+type hamtEntry struct {
+	key   Object
+	index int
+}
+
+// hamtHash mixes the type into the hash key, so keys of different types
+// with the same value don't end up in the same leaf.
+func hamtHash(key HashKey) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key.Type))
+	return key.Value ^ h.Sum64()
+}
+
+func (n *hamtNode) position(bit uint32) int {
+	return bits.OnesCount32(n.bitmap & (bit - 1))
+}
+
+func hamtBit(hash uint64, shift uint) uint32 {
+	return 1 << ((hash >> shift) & hamtMask)
+}
+
+func (n *hamtNode) find(hash uint64, key Object, shift uint) (int, bool) {
+	for n != nil {
+		bit := hamtBit(hash, shift)
+		if n.bitmap&bit == 0 {
+			return 0, false
+		}
+
+		switch child := n.children[n.position(bit)].(type) {
+		case *hamtNode:
+			n, shift = child, shift+hamtBits
+		case *hamtLeaf:
+			if child.hash != hash {
+				return 0, false
+			}
+			for _, entry := range child.entries {
+				if Equal(entry.key, key) {
+					return entry.index, true
+				}
+			}
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// insert returns the trie with entry added, its key must not be in n.
+func (n *hamtNode) insert(hash uint64, entry hamtEntry, shift uint) *hamtNode {
+	if n == nil {
+		n = &hamtNode{}
+	}
+
+	bit := hamtBit(hash, shift)
+	i := n.position(bit)
+
+	if n.bitmap&bit == 0 {
+		children := make([]interface{}, len(n.children)+1)
+		copy(children, n.children[:i])
+		children[i] = &hamtLeaf{hash: hash, entries: []hamtEntry{entry}}
+		copy(children[i+1:], n.children[i:])
+		return &hamtNode{bitmap: n.bitmap | bit, children: children}
+	}
+
+	var replacement interface{}
+	switch child := n.children[i].(type) {
+	case *hamtNode:
+		replacement = child.insert(hash, entry, shift+hamtBits)
+	case *hamtLeaf:
+		if child.hash == hash {
+			entries := make([]hamtEntry, len(child.entries), len(child.entries)+1)
+			copy(entries, child.entries)
+			replacement = &hamtLeaf{hash: hash, entries: append(entries, entry)}
+		} else {
+			// split the leaf into a node on the next level
+			node := newHamtNode(child, shift+hamtBits)
+			replacement = node.insert(hash, entry, shift+hamtBits)
+		}
+	}
+
+	children := make([]interface{}, len(n.children))
+	copy(children, n.children)
+	children[i] = replacement
+	return &hamtNode{bitmap: n.bitmap, children: children}
+}
+
+func newHamtNode(leaf *hamtLeaf, shift uint) *hamtNode {
+	return &hamtNode{bitmap: hamtBit(leaf.hash, shift), children: []interface{}{leaf}}
+}
+
+// remove returns the trie without key, which must be in n.
+func (n *hamtNode) remove(hash uint64, key Object, shift uint) *hamtNode {
+	bit := hamtBit(hash, shift)
+	i := n.position(bit)
+
+	var replacement interface{}
+	switch child := n.children[i].(type) {
+	case *hamtNode:
+		if node := child.remove(hash, key, shift+hamtBits); node != nil {
+			replacement = node
+		}
+	case *hamtLeaf:
+		entries := []hamtEntry{}
+		for _, entry := range child.entries {
+			if !Equal(entry.key, key) {
+				entries = append(entries, entry)
+			}
+		}
+		if len(entries) > 0 {
+			replacement = &hamtLeaf{hash: hash, entries: entries}
+		}
+	}
+
+	if replacement != nil {
+		children := make([]interface{}, len(n.children))
+		copy(children, n.children)
+		children[i] = replacement
+		return &hamtNode{bitmap: n.bitmap, children: children}
+	}
+
+	if len(n.children) == 1 {
+		return nil
+	}
+	children := make([]interface{}, 0, len(n.children)-1)
+	children = append(children, n.children[:i]...)
+	children = append(children, n.children[i+1:]...)
+	return &hamtNode{bitmap: n.bitmap &^ bit, children: children}
+}
@@ -0,0 +1,42 @@
+package object
+
+import "sync"
+
+const ITERATOR_OBJ = "ITERATOR"
+
+// Iterator produces values on demand, so it can represent infinite
+// sequences. Iterators are consumed as they are read, reads from
+// multiple goroutines are serialized.
+type Iterator struct {
+	mu    sync.Mutex
+	next  func() (Object, bool)
+	close func()
+}
+
+// NewIterator returns an iterator reading values from next, close is
+// called when the iterator won't be read anymore and may be nil.
+func NewIterator(next func() (Object, bool), close func()) *Iterator {
+	return &Iterator{next: next, close: close}
+}
+
+// Next returns the next value, ok is false if there are no more values.
+// An error producing the value is returned as an *Error.
+func (it *Iterator) Next() (value Object, ok bool) {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	return it.next()
+}
+
+// Close releases resources held by it, e.g. a goroutine producing
+// its values. It's safe to call Close more than once.
+func (it *Iterator) Close() {
+	if it.close != nil {
+		it.close()
+	}
+}
+
+func (it *Iterator) Type() ObjectType { return ITERATOR_OBJ }
+func (it *Iterator) Inspect() string  { return "iterator" }
+
+var _ Object = &Iterator{}
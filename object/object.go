@@ -2,6 +2,7 @@ package object
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"hash/fnv"
 	"strings"
@@ -17,11 +18,14 @@ const (
 	NULL_OBJ         = "NULL"
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
 	ERROR_OBJ        = "ERROR"
+	ERROR_VALUE_OBJ  = "ERROR_VALUE"
 	FUNCTION_OBJ     = "FUNCTION"
 	STRING_OBJ       = "STRING"
 	BUILTIN_OBJ      = "BUILTIN"
 	ARRAY_OBJ        = "ARRAY"
 	HASH_OBJ         = "HASH"
+	QUOTE_OBJ        = "QUOTE"
+	MACRO_OBJ        = "MACRO"
 )
 
 type Object interface {
@@ -63,15 +67,72 @@ func (rv *ReturnValue) Inspect() string  { return rv.Value.Inspect() }
 
 var _ Object = &ReturnValue{}
 
+// Kinds of errors raised by the interpreter, scripts can throw
+// errors of any kind.
+const (
+	GenericError  = "Error"
+	TypeError     = "TypeError"
+	NameError     = "NameError"
+	ArgumentError = "ArgumentError"
+	// ChannelError is raised by operations on closed channels and
+	// by selects which would block forever.
+	ChannelError = "ChannelError"
+	// SyntaxError is raised by code the parser rejects, which can
+	// only be evaluated if its tree was built in another way.
+	SyntaxError = "SyntaxError"
+	// IOError is raised when reading input fails.
+	IOError = "IOError"
+	// ZeroDivisionError is raised by integer division by zero.
+	ZeroDivisionError = "ZeroDivisionError"
+	// MatchError is raised when no arm of a match expression matches.
+	MatchError = "MatchError"
+	// AssertionError is raised by failed assertions in tests.
+	AssertionError = "AssertionError"
+)
+
+// Error unwinds evaluation until it's caught by a try expression
+// or reaches the top of the program.
 type Error struct {
 	Message string
+	// Kind is GenericError if empty.
+	Kind string
+	// Value is the value passed to throw, nil for errors
+	// raised by the interpreter.
+	Value Object
+	// Stack lists calls the error unwound through, innermost first.
+	Stack []string
+}
+
+// NewError returns an error of kind, which scripts can catch and inspect.
+func NewError(kind string, format string, a ...interface{}) *Error {
+	return &Error{Message: fmt.Sprintf(format, a...), Kind: kind}
 }
 
 func (e *Error) Type() ObjectType { return ERROR_OBJ }
 func (e *Error) Inspect() string  { return "ERROR: " + e.Message }
 
+func (e *Error) ErrorKind() string {
+	if e.Kind == "" {
+		return GenericError
+	}
+	return e.Kind
+}
+
 var _ Object = &Error{}
 
+// ErrorValue is an error which doesn't unwind, it's what catch binds
+// and what the error builtin returns. Throwing it raises Err again.
+type ErrorValue struct {
+	Err *Error
+}
+
+func (ev *ErrorValue) Type() ObjectType { return ERROR_VALUE_OBJ }
+func (ev *ErrorValue) Inspect() string {
+	return ev.Err.ErrorKind() + ": " + ev.Err.Message
+}
+
+var _ Object = &ErrorValue{}
+
 type Function struct {
 	Parameters []*ast.Identifier
 	Body       *ast.BlockStatement
@@ -113,17 +174,60 @@ type Builtin struct {
 func (b *Builtin) Type() ObjectType { return BUILTIN_OBJ }
 func (b *Builtin) Inspect() string  { return "builtin function" }
 
+// Array is an immutable list, operations which change it return a new
+// array sharing most of its structure with the original.
 type Array struct {
-	Elements []Object
+	elements vector
+}
+
+func NewArray(elements []Object) *Array {
+	values := make([]interface{}, len(elements))
+	for i, el := range elements {
+		values[i] = el
+	}
+	return &Array{elements: newVector(values)}
+}
+
+// Len returns the number of elements of ao.
+func (ao *Array) Len() int {
+	return ao.elements.len()
+}
+
+// At returns the element at index i, which must be in range.
+func (ao *Array) At(i int) Object {
+	return ao.elements.at(i).(Object)
+}
+
+// Elements returns a copy of elements of ao.
+func (ao *Array) Elements() []Object {
+	elements := make([]Object, 0, ao.Len())
+	ao.elements.each(func(value interface{}) {
+		elements = append(elements, value.(Object))
+	})
+	return elements
+}
+
+// Push returns ao with obj appended.
+func (ao *Array) Push(obj Object) *Array {
+	return &Array{elements: ao.elements.push(obj)}
+}
+
+// Rest returns ao without its first element, ao must not be empty.
+func (ao *Array) Rest() *Array {
+	return &Array{elements: ao.elements.rest()}
 }
 
 func (ao *Array) Type() ObjectType { return ARRAY_OBJ }
 func (ao *Array) Inspect() string {
+	return ao.inspect(map[*StructInstance]bool{})
+}
+
+func (ao *Array) inspect(visiting map[*StructInstance]bool) string {
 	var out bytes.Buffer
 	elements := []string{}
 
-	for _, e := range ao.Elements {
-		elements = append(elements, e.Inspect())
+	for _, e := range ao.Elements() {
+		elements = append(elements, inspect(e, visiting))
 	}
 
 	out.WriteString("[")
@@ -160,22 +264,170 @@ func (s *String) HashKey() HashKey {
 	return HashKey{Type: s.Type(), Value: h.Sum64()}
 }
 
+// HashKey of an array combines hash keys of its elements, it's only
+// meaningful if all of them are hashable, see HashKeyOf.
+func (ao *Array) HashKey() HashKey {
+	key, _ := ao.hashKey()
+	return key
+}
+
+// hashKey returns the hash key of ao, ok is false if one of its
+// elements is not hashable. Each element is visited once, so hashing
+// nested arrays takes time linear in their size.
+func (ao *Array) hashKey() (key HashKey, ok bool) {
+	h := fnv.New64a()
+	buf := make([]byte, 8)
+	for i := 0; i < ao.Len(); i++ {
+		key, ok := HashKeyOf(ao.At(i))
+		if !ok {
+			return HashKey{}, false
+		}
+		h.Write([]byte(key.Type))
+		binary.LittleEndian.PutUint64(buf, key.Value)
+		h.Write(buf)
+	}
+	return HashKey{Type: ao.Type(), Value: h.Sum64()}, true
+}
+
+// HashKeyOf returns the hash key of obj, ok is false if obj can't be
+// used as a key, e.g. it's an array containing a function.
+func HashKeyOf(obj Object) (key HashKey, ok bool) {
+	switch obj := obj.(type) {
+	case *Array:
+		return obj.hashKey()
+	case Hashable:
+		return obj.HashKey(), true
+	default:
+		return HashKey{}, false
+	}
+}
+
 type HashPair struct {
 	Key   Object
 	Value Object
 }
 
+// Hash maps keys to values, keeping pairs in insertion order. Keys with
+// the same hash key are told apart by Equal, so collisions are harmless.
+// Hashes are persistent, With and Without return new hashes sharing most
+// of their structure with the original. The zero value is an empty hash.
 type Hash struct {
-	Pairs map[HashKey]HashPair
+	// index maps keys to positions of their pairs in pairs
+	index *hamtNode
+	// pairs holds HashPairs in insertion order, removed pairs
+	// are left as holes with a nil Key
+	pairs vector
+	size  int
+}
+
+func NewHash() *Hash {
+	return &Hash{}
+}
+
+// Get returns the value for key, ok is false if there is none
+// or key is not hashable.
+func (h *Hash) Get(key Object) (value Object, ok bool) {
+	hashKey, ok := HashKeyOf(key)
+	if !ok {
+		return nil, false
+	}
+	if i, found := h.index.find(hamtHash(hashKey), key, 0); found {
+		return h.pairs.at(i).(HashPair).Value, true
+	}
+	return nil, false
+}
+
+// Set maps key to value in place, replacing the previous value of key.
+// It reports false if key is not hashable. It's meant for building new
+// hashes, use With for hashes which may be shared.
+func (h *Hash) Set(key, value Object) bool {
+	updated, ok := h.With(key, value)
+	if ok {
+		*h = *updated
+	}
+	return ok
+}
+
+// With returns h with key mapped to value, ok is false if key
+// is not hashable.
+func (h *Hash) With(key, value Object) (result *Hash, ok bool) {
+	hashKey, ok := HashKeyOf(key)
+	if !ok {
+		return nil, false
+	}
+	hash := hamtHash(hashKey)
+	pair := HashPair{Key: key, Value: value}
+
+	if i, found := h.index.find(hash, key, 0); found {
+		return &Hash{index: h.index, pairs: h.pairs.set(i, pair), size: h.size}, true
+	}
+
+	entry := hamtEntry{key: key, index: h.pairs.len()}
+	return &Hash{
+		index: h.index.insert(hash, entry, 0),
+		pairs: h.pairs.push(pair),
+		size:  h.size + 1,
+	}, true
+}
+
+// Without returns h without key.
+func (h *Hash) Without(key Object) *Hash {
+	hashKey, ok := HashKeyOf(key)
+	if !ok {
+		return h
+	}
+	hash := hamtHash(hashKey)
+
+	i, found := h.index.find(hash, key, 0)
+	if !found {
+		return h
+	}
+
+	result := &Hash{
+		index: h.index.remove(hash, key, 0),
+		pairs: h.pairs.set(i, HashPair{}),
+		size:  h.size - 1,
+	}
+
+	// don't let holes outgrow the pairs
+	if result.pairs.len() > 2*result.size+vectorWidth {
+		compacted := NewHash()
+		for _, pair := range result.Pairs() {
+			compacted.Set(pair.Key, pair.Value)
+		}
+		return compacted
+	}
+
+	return result
+}
+
+// Len returns the number of pairs in h.
+func (h *Hash) Len() int {
+	return h.size
+}
+
+// Pairs returns pairs of h in insertion order.
+func (h *Hash) Pairs() []HashPair {
+	pairs := make([]HashPair, 0, h.size)
+	h.pairs.each(func(value interface{}) {
+		if pair := value.(HashPair); pair.Key != nil {
+			pairs = append(pairs, pair)
+		}
+	})
+	return pairs
 }
 
 func (h *Hash) Type() ObjectType { return HASH_OBJ }
 func (h *Hash) Inspect() string {
+	return h.inspect(map[*StructInstance]bool{})
+}
+
+func (h *Hash) inspect(visiting map[*StructInstance]bool) string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for _, pair := range h.Pairs {
-		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
+	for _, pair := range h.Pairs() {
+		pairs = append(pairs, fmt.Sprintf("%s: %s", inspect(pair.Key, visiting), inspect(pair.Value, visiting)))
 	}
 
 	out.WriteString("{")
@@ -185,6 +437,26 @@ func (h *Hash) Inspect() string {
 	return out.String()
 }
 
+// Member returns the value under string key name.
+func (h *Hash) Member(name string) (Object, bool) {
+	return h.Get(&String{Value: name})
+}
+
+// MemberAccessor is implemented by objects with members which can be
+// accessed with the dot operator, e.g. hashes or struct instances.
+type MemberAccessor interface {
+	Member(name string) (Object, bool)
+}
+
+var _ MemberAccessor = &Hash{}
+
+// InfixOperable is implemented by objects which define infix operators.
+// The receiver is the left operand, unless reversed is true, then it's
+// the right one. ok is false if the operation is not supported.
+type InfixOperable interface {
+	InfixOperation(operator string, other Object, reversed bool) (result Object, ok bool)
+}
+
 type Hashable interface {
 	HashKey() HashKey
 }
@@ -192,3 +464,38 @@ type Hashable interface {
 var _ Hashable = &Boolean{}
 var _ Hashable = &String{}
 var _ Hashable = &Integer{}
+var _ Hashable = &Array{}
+
+type Quote struct {
+	Node ast.Node
+}
+
+func (q *Quote) Type() ObjectType { return QUOTE_OBJ }
+func (q *Quote) Inspect() string {
+	return "QUOTE(" + q.Node.String() + ")"
+}
+
+type Macro struct {
+	Parameters []*ast.Identifier
+	Body       *ast.BlockStatement
+	Env        *Environment
+}
+
+func (m *Macro) Type() ObjectType { return MACRO_OBJ }
+func (m *Macro) Inspect() string {
+	var out bytes.Buffer
+
+	params := []string{}
+	for _, p := range m.Parameters {
+		params = append(params, p.String())
+	}
+
+	out.WriteString("macro")
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") {\n")
+	out.WriteString(m.Body.String())
+	out.WriteString("\n}")
+
+	return out.String()
+}
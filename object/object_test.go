@@ -17,3 +17,194 @@ func TestStringHashKey(t *testing.T) {
 		t.Errorf("strings with different content have same hash keys")
 	}
 }
+
+func TestArrayHashKey(t *testing.T) {
+	pair1 := NewArray([]Object{&Integer{Value: 1}, &Integer{Value: 2}})
+	pair2 := NewArray([]Object{&Integer{Value: 1}, &Integer{Value: 2}})
+	swapped := NewArray([]Object{&Integer{Value: 2}, &Integer{Value: 1}})
+	strings := NewArray([]Object{&String{Value: "1"}, &String{Value: "2"}})
+	nested := NewArray([]Object{pair1})
+
+	if pair1.HashKey() != pair2.HashKey() {
+		t.Errorf("arrays with same content have different hash keys")
+	}
+	if pair1.HashKey() == swapped.HashKey() {
+		t.Errorf("arrays with different order have same hash keys")
+	}
+	if pair1.HashKey() == strings.HashKey() {
+		t.Errorf("arrays with elements of different types have same hash keys")
+	}
+	if pair1.HashKey() == nested.HashKey() {
+		t.Errorf("nested array has same hash key as its element")
+	}
+}
+
+func TestHashKeyOf(t *testing.T) {
+	tests := []struct {
+		obj      Object
+		hashable bool
+	}{
+		{&Integer{Value: 1}, true},
+		{&String{Value: "a"}, true},
+		{&Boolean{Value: true}, true},
+		{&Array{}, true},
+		{NewArray([]Object{NewArray([]Object{&String{Value: "a"}})}), true},
+		{NewArray([]Object{&Function{}}), false},
+		{NewArray([]Object{NewArray([]Object{NewHash()})}), false},
+		{NewHash(), false},
+		{&Null{}, false},
+	}
+
+	for i, tt := range tests {
+		if _, ok := HashKeyOf(tt.obj); ok != tt.hashable {
+			t.Errorf("tests[%d] HashKeyOf(%s) wrong. expected hashable=%t, got=%t", i, tt.obj.Inspect(), tt.hashable, ok)
+		}
+	}
+}
+
+// Elements are hashed once, so this doesn't take time exponential in depth.
+func TestHashKeyOfDeeplyNested(t *testing.T) {
+	hashable := NewArray([]Object{&Integer{Value: 1}})
+	unhashable := NewArray([]Object{&Function{}})
+	for i := 0; i < 100; i++ {
+		hashable = NewArray([]Object{&Integer{Value: 1}, hashable})
+		unhashable = NewArray([]Object{&Integer{Value: 1}, unhashable})
+	}
+
+	if _, ok := HashKeyOf(hashable); !ok {
+		t.Errorf("nested array is not hashable")
+	}
+	if _, ok := HashKeyOf(unhashable); ok {
+		t.Errorf("nested array containing a function is hashable")
+	}
+}
+
+// colliding has the same hash key for all values.
+type colliding struct {
+	name string
+}
+
+func (c *colliding) Type() ObjectType { return "COLLIDING" }
+func (c *colliding) Inspect() string  { return c.name }
+func (c *colliding) HashKey() HashKey { return HashKey{Type: c.Type(), Value: 42} }
+
+func TestHashCollisions(t *testing.T) {
+	a := &colliding{name: "a"}
+	b := &colliding{name: "b"}
+
+	h := NewHash()
+	h.Set(a, &Integer{Value: 1})
+	h.Set(b, &Integer{Value: 2})
+
+	if h.Len() != 2 {
+		t.Fatalf("colliding keys overwrote each other, got %d pairs", h.Len())
+	}
+
+	for _, tt := range []struct {
+		key      Object
+		expected int64
+	}{{a, 1}, {b, 2}} {
+		value, ok := h.Get(tt.key)
+		if !ok {
+			t.Errorf("no value for %s", tt.key.Inspect())
+			continue
+		}
+		if value.(*Integer).Value != tt.expected {
+			t.Errorf("wrong value for %s, expected %d, got=%s", tt.key.Inspect(), tt.expected, value.Inspect())
+		}
+	}
+
+	if _, ok := h.Get(&colliding{name: "c"}); ok {
+		t.Errorf("found a value for a key which was not set")
+	}
+
+	h.Set(b, &Integer{Value: 3})
+	if value, _ := h.Get(b); h.Len() != 2 || value.(*Integer).Value != 3 {
+		t.Errorf("setting an existing colliding key didn't replace its value, got %s", h.Inspect())
+	}
+	if value, _ := h.Get(a); value.(*Integer).Value != 1 {
+		t.Errorf("setting a colliding key changed value of another key, got %s", h.Inspect())
+	}
+}
+
+func TestHashInsertionOrder(t *testing.T) {
+	h := NewHash()
+	h.Set(&String{Value: "b"}, &Integer{Value: 1})
+	h.Set(&Integer{Value: 1}, &Integer{Value: 2})
+	h.Set(&String{Value: "a"}, &Integer{Value: 3})
+	h.Set(&String{Value: "b"}, &Integer{Value: 4})
+
+	if h.Inspect() != "{b: 4,1: 2,a: 3}" {
+		t.Errorf("pairs are not in insertion order, got %s", h.Inspect())
+	}
+}
+
+func TestHashWithAndWithout(t *testing.T) {
+	const n = 5000
+
+	h := NewHash()
+	for i := 0; i < n; i++ {
+		h, _ = h.With(&Integer{Value: int64(i)}, &Integer{Value: int64(i * 2)})
+	}
+	if h.Len() != n {
+		t.Fatalf("wrong length. expected=%d, got=%d", n, h.Len())
+	}
+
+	removed := h
+	for i := 0; i < n; i += 2 {
+		removed = removed.Without(&Integer{Value: int64(i)})
+	}
+	updated, _ := removed.With(&Integer{Value: 1}, &String{Value: "one"})
+
+	if h.Len() != n || removed.Len() != n/2 || updated.Len() != n/2 {
+		t.Fatalf("wrong lengths, got=%d %d %d", h.Len(), removed.Len(), updated.Len())
+	}
+
+	for i := 0; i < n; i++ {
+		key := &Integer{Value: int64(i)}
+		if value, ok := h.Get(key); !ok || value.(*Integer).Value != int64(i*2) {
+			t.Fatalf("original hash was modified at %d, got %v", i, value)
+		}
+		_, ok := removed.Get(key)
+		if ok != (i%2 == 1) {
+			t.Fatalf("wrong presence of %d after removal, got %t", i, ok)
+		}
+	}
+
+	if value, _ := removed.Get(&Integer{Value: 1}); value.(*Integer).Value != 2 {
+		t.Errorf("updating a copy modified the original, got %s", value.Inspect())
+	}
+	if value, _ := updated.Get(&Integer{Value: 1}); value.Inspect() != "one" {
+		t.Errorf("wrong updated value, got %s", value.Inspect())
+	}
+
+	pairs := updated.Pairs()
+	for i, pair := range pairs {
+		if pair.Key.(*Integer).Value != int64(i*2+1) {
+			t.Fatalf("pairs are not in insertion order after removal, got %s at %d", pair.Key.Inspect(), i)
+		}
+	}
+}
+
+func TestHashWithoutCollisions(t *testing.T) {
+	a := &colliding{name: "a"}
+	b := &colliding{name: "b"}
+
+	h := NewHash()
+	h.Set(a, &Integer{Value: 1})
+	h.Set(b, &Integer{Value: 2})
+
+	withoutA := h.Without(a)
+	if _, ok := withoutA.Get(a); ok || withoutA.Len() != 1 {
+		t.Errorf("colliding key was not removed, got %s", withoutA.Inspect())
+	}
+	if value, ok := withoutA.Get(b); !ok || value.(*Integer).Value != 2 {
+		t.Errorf("removing a colliding key removed another one, got %s", withoutA.Inspect())
+	}
+	if empty := withoutA.Without(b); empty.Len() != 0 || empty.Inspect() != "{}" {
+		t.Errorf("hash is not empty, got %s", empty.Inspect())
+	}
+	if h.Without(&colliding{name: "c"}).Len() != 2 {
+		t.Errorf("removing a missing key changed the hash")
+	}
+}
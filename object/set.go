@@ -0,0 +1,162 @@
+package object
+
+import (
+	"bytes"
+	"strings"
+)
+
+const SET_OBJ = "SET"
+
+// Set is an unordered collection of unique hashable values, which
+// remembers the order elements were added in. Sets are immutable
+// once they are visible to scripts, operations return new sets
+// sharing structure with the original.
+type Set struct {
+	elements Hash
+}
+
+func NewSet() *Set {
+	return &Set{}
+}
+
+// Add adds obj to s, it reports false if obj is not hashable.
+// It's only meant to be used while building a new set.
+func (s *Set) Add(obj Object) bool {
+	if _, ok := s.elements.Get(obj); ok {
+		return true
+	}
+	return s.elements.Set(obj, obj)
+}
+
+func (s *Set) Contains(obj Object) bool {
+	_, ok := s.elements.Get(obj)
+	return ok
+}
+
+func (s *Set) Len() int {
+	return s.elements.Len()
+}
+
+// Elements returns elements of s in the order they were added.
+func (s *Set) Elements() []Object {
+	elements := make([]Object, 0, s.Len())
+	for _, pair := range s.elements.Pairs() {
+		elements = append(elements, pair.Key)
+	}
+	return elements
+}
+
+// With returns a copy of s with obj added, ok is false if obj is not hashable.
+func (s *Set) With(obj Object) (result *Set, ok bool) {
+	if s.Contains(obj) {
+		return s, true
+	}
+	elements, ok := s.elements.With(obj, obj)
+	if !ok {
+		return nil, false
+	}
+	return &Set{elements: *elements}, true
+}
+
+// Without returns a copy of s without obj.
+func (s *Set) Without(obj Object) *Set {
+	return &Set{elements: *s.elements.Without(obj)}
+}
+
+// Union returns elements of s followed by those of other not in s.
+func (s *Set) Union(other *Set) *Set {
+	result := &Set{elements: s.elements}
+	for _, el := range other.Elements() {
+		result.Add(el)
+	}
+	return result
+}
+
+func (s *Set) Intersection(other *Set) *Set {
+	result := NewSet()
+	for _, el := range s.Elements() {
+		if other.Contains(el) {
+			result.Add(el)
+		}
+	}
+	return result
+}
+
+func (s *Set) Difference(other *Set) *Set {
+	result := NewSet()
+	for _, el := range s.Elements() {
+		if !other.Contains(el) {
+			result.Add(el)
+		}
+	}
+	return result
+}
+
+// IsSubset reports whether all elements of s are in other.
+func (s *Set) IsSubset(other *Set) bool {
+	if s.Len() > other.Len() {
+		return false
+	}
+	for _, el := range s.Elements() {
+		if !other.Contains(el) {
+			return false
+		}
+	}
+	return true
+}
+
+// HashKey of a set doesn't depend on the order elements were added in.
+func (s *Set) HashKey() HashKey {
+	var value uint64
+	for _, el := range s.Elements() {
+		key, _ := HashKeyOf(el)
+		value += key.Value
+	}
+	return HashKey{Type: s.Type(), Value: value}
+}
+
+// InfixOperation implements + as union and - as difference.
+func (s *Set) InfixOperation(operator string, other Object, reversed bool) (Object, bool) {
+	o, ok := other.(*Set)
+	if !ok {
+		return nil, false
+	}
+
+	left, right := s, o
+	if reversed {
+		left, right = o, s
+	}
+
+	switch operator {
+	case "+":
+		return left.Union(right), true
+	case "-":
+		return left.Difference(right), true
+	default:
+		return nil, false
+	}
+}
+
+func (s *Set) Type() ObjectType { return SET_OBJ }
+func (s *Set) Inspect() string {
+	return s.inspect(map[*StructInstance]bool{})
+}
+
+func (s *Set) inspect(visiting map[*StructInstance]bool) string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, el := range s.Elements() {
+		elements = append(elements, inspect(el, visiting))
+	}
+
+	out.WriteString("#{")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+var _ Object = &Set{}
+var _ Hashable = &Set{}
+var _ InfixOperable = &Set{}
@@ -0,0 +1,107 @@
+package object
+
+import "testing"
+
+func set(elements ...Object) *Set {
+	s := NewSet()
+	for _, el := range elements {
+		s.Add(el)
+	}
+	return s
+}
+
+func TestSetAdd(t *testing.T) {
+	s := set(integer(1), str("a"), integer(1), arr(integer(2)), arr(integer(2)))
+
+	if s.Len() != 3 {
+		t.Fatalf("wrong length. expected=3, got=%d", s.Len())
+	}
+	if s.Inspect() != "#{1, a, [2]}" {
+		t.Errorf("wrong elements. got=%s", s.Inspect())
+	}
+	if !s.Contains(arr(integer(2))) {
+		t.Errorf("set doesn't contain [2]")
+	}
+	if s.Contains(str("1")) {
+		t.Errorf("set contains \"1\"")
+	}
+	if s.Add(arr(&Function{})) {
+		t.Errorf("unhashable element was added")
+	}
+}
+
+func TestSetOperations(t *testing.T) {
+	a := set(integer(1), integer(2), integer(3))
+	b := set(integer(4), integer(3), integer(2))
+
+	tests := []struct {
+		result   *Set
+		expected string
+	}{
+		{a.Union(b), "#{1, 2, 3, 4}"},
+		{b.Union(a), "#{4, 3, 2, 1}"},
+		{a.Intersection(b), "#{2, 3}"},
+		{a.Difference(b), "#{1}"},
+		{b.Difference(a), "#{4}"},
+		{a.Without(integer(2)), "#{1, 3}"},
+		{a.Without(integer(5)), "#{1, 2, 3}"},
+	}
+
+	for _, tt := range tests {
+		if tt.result.Inspect() != tt.expected {
+			t.Errorf("wrong result. expected=%s, got=%s", tt.expected, tt.result.Inspect())
+		}
+	}
+
+	with, ok := a.With(integer(0))
+	if !ok || with.Inspect() != "#{1, 2, 3, 0}" {
+		t.Errorf("wrong result of With. got=%s", with.Inspect())
+	}
+	if a.Inspect() != "#{1, 2, 3}" || b.Inspect() != "#{4, 3, 2}" {
+		t.Errorf("operands were modified. got=%s and %s", a.Inspect(), b.Inspect())
+	}
+}
+
+func TestSetIsSubset(t *testing.T) {
+	tests := []struct {
+		a, b     *Set
+		expected bool
+	}{
+		{set(), set(), true},
+		{set(), set(integer(1)), true},
+		{set(integer(1)), set(), false},
+		{set(integer(2), integer(1)), set(integer(1), integer(2), integer(3)), true},
+		{set(integer(1), integer(4)), set(integer(1), integer(2), integer(3)), false},
+	}
+
+	for _, tt := range tests {
+		if tt.a.IsSubset(tt.b) != tt.expected {
+			t.Errorf("%s.IsSubset(%s) is not %t", tt.a.Inspect(), tt.b.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestSetEqualityAndHashKey(t *testing.T) {
+	a := set(integer(1), str("a"), arr(integer(2)))
+	b := set(arr(integer(2)), integer(1), str("a"))
+	c := set(integer(1), str("a"))
+
+	if !Equal(a, b) {
+		t.Errorf("sets with the same elements in different order are not equal")
+	}
+	if Equal(a, c) || Equal(c, a) {
+		t.Errorf("sets with different elements are equal")
+	}
+	if a.HashKey() != b.HashKey() {
+		t.Errorf("hash key depends on order of elements")
+	}
+
+	h := NewHash()
+	h.Set(a, integer(1))
+	if value, ok := h.Get(b); !ok || !Equal(value, integer(1)) {
+		t.Errorf("equal set is not found in hash")
+	}
+	if !set(a).Contains(b) {
+		t.Errorf("equal set is not found in set")
+	}
+}
@@ -0,0 +1,149 @@
+package object
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+)
+
+const (
+	STRUCT_TYPE_OBJ  = "STRUCT_TYPE"
+	STRUCT_OBJ       = "STRUCT"
+	BOUND_METHOD_OBJ = "BOUND_METHOD"
+)
+
+// StructType is declared by a struct statement, calling it
+// creates an instance with fields given in order.
+type StructType struct {
+	Name    string
+	Fields  []string
+	Methods map[string]*Function
+}
+
+func (st *StructType) Type() ObjectType { return STRUCT_TYPE_OBJ }
+func (st *StructType) Inspect() string {
+	return "struct " + st.Name + " { " + strings.Join(st.Fields, ", ") + " }"
+}
+
+// HasField reports whether instances of st have a field called name.
+func (st *StructType) HasField(name string) bool {
+	for _, f := range st.Fields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+var _ Object = &StructType{}
+
+// StructInstance is a value of a struct type. Unlike other objects
+// its fields can be assigned, so access is guarded by a mutex.
+type StructInstance struct {
+	StructType *StructType
+
+	mu     sync.Mutex
+	fields map[string]Object
+}
+
+// NewStructInstance returns an instance of st, values are
+// assigned to fields in order.
+func NewStructInstance(st *StructType, values []Object) *StructInstance {
+	fields := make(map[string]Object, len(st.Fields))
+	for i, name := range st.Fields {
+		fields[name] = values[i]
+	}
+	return &StructInstance{StructType: st, fields: fields}
+}
+
+// Get returns the value of field name.
+func (si *StructInstance) Get(name string) (Object, bool) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+
+	val, ok := si.fields[name]
+	return val, ok
+}
+
+// Set assigns val to field name, it reports false if there is no such field.
+func (si *StructInstance) Set(name string, val Object) bool {
+	if !si.StructType.HasField(name) {
+		return false
+	}
+
+	si.mu.Lock()
+	defer si.mu.Unlock()
+
+	si.fields[name] = val
+	return true
+}
+
+// Member returns the value of field name, or method name bound to si.
+func (si *StructInstance) Member(name string) (Object, bool) {
+	if val, ok := si.Get(name); ok {
+		return val, true
+	}
+	if method, ok := si.StructType.Methods[name]; ok {
+		return &BoundMethod{Receiver: si, Method: method}, true
+	}
+	return nil, false
+}
+
+func (si *StructInstance) Type() ObjectType { return STRUCT_OBJ }
+func (si *StructInstance) Inspect() string {
+	return si.inspect(map[*StructInstance]bool{})
+}
+
+// inspect prints fields of si, an instance which is already being
+// printed, i.e. one of its fields refers back to it, is printed as Name{...}.
+func (si *StructInstance) inspect(visiting map[*StructInstance]bool) string {
+	if visiting[si] {
+		return si.StructType.Name + "{...}"
+	}
+	visiting[si] = true
+	defer delete(visiting, si)
+
+	var out bytes.Buffer
+
+	fields := []string{}
+	for _, name := range si.StructType.Fields {
+		val, _ := si.Get(name)
+		fields = append(fields, name+": "+inspect(val, visiting))
+	}
+
+	out.WriteString(si.StructType.Name)
+	out.WriteString("{")
+	out.WriteString(strings.Join(fields, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+var _ Object = &StructInstance{}
+var _ MemberAccessor = &StructInstance{}
+
+// nestedInspector is implemented by objects holding other objects.
+// Struct instances are the only mutable objects, so cycles always go
+// through one of them, visiting holds the instances being printed.
+type nestedInspector interface {
+	inspect(visiting map[*StructInstance]bool) string
+}
+
+func inspect(obj Object, visiting map[*StructInstance]bool) string {
+	if n, ok := obj.(nestedInspector); ok {
+		return n.inspect(visiting)
+	}
+	return obj.Inspect()
+}
+
+// BoundMethod is a method together with the value it was accessed on,
+// which is passed as the first argument when it's called.
+type BoundMethod struct {
+	Receiver Object
+	Method   Object
+}
+
+func (bm *BoundMethod) Type() ObjectType { return BOUND_METHOD_OBJ }
+func (bm *BoundMethod) Inspect() string  { return "bound method of " + bm.Receiver.Inspect() }
+
+var _ Object = &BoundMethod{}
@@ -0,0 +1,187 @@
+package object
+
+// vector is a persistent vector, a trie with 32 way branching where
+// updates copy only the path to the changed leaf and share the rest
+// with the original. Appending is amortized O(1), lookups and updates
+// are O(log32 n). The zero value is an empty vector.
+//
+// Dropping the first element only moves start, so it's O(1) too, the
+// dropped elements stay reachable until the vector is garbage.
+type vector struct {
+	// count includes elements before start
+	count int
+	shift uint
+	root  *vectorNode
+	// tail holds the last up to 32 elements, which are not in the trie yet
+	tail  []interface{}
+	start int
+}
+
+const (
+	vectorBits  = 5
+	vectorWidth = 1 << vectorBits
+	vectorMask  = vectorWidth - 1
+)
+
+// vectorNode is an inner node with children, or a leaf with values.
+type vectorNode struct {
+	children []*vectorNode
+	values   []interface{}
+}
+
+func newVector(values []interface{}) vector {
+	var v vector
+	for len(values) > 0 {
+		n := len(values)
+		if n > vectorWidth {
+			n = vectorWidth
+		}
+		if len(v.tail) == vectorWidth {
+			v.root, v.shift = v.pushTail()
+		}
+		v.tail = make([]interface{}, n)
+		copy(v.tail, values[:n])
+		v.count += n
+		values = values[n:]
+	}
+	return v
+}
+
+func (v vector) len() int {
+	return v.count - v.start
+}
+
+func (v vector) tailOffset() int {
+	return v.count - len(v.tail)
+}
+
+func (v vector) at(i int) interface{} {
+	i += v.start
+	if i >= v.tailOffset() {
+		return v.tail[i-v.tailOffset()]
+	}
+	return v.leaf(i).values[i&vectorMask]
+}
+
+func (v vector) leaf(i int) *vectorNode {
+	node := v.root
+	for level := v.shift; level > 0; level -= vectorBits {
+		node = node.children[(i>>level)&vectorMask]
+	}
+	return node
+}
+
+// push returns v with value appended.
+func (v vector) push(value interface{}) vector {
+	if len(v.tail) < vectorWidth {
+		tail := make([]interface{}, len(v.tail)+1)
+		copy(tail, v.tail)
+		tail[len(v.tail)] = value
+		v.tail = tail
+		v.count++
+		return v
+	}
+
+	v.root, v.shift = v.pushTail()
+	v.tail = []interface{}{value}
+	v.count++
+	return v
+}
+
+// pushTail returns the root and shift of the trie with the full tail of v
+// moved into it.
+func (v vector) pushTail() (*vectorNode, uint) {
+	leaf := &vectorNode{values: v.tail}
+
+	if v.root == nil {
+		return &vectorNode{children: []*vectorNode{leaf}}, vectorBits
+	}
+
+	// the root is full, the trie grows a level
+	if v.count>>vectorBits > 1<<v.shift {
+		root := &vectorNode{children: []*vectorNode{v.root, newVectorPath(v.shift, leaf)}}
+		return root, v.shift + vectorBits
+	}
+
+	return v.pushLeaf(v.shift, v.root, leaf), v.shift
+}
+
+func (v vector) pushLeaf(level uint, parent *vectorNode, leaf *vectorNode) *vectorNode {
+	i := ((v.count - 1) >> level) & vectorMask
+
+	node := &vectorNode{children: make([]*vectorNode, i+1)}
+	copy(node.children, parent.children)
+
+	if level == vectorBits {
+		node.children[i] = leaf
+	} else if i < len(parent.children) {
+		node.children[i] = v.pushLeaf(level-vectorBits, parent.children[i], leaf)
+	} else {
+		node.children[i] = newVectorPath(level-vectorBits, leaf)
+	}
+
+	return node
+}
+
+func newVectorPath(level uint, leaf *vectorNode) *vectorNode {
+	if level == 0 {
+		return leaf
+	}
+	return &vectorNode{children: []*vectorNode{newVectorPath(level-vectorBits, leaf)}}
+}
+
+// set returns v with the value at i replaced.
+func (v vector) set(i int, value interface{}) vector {
+	i += v.start
+	if i >= v.tailOffset() {
+		tail := make([]interface{}, len(v.tail))
+		copy(tail, v.tail)
+		tail[i-v.tailOffset()] = value
+		v.tail = tail
+		return v
+	}
+
+	v.root = setInVectorNode(v.shift, v.root, i, value)
+	return v
+}
+
+func setInVectorNode(level uint, node *vectorNode, i int, value interface{}) *vectorNode {
+	if level == 0 {
+		values := make([]interface{}, len(node.values))
+		copy(values, node.values)
+		values[i&vectorMask] = value
+		return &vectorNode{values: values}
+	}
+
+	children := make([]*vectorNode, len(node.children))
+	copy(children, node.children)
+	j := (i >> level) & vectorMask
+	children[j] = setInVectorNode(level-vectorBits, children[j], i, value)
+	return &vectorNode{children: children}
+}
+
+// rest returns v without its first element, v must not be empty.
+func (v vector) rest() vector {
+	if v.len() == 1 {
+		return vector{}
+	}
+	v.start++
+	return v
+}
+
+// each calls f with elements of v in order.
+func (v vector) each(f func(value interface{})) {
+	for i := v.start; i < v.count; {
+		var values []interface{}
+		offset := 0
+		if i >= v.tailOffset() {
+			values, offset = v.tail, i-v.tailOffset()
+		} else {
+			values, offset = v.leaf(i).values, i&vectorMask
+		}
+		for _, value := range values[offset:] {
+			f(value)
+		}
+		i += len(values) - offset
+	}
+}
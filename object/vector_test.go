@@ -0,0 +1,95 @@
+package object
+
+import "testing"
+
+func TestVectorPush(t *testing.T) {
+	// large enough for a trie three levels deep
+	const n = 40000
+
+	var v vector
+	for i := 0; i < n; i++ {
+		v = v.push(i)
+	}
+
+	if v.len() != n {
+		t.Fatalf("wrong length. expected=%d, got=%d", n, v.len())
+	}
+	for i := 0; i < n; i++ {
+		if v.at(i) != i {
+			t.Fatalf("wrong value at %d. got=%v", i, v.at(i))
+		}
+	}
+
+	built := newVector(vectorValues(n))
+	if built.len() != n {
+		t.Fatalf("wrong length of built vector. expected=%d, got=%d", n, built.len())
+	}
+	for i := 0; i < n; i++ {
+		if built.at(i) != i {
+			t.Fatalf("wrong value at %d of built vector. got=%v", i, built.at(i))
+		}
+	}
+}
+
+func TestVectorSharing(t *testing.T) {
+	for _, n := range []int{0, 1, 31, 32, 33, 1024, 1056, 2000} {
+		base := newVector(vectorValues(n))
+
+		a := base.push("a")
+		b := base.push("b")
+		if base.len() != n || a.len() != n+1 || b.len() != n+1 {
+			t.Fatalf("n=%d: wrong lengths, got=%d %d %d", n, base.len(), a.len(), b.len())
+		}
+		if a.at(n) != "a" || b.at(n) != "b" {
+			t.Errorf("n=%d: pushes are not independent, got=%v %v", n, a.at(n), b.at(n))
+		}
+
+		if n == 0 {
+			continue
+		}
+		updated := base.set(n-1, "x").set(0, "y")
+		if base.at(n-1) != n-1 || base.at(0) != 0 {
+			t.Errorf("n=%d: set modified the original", n)
+		}
+		if updated.at(0) != "y" || (n > 1 && updated.at(n-1) != "x") {
+			t.Errorf("n=%d: set didn't update the copy", n)
+		}
+	}
+}
+
+func TestVectorRest(t *testing.T) {
+	const n = 100
+
+	v := newVector(vectorValues(n))
+	for i := 0; i < n; i++ {
+		if v.len() != n-i {
+			t.Fatalf("wrong length. expected=%d, got=%d", n-i, v.len())
+		}
+		if v.at(0) != i {
+			t.Fatalf("wrong first value. expected=%d, got=%v", i, v.at(0))
+		}
+
+		var values []interface{}
+		v.each(func(value interface{}) { values = append(values, value) })
+		if len(values) != n-i || values[0] != i || values[len(values)-1] != n-1 {
+			t.Fatalf("wrong values: %v", values)
+		}
+
+		v = v.rest()
+	}
+
+	if v.len() != 0 {
+		t.Errorf("vector is not empty. got=%d", v.len())
+	}
+	if v = v.push(1); v.len() != 1 || v.at(0) != 1 {
+		t.Errorf("wrong value pushed after rest. got=%v", v.at(0))
+	}
+}
+
+func vectorValues(n int) []interface{} {
+	values := make([]interface{}, n)
+	for i := range values {
+		values[i] = i
+	}
+	return values
+}
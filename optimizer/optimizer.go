@@ -0,0 +1,401 @@
+// Package optimizer rewrites parsed programs so they do less work when
+// evaluated, without changing their result.
+package optimizer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/wmolicki/go-monkey/ast"
+	"github.com/wmolicki/go-monkey/token"
+)
+
+// Pass is a single rewrite of a program, passes modify the tree in place.
+type Pass struct {
+	Name        string
+	Description string
+	Apply       func(program *ast.Program)
+}
+
+var (
+	ConstantFolding = Pass{
+		Name:        "fold",
+		Description: "evaluate infix and prefix expressions on literals",
+		Apply:       foldConstants,
+	}
+	DeadBranchElimination = Pass{
+		Name:        "branches",
+		Description: "drop branches of if expressions with a constant condition",
+		Apply:       eliminateDeadBranches,
+	}
+	UnusedLetRemoval = Pass{
+		Name:        "lets",
+		Description: "remove let statements binding names which are never used",
+		Apply:       removeUnusedLets,
+	}
+)
+
+// DefaultPasses are all passes, in the order they work best in.
+var DefaultPasses = []Pass{ConstantFolding, DeadBranchElimination, UnusedLetRemoval}
+
+// Optimize applies passes to program in order and returns it.
+func Optimize(program *ast.Program, passes []Pass) *ast.Program {
+	for _, pass := range passes {
+		pass.Apply(program)
+	}
+	return program
+}
+
+// OptimizeAll applies passes to programs which are evaluated one after
+// another in the same environment, e.g. files run together. A name bound
+// by one of them is used if any of them uses it.
+func OptimizeAll(programs []*ast.Program, passes []Pass) {
+	// programs are optimized as blocks of a single program, blocks keep
+	// their last statement, which is the value of the program
+	combined := &ast.Program{}
+	for _, program := range programs {
+		combined.Statements = append(combined.Statements, &ast.BlockStatement{Statements: program.Statements})
+	}
+	Optimize(combined, passes)
+	for i, stmt := range combined.Statements {
+		programs[i].Statements = stmt.(*ast.BlockStatement).Statements
+	}
+}
+
+// ParsePasses returns passes named in a comma separated list, e.g. "fold,lets".
+func ParsePasses(names string) ([]Pass, error) {
+	passes := []Pass{}
+
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		found := false
+		for _, pass := range DefaultPasses {
+			if pass.Name == name {
+				passes = append(passes, pass)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown optimizer pass: %q", name)
+		}
+	}
+
+	return passes, nil
+}
+
+func foldConstants(program *ast.Program) {
+	ast.Modify(program, func(node ast.Node) ast.Node {
+		switch node := node.(type) {
+		case *ast.InfixExpression:
+			if folded := foldInfix(node); folded != nil {
+				return folded
+			}
+		case *ast.PrefixExpression:
+			if folded := foldPrefix(node); folded != nil {
+				return folded
+			}
+		}
+		return node
+	})
+}
+
+// foldInfix returns the literal the expression evaluates to, or nil
+// if it can't be folded. Expressions which would fail at runtime are
+// left alone, so the error is still reported.
+func foldInfix(ie *ast.InfixExpression) ast.Expression {
+	pos := ie.Token
+
+	switch left := ie.Left.(type) {
+	case *ast.IntegerLiteral:
+		right, ok := ie.Right.(*ast.IntegerLiteral)
+		if !ok {
+			return nil
+		}
+		l, r := left.Value, right.Value
+		switch ie.Operator {
+		case "+":
+			return integer(pos, l+r)
+		case "-":
+			return integer(pos, l-r)
+		case "*":
+			return integer(pos, l*r)
+		case "/":
+			if r == 0 {
+				return nil
+			}
+			return integer(pos, l/r)
+		case "<":
+			return boolean(pos, l < r)
+		case ">":
+			return boolean(pos, l > r)
+		case "==":
+			return boolean(pos, l == r)
+		case "!=":
+			return boolean(pos, l != r)
+		}
+	case *ast.StringLiteral:
+		right, ok := ie.Right.(*ast.StringLiteral)
+		if !ok {
+			return nil
+		}
+		switch ie.Operator {
+		case "+":
+			return str(pos, left.Value+right.Value)
+		case "==":
+			return boolean(pos, left.Value == right.Value)
+		case "!=":
+			return boolean(pos, left.Value != right.Value)
+		}
+	case *ast.Boolean:
+		right, ok := ie.Right.(*ast.Boolean)
+		if !ok {
+			return nil
+		}
+		switch ie.Operator {
+		case "==":
+			return boolean(pos, left.Value == right.Value)
+		case "!=":
+			return boolean(pos, left.Value != right.Value)
+		}
+	}
+
+	return nil
+}
+
+func foldPrefix(pe *ast.PrefixExpression) ast.Expression {
+	switch pe.Operator {
+	case "-":
+		if right, ok := pe.Right.(*ast.IntegerLiteral); ok {
+			return integer(pe.Token, -right.Value)
+		}
+	case "!":
+		// only null and false are falsy
+		switch right := pe.Right.(type) {
+		case *ast.Boolean:
+			return boolean(pe.Token, !right.Value)
+		case *ast.IntegerLiteral, *ast.StringLiteral:
+			return boolean(pe.Token, false)
+		}
+	}
+	return nil
+}
+
+func integer(pos token.Token, value int64) *ast.IntegerLiteral {
+	literal := strconv.FormatInt(value, 10)
+	return &ast.IntegerLiteral{Token: at(pos, token.INT, literal), Value: value}
+}
+
+func boolean(pos token.Token, value bool) *ast.Boolean {
+	if value {
+		return &ast.Boolean{Token: at(pos, token.TRUE, "true"), Value: true}
+	}
+	return &ast.Boolean{Token: at(pos, token.FALSE, "false"), Value: false}
+}
+
+func str(pos token.Token, value string) *ast.StringLiteral {
+	return &ast.StringLiteral{Token: at(pos, token.STRING, value), Value: value}
+}
+
+// at returns a token positioned where the folded expression was.
+func at(pos token.Token, t token.TokenType, literal string) token.Token {
+	return token.Token{Type: t, Literal: literal, Line: pos.Line, Column: pos.Column}
+}
+
+// constantCondition reports whether e is a literal, and if so whether it's truthy.
+func constantCondition(e ast.Expression) (isConstant bool, truthy bool) {
+	switch e := e.(type) {
+	case *ast.Boolean:
+		return true, e.Value
+	case *ast.IntegerLiteral, *ast.StringLiteral:
+		return true, true
+	default:
+		return false, false
+	}
+}
+
+func eliminateDeadBranches(program *ast.Program) {
+	ast.Modify(program, func(node ast.Node) ast.Node {
+		switch node := node.(type) {
+		case *ast.IfExpression:
+			return reduceIf(node)
+		case *ast.BlockStatement:
+			node.Statements = spliceConstantIfs(node.Statements)
+		case *ast.Program:
+			node.Statements = spliceConstantIfs(node.Statements)
+		}
+		return node
+	})
+}
+
+// reduceIf replaces an if expression with a constant condition by the
+// expression of the taken branch, if it consists of a single expression,
+// otherwise it drops the branch which is never taken.
+func reduceIf(ie *ast.IfExpression) ast.Expression {
+	isConstant, truthy := constantCondition(ie.Condition)
+	if !isConstant {
+		return ie
+	}
+
+	taken := ie.Consequence
+	if !truthy {
+		if ie.Alternative == nil {
+			// evaluates to null, which has no literal
+			return ie
+		}
+		taken = ie.Alternative
+	}
+
+	if len(taken.Statements) == 1 {
+		if es, ok := taken.Statements[0].(*ast.ExpressionStatement); ok && es.Expression != nil {
+			return es.Expression
+		}
+	}
+
+	if !truthy {
+		ie.Condition = boolean(ie.Token, true)
+		ie.Consequence = ie.Alternative
+	}
+	ie.Alternative = nil
+
+	return ie
+}
+
+// spliceConstantIfs replaces if statements with a constant condition
+// by statements of the taken branch. Blocks don't introduce a new
+// environment, so this doesn't change what names are visible.
+func spliceConstantIfs(statements []ast.Statement) []ast.Statement {
+	result := []ast.Statement{}
+
+	for i, stmt := range statements {
+		es, ok := stmt.(*ast.ExpressionStatement)
+		if !ok {
+			result = append(result, stmt)
+			continue
+		}
+		ie, ok := es.Expression.(*ast.IfExpression)
+		if !ok {
+			result = append(result, stmt)
+			continue
+		}
+		isConstant, truthy := constantCondition(ie.Condition)
+		if !isConstant {
+			result = append(result, stmt)
+			continue
+		}
+
+		var taken []ast.Statement
+		if truthy {
+			taken = ie.Consequence.Statements
+		} else if ie.Alternative != nil {
+			taken = ie.Alternative.Statements
+		}
+
+		// the value of the last statement is the value of the block,
+		// an if without statements evaluates to null, which has no literal
+		isLast := i == len(statements)-1
+		if isLast && len(taken) == 0 {
+			result = append(result, stmt)
+			continue
+		}
+
+		result = append(result, taken...)
+	}
+
+	return result
+}
+
+func removeUnusedLets(program *ast.Program) {
+	for {
+		used := usedNames(program)
+		removed := false
+
+		remove := func(statements []ast.Statement) []ast.Statement {
+			result := []ast.Statement{}
+			for i, stmt := range statements {
+				isLast := i == len(statements)-1
+				if ls, ok := stmt.(*ast.LetStatement); ok && !isLast && !used[ls.Name.Value] && isPure(ls.Value) {
+					removed = true
+					continue
+				}
+				result = append(result, stmt)
+			}
+			return result
+		}
+
+		ast.Inspect(program, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.Program:
+				node.Statements = remove(node.Statements)
+			case *ast.BlockStatement:
+				node.Statements = remove(node.Statements)
+			}
+			return true
+		})
+
+		// removing a let can make names used in its value unused
+		if !removed {
+			return
+		}
+	}
+}
+
+// usedNames returns names of all identifiers which are read in program.
+// Names are looked up at runtime, so a name is used if any
+// identifier with that name is, regardless of scope.
+func usedNames(program *ast.Program) map[string]bool {
+	used := map[string]bool{}
+
+	var visit func(node ast.Node) bool
+	visit = func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.LetStatement:
+			// skip the name being bound
+			if node.Value != nil {
+				ast.Inspect(node.Value, visit)
+			}
+			return false
+		case *ast.Identifier:
+			used[node.Value] = true
+		}
+		return true
+	}
+	ast.Inspect(program, visit)
+
+	return used
+}
+
+// isPure reports whether evaluating e can't fail and has no side effects.
+func isPure(e ast.Expression) bool {
+	switch e := e.(type) {
+	case *ast.IntegerLiteral, *ast.StringLiteral, *ast.Boolean, *ast.FunctionLiteral:
+		return true
+	case *ast.ArrayLiteral:
+		for _, el := range e.Elements {
+			if !isPure(el) {
+				return false
+			}
+		}
+		return true
+	case *ast.HashLiteral:
+		for key, value := range e.Pairs {
+			switch key.(type) {
+			case *ast.IntegerLiteral, *ast.StringLiteral, *ast.Boolean:
+			default:
+				return false
+			}
+			if !isPure(value) {
+				return false
+			}
+		}
+		return true
+	default:
+		// identifiers might not be defined, calls and operators can fail
+		return false
+	}
+}
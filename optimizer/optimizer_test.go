@@ -0,0 +1,197 @@
+package optimizer
+
+import (
+	"testing"
+
+	"github.com/wmolicki/go-monkey/ast"
+	"github.com/wmolicki/go-monkey/evaluator"
+	"github.com/wmolicki/go-monkey/lexer"
+	"github.com/wmolicki/go-monkey/object"
+	"github.com/wmolicki/go-monkey/parser"
+)
+
+func TestConstantFolding(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"60 * 60 * 24", "86400"},
+		{"1 + 2 * 3 - 4 / 2", "5"},
+		{"-5 + 2", "-3"},
+		{"--5", "5"},
+		{`"a" + "b" + "c"`, "abc"},
+		{`"a" == "a"`, "true"},
+		{"1 < 2 == true", "true"},
+		{"true != false", "true"},
+		{"!true", "false"},
+		{"!5", "false"},
+		{"!!0", "true"},
+		{"x + 1 * 2", "(x + 2)"},
+		{"1 / 0", "(1 / 0)"},
+		{"1 + true", "(1 + true)"},
+		{"true + false", "(true + false)"},
+		{`"a" - "b"`, "(a - b)"},
+		{"f(2 * 3)", "f(6)"},
+		{"let x = 2 * 3;", "let x = 6;"},
+	}
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		Optimize(program, []Pass{ConstantFolding})
+
+		if program.String() != tt.expected {
+			t.Errorf("%q: expected %q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+}
+
+func TestDeadBranchElimination(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"if (true) { 1 } else { 2 }", "1"},
+		{"if (false) { 1 } else { 2 }", "2"},
+		{"let x = if (1) { 1 } else { 2 };", "let x = 1;"},
+		{"if (false) { 1 }; 5", "5"},
+		{"5; if (false) { 1 }", "5iffalse 1"},
+		{"if (true) { let a = 1; a }; a", "let a = 1;aa"},
+		{"let x = if (false) { 1 } else { let a = 1; a };", "let x = iftrue let a = 1;a;"},
+		{"fn() { if (true) { return 1; }; 2 }", "fn()return 1;2"},
+		{"if (x) { 1 } else { 2 }", "ifx 12"},
+	}
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		Optimize(program, []Pass{DeadBranchElimination})
+
+		if program.String() != tt.expected {
+			t.Errorf("%q: expected %q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+}
+
+func TestUnusedLetRemoval(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = 1; 5", "5"},
+		{"let a = 1; a", "let a = 1;a"},
+		{"let a = 1; let b = [a]; 5", "let a = 1;let b = [a];5"},
+		{"let a = 1; let b = [1, {true: a}]; b", "let a = 1;let b = [1, {true:a}];b"},
+		{"let a = 1; let b = [1, {true: fn() { a }}]; 5", "5"},
+		{"let a = f(); 5", "let a = f();5"},
+		{"let a = x; 5", "let a = x;5"},
+		{"let a = 1 + true; 5", "let a = (1 + true);5"},
+		{"let f = fn() { let unused = 1; 2 }; f()", "let f = fn()2;f()"},
+		{"let a = 1;", "let a = 1;"},
+		// a function referring to itself keeps itself alive
+		{"let r = fn(n) { r(n) }; 5", "let r = fn(n)r(n);5"},
+	}
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		Optimize(program, []Pass{UnusedLetRemoval})
+
+		if program.String() != tt.expected {
+			t.Errorf("%q: expected %q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+}
+
+func TestOptimizeAll(t *testing.T) {
+	tests := []struct {
+		inputs   []string
+		expected []string
+	}{
+		// names bound by one program may be used by a later one
+		{
+			[]string{"let helper = fn() { 42 }; let unused = 1; let x = 1 + 2;", "helper()"},
+			[]string{"let helper = fn()42;let x = 3;", "helper()"},
+		},
+		// each program keeps its last statement, and constant ifs are
+		// spliced within their program
+		{
+			[]string{"let a = 1; if (true) { let b = 2 }", "let c = 3; 5", "b"},
+			[]string{"let b = 2;", "5", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		programs := []*ast.Program{}
+		for _, input := range tt.inputs {
+			programs = append(programs, parse(t, input))
+		}
+		OptimizeAll(programs, DefaultPasses)
+
+		for i, program := range programs {
+			if program.String() != tt.expected[i] {
+				t.Errorf("%q: program %d: expected %q, got=%q", tt.inputs, i, tt.expected[i], program.String())
+			}
+		}
+	}
+}
+
+// TestOptimizePreservesResults checks optimized programs evaluate
+// to the same result as the original ones.
+func TestOptimizePreservesResults(t *testing.T) {
+	tests := []string{
+		"let day = 60 * 60 * 24; day",
+		"let x = 0; for (let i = 0; i < 3; let i = i + 1) { let x = x + 2 * 5 }; x",
+		"if (1 > 2) { 10 } else { 20 }",
+		"if (false) { 10 }",
+		"let f = fn() { if (true) { return 1; }; 2 }; f()",
+		"let f = fn() { 5; if (false) { 1 } }; f()",
+		`let greet = fn(name) { "hello " + name }; greet("wor" + "ld")`,
+		"let unused = 5; let used = !true; used",
+		"1 / 0 + 1",
+		"let a = 1 + true; 5",
+	}
+
+	for _, input := range tests {
+		expected := evaluate(parse(t, input))
+
+		program := Optimize(parse(t, input), DefaultPasses)
+		actual := evaluate(program)
+
+		if inspect(actual) != inspect(expected) {
+			t.Errorf("%q: optimized to %q, expected %s, got=%s",
+				input, program.String(), inspect(expected), inspect(actual))
+		}
+	}
+}
+
+func TestParsePasses(t *testing.T) {
+	passes, err := ParsePasses("fold, lets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(passes) != 2 || passes[0].Name != "fold" || passes[1].Name != "lets" {
+		t.Errorf("wrong passes parsed: %+v", passes)
+	}
+
+	if _, err := ParsePasses("fold,nope"); err == nil {
+		t.Errorf("expected an error for an unknown pass")
+	}
+}
+
+func parse(t *testing.T, input string) *ast.Program {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+	return program
+}
+
+func evaluate(program *ast.Program) object.Object {
+	return evaluator.Eval(program, object.NewEnvironment())
+}
+
+func inspect(obj object.Object) string {
+	if obj == nil {
+		return "<nil>"
+	}
+	return obj.Inspect()
+}
@@ -12,6 +12,7 @@ import (
 const (
 	_ int = iota
 	LOWEST
+	ASSIGN      // x.y = z
 	EQUALS      // ==
 	LESSGREATER // < or >
 	SUM         // +
@@ -24,7 +25,7 @@ const (
 var precedences = map[token.TokenType]int{
 	token.EQ:       EQUALS,
 	token.NOT_EQ:   EQUALS,
-	token.ASSIGN:   EQUALS,
+	token.ASSIGN:   ASSIGN,
 	token.LT:       LESSGREATER,
 	token.GT:       LESSGREATER,
 	token.PLUS:     SUM,
@@ -33,15 +34,31 @@ var precedences = map[token.TokenType]int{
 	token.ASTERISK: PRODUCT,
 	token.LPAREN:   CALL,
 	token.LBRACKET: INDEX,
+	token.DOT:      INDEX,
+}
+
+// Precedence returns the binding power of an infix operator token,
+// LOWEST if the token is not an infix operator.
+func Precedence(t token.TokenType) int {
+	if p, ok := precedences[t]; ok {
+		return p
+	}
+	return LOWEST
 }
 
 type Parser struct {
 	l *lexer.Lexer
 
-	errors    []string
+	errors []string
+	// errorPositions holds the position of each error
+	errorPositions []token.Position
+
 	curToken  token.Token
 	peekToken token.Token
 
+	// functionDepth is the number of function literals being parsed
+	functionDepth int
+
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
 }
@@ -61,10 +78,18 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
+	p.registerPrefix(token.HASH_LBRACE, p.parseSetLiteral)
 	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
 	p.registerPrefix(token.FOR, p.parseForExpression)
+	p.registerPrefix(token.MACRO, p.parseMacroLiteral)
+	p.registerPrefix(token.MATCH, p.parseMatchExpression)
+	p.registerPrefix(token.TRY, p.parseTryExpression)
+	p.registerPrefix(token.SPAWN, p.parseSpawnExpression)
+	p.registerPrefix(token.SELECT, p.parseSelectExpression)
 
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
+	p.registerInfix(token.DOT, p.parseMemberExpression)
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
 	p.registerInfix(token.ASTERISK, p.parseInfixExpression)
@@ -87,10 +112,19 @@ func (p *Parser) Errors() []string {
 	return p.errors
 }
 
+// ErrorPositions returns where each of Errors was found, in the same order.
+func (p *Parser) ErrorPositions() []token.Position {
+	return p.errorPositions
+}
+
+func (p *Parser) addError(pos token.Position, format string, a ...interface{}) {
+	p.errors = append(p.errors, fmt.Sprintf(format, a...))
+	p.errorPositions = append(p.errorPositions, pos)
+}
+
 func (p *Parser) peekError(t token.TokenType) {
-	msg := fmt.Sprintf("expected next token to be %s, got %s instead",
+	p.addError(p.peekToken.Pos(), "expected next token to be %s, got %s instead",
 		t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
 }
 
 func (p *Parser) nextToken() {
@@ -113,15 +147,36 @@ func (p *Parser) ParseProgram() *ast.Program {
 	return program
 }
 
+// parseStatement returns nil, rather than a nil pointer of a statement
+// type, if the statement can't be parsed.
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
 	case token.LET:
-		return p.parseLetStatement()
+		if stmt := p.parseLetStatement(); stmt != nil {
+			return stmt
+		}
 	case token.RETURN:
-		return p.parseReturnStatement()
+		if stmt := p.parseReturnStatement(); stmt != nil {
+			return stmt
+		}
+	case token.THROW:
+		if stmt := p.parseThrowStatement(); stmt != nil {
+			return stmt
+		}
+	case token.YIELD:
+		if stmt := p.parseYieldStatement(); stmt != nil {
+			return stmt
+		}
+	case token.STRUCT:
+		if stmt := p.parseStructStatement(); stmt != nil {
+			return stmt
+		}
 	default:
-		return p.parseExpressionStatement()
+		if stmt := p.parseExpressionStatement(); stmt != nil {
+			return stmt
+		}
 	}
+	return nil
 }
 
 func (p *Parser) parseLetStatement() *ast.LetStatement {
@@ -235,8 +290,7 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.addError(p.curToken.Pos(), "could not parse %q as integer", p.curToken.Literal)
 		return nil
 	}
 
@@ -246,8 +300,7 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 }
 
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
-	msg := fmt.Sprintf("no prefix parse function for '%s' found", t)
-	p.errors = append(p.errors, msg)
+	p.addError(p.curToken.Pos(), "no prefix parse function for '%s' found", t)
 }
 
 func (p *Parser) parsePrefixExpression() ast.Expression {
@@ -346,9 +399,10 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 		stmt := p.parseStatement()
 		if stmt != nil {
 			block.Statements = append(block.Statements, stmt)
-			p.nextToken()
 		}
+		p.nextToken()
 	}
+	block.EndToken = p.curToken
 
 	return block
 }
@@ -366,7 +420,9 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 		return nil
 	}
 
+	p.functionDepth++
 	lit.Body = p.parseBlockStatement()
+	p.functionDepth--
 
 	return lit
 }
@@ -417,6 +473,14 @@ func (p *Parser) parseArrayLiteral() ast.Expression {
 	return array
 }
 
+func (p *Parser) parseSetLiteral() ast.Expression {
+	set := &ast.SetLiteral{Token: p.curToken}
+
+	set.Elements = p.parseExpressionList(token.RBRACE)
+
+	return set
+}
+
 func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 	list := []ast.Expression{}
 
@@ -430,6 +494,10 @@ func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 
 	for p.peekTokenIs(token.COMMA) {
 		p.nextToken()
+		// allow trailing comma, e.g. in multi-line array literals
+		if p.peekTokenIs(end) {
+			break
+		}
 		p.nextToken()
 		list = append(list, p.parseExpression(LOWEST))
 	}
@@ -520,3 +588,537 @@ func (p *Parser) parseForExpression() ast.Expression {
 
 	return exp
 }
+
+func (p *Parser) parseMacroLiteral() ast.Expression {
+	lit := &ast.MacroLiteral{Token: p.curToken}
+
+	if !p.expectPeekAndAdvance(token.LPAREN) {
+		return nil
+	}
+
+	lit.Parameters = p.parseFunctionParameters()
+
+	if !p.expectPeekAndAdvance(token.LBRACE) {
+		return nil
+	}
+
+	lit.Body = p.parseBlockStatement()
+
+	return lit
+}
+
+func (p *Parser) parseMatchExpression() ast.Expression {
+	exp := &ast.MatchExpression{Token: p.curToken}
+
+	if !p.expectPeekAndAdvance(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	exp.Subject = p.parseExpression(LOWEST)
+
+	if !p.expectPeekAndAdvance(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeekAndAdvance(token.LBRACE) {
+		return nil
+	}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+
+		arm := &ast.MatchArm{Pattern: p.parsePattern()}
+		if arm.Pattern == nil {
+			return nil
+		}
+
+		if p.peekTokenIs(token.IF) {
+			p.nextToken()
+			p.nextToken()
+			arm.Guard = p.parseExpression(LOWEST)
+		}
+
+		if !p.expectPeekAndAdvance(token.FAT_ARROW) {
+			return nil
+		}
+
+		p.nextToken()
+		arm.Body = p.parseExpression(LOWEST)
+
+		exp.Arms = append(exp.Arms, arm)
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeekAndAdvance(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeekAndAdvance(token.RBRACE) {
+		return nil
+	}
+	exp.EndToken = p.curToken
+
+	p.checkUnreachableArms(exp)
+
+	return exp
+}
+
+func (p *Parser) parseSpawnExpression() ast.Expression {
+	exp := &ast.SpawnExpression{Token: p.curToken}
+
+	p.nextToken()
+	exp.Value = p.parseExpression(PREFIX)
+	if exp.Value == nil {
+		return nil
+	}
+
+	return exp
+}
+
+func (p *Parser) parseSelectExpression() ast.Expression {
+	exp := &ast.SelectExpression{Token: p.curToken}
+
+	if !p.expectPeekAndAdvance(token.LBRACE) {
+		return nil
+	}
+
+	hasDefault := false
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+
+		c := p.parseSelectCase()
+		if c == nil {
+			return nil
+		}
+		if c.IsDefault() {
+			if hasDefault {
+				p.addError(c.Token.Pos(), "duplicate default case in select at %s", c.Token.Pos())
+			}
+			hasDefault = true
+		}
+
+		exp.Cases = append(exp.Cases, c)
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeekAndAdvance(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeekAndAdvance(token.RBRACE) {
+		return nil
+	}
+	exp.EndToken = p.curToken
+
+	return exp
+}
+
+func (p *Parser) parseSelectCase() *ast.SelectCase {
+	c := &ast.SelectCase{Token: p.curToken}
+
+	if p.curTokenIs(token.LET) {
+		if !p.expectPeekAndAdvance(token.IDENT) {
+			return nil
+		}
+		c.Binding = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		if !p.expectPeekAndAdvance(token.ASSIGN) {
+			return nil
+		}
+		p.nextToken()
+	}
+
+	isDefault := c.Binding == nil && p.curTokenIs(token.IDENT) && p.curToken.Literal == "_"
+	if !isDefault {
+		call, ok := p.parseExpression(LOWEST).(*ast.CallExpression)
+		if !ok || !isSelectCall(call) {
+			p.addError(c.Token.Pos(), "select case at %s must be recv(channel) or send(channel, value)", c.Token.Pos())
+			return nil
+		}
+		c.Call = call
+		if c.Binding != nil && c.IsSend() {
+			p.addError(c.Token.Pos(), "send in select case at %s can't be bound", c.Token.Pos())
+		}
+	}
+
+	if !p.expectPeekAndAdvance(token.FAT_ARROW) {
+		return nil
+	}
+
+	p.nextToken()
+	c.Body = p.parseExpression(LOWEST)
+
+	return c
+}
+
+func isSelectCall(call *ast.CallExpression) bool {
+	ident, ok := call.Function.(*ast.Identifier)
+	if !ok {
+		return false
+	}
+	switch ident.Value {
+	case "recv":
+		return len(call.Arguments) == 1
+	case "send":
+		return len(call.Arguments) == 2
+	default:
+		return false
+	}
+}
+
+// checkUnreachableArms reports arms which can never be taken, because
+// an earlier arm without a guard matches every value, or the same literal.
+func (p *Parser) checkUnreachableArms(exp *ast.MatchExpression) {
+	var catchAll *ast.MatchArm
+	literals := map[string]bool{}
+
+	for _, arm := range exp.Arms {
+		if catchAll != nil {
+			p.addError(arm.Pattern.Pos(), "unreachable match arm at %s: pattern %s at %s matches every value",
+				arm.Pattern.Pos(), catchAll.Pattern, catchAll.Pattern.Pos())
+			continue
+		}
+
+		if arm.Guard != nil {
+			continue
+		}
+
+		switch pattern := arm.Pattern.(type) {
+		case *ast.WildcardPattern, *ast.BindingPattern:
+			catchAll = arm
+		case *ast.LiteralPattern:
+			key := literalKey(pattern.Value)
+			if literals[key] {
+				p.addError(arm.Pattern.Pos(), "unreachable match arm at %s: pattern %s is already matched",
+					arm.Pattern.Pos(), pattern)
+			}
+			literals[key] = true
+		}
+	}
+}
+
+// literalKey identifies the value of a literal pattern, so that 1 and "1"
+// are different, but -1 and - 1 are the same.
+func literalKey(e ast.Expression) string {
+	switch e := e.(type) {
+	case *ast.StringLiteral:
+		return "string:" + e.Value
+	case *ast.PrefixExpression:
+		return "int:-" + e.Right.String()
+	default:
+		return "literal:" + e.String()
+	}
+}
+
+// parsePattern parses a pattern of a match arm starting at the current token.
+func (p *Parser) parsePattern() ast.Pattern {
+	switch p.curToken.Type {
+	case token.INT:
+		return &ast.LiteralPattern{Value: p.parseIntegerLiteral()}
+	case token.MINUS:
+		exp := &ast.PrefixExpression{Token: p.curToken, Operator: p.curToken.Literal}
+		if !p.expectPeekAndAdvance(token.INT) {
+			return nil
+		}
+		exp.Right = p.parseIntegerLiteral()
+		return &ast.LiteralPattern{Value: exp}
+	case token.STRING:
+		return &ast.LiteralPattern{Value: p.parseStringLiteral()}
+	case token.TRUE, token.FALSE:
+		return &ast.LiteralPattern{Value: p.parseBoolean()}
+	case token.IDENT:
+		if p.curToken.Literal == "_" {
+			return &ast.WildcardPattern{Token: p.curToken}
+		}
+		return &ast.BindingPattern{Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}}
+	case token.LBRACKET:
+		return p.parseArrayPattern()
+	case token.LBRACE:
+		return p.parseHashPattern()
+	default:
+		p.addError(p.curToken.Pos(), "unexpected %s in pattern", p.curToken.Type)
+		return nil
+	}
+}
+
+func (p *Parser) parseArrayPattern() ast.Pattern {
+	pattern := &ast.ArrayPattern{Token: p.curToken}
+
+	for !p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+
+		if p.curTokenIs(token.ELLIPSIS) {
+			ellipsis := p.curToken
+			pattern.HasRest = true
+			if p.peekTokenIs(token.IDENT) {
+				p.nextToken()
+				if p.curToken.Literal != "_" {
+					pattern.Rest = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+				}
+			}
+			if p.peekTokenIs(token.COMMA) {
+				p.nextToken()
+			}
+			if !p.peekTokenIs(token.RBRACKET) {
+				p.addError(ellipsis.Pos(), "rest pattern at %s must be the last element", ellipsis.Pos())
+				return nil
+			}
+			break
+		}
+
+		el := p.parsePattern()
+		if el == nil {
+			return nil
+		}
+		pattern.Elements = append(pattern.Elements, el)
+
+		if !p.peekTokenIs(token.RBRACKET) && !p.expectPeekAndAdvance(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeekAndAdvance(token.RBRACKET) {
+		return nil
+	}
+
+	return pattern
+}
+
+func (p *Parser) parseHashPattern() ast.Pattern {
+	pattern := &ast.HashPattern{Token: p.curToken}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+
+		key, ok := p.parsePattern().(*ast.LiteralPattern)
+		if !ok {
+			p.addError(p.curToken.Pos(), "hash pattern key at %s must be a literal", p.curToken.Pos())
+			return nil
+		}
+
+		if !p.expectPeekAndAdvance(token.COLON) {
+			return nil
+		}
+
+		p.nextToken()
+		value := p.parsePattern()
+		if value == nil {
+			return nil
+		}
+
+		pattern.Pairs = append(pattern.Pairs, &ast.HashPatternPair{Key: key.Value, Value: value})
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeekAndAdvance(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeekAndAdvance(token.RBRACE) {
+		return nil
+	}
+
+	return pattern
+}
+
+func (p *Parser) parseThrowStatement() *ast.ThrowStatement {
+	stmt := &ast.ThrowStatement{Token: p.curToken}
+
+	p.nextToken()
+
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseYieldStatement() *ast.YieldStatement {
+	stmt := &ast.YieldStatement{Token: p.curToken}
+
+	if p.functionDepth == 0 {
+		p.addError(stmt.Pos(), "yield outside function at %s", stmt.Pos())
+	}
+
+	p.nextToken()
+
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseTryExpression() ast.Expression {
+	exp := &ast.TryExpression{Token: p.curToken}
+
+	if !p.expectPeekAndAdvance(token.LBRACE) {
+		return nil
+	}
+
+	exp.Body = p.parseBlockStatement()
+
+	if p.peekTokenIs(token.CATCH) {
+		p.nextToken()
+
+		if p.peekTokenIs(token.LPAREN) {
+			p.nextToken()
+			if !p.expectPeekAndAdvance(token.IDENT) {
+				return nil
+			}
+			exp.CatchParameter = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			if !p.expectPeekAndAdvance(token.RPAREN) {
+				return nil
+			}
+		}
+
+		if !p.expectPeekAndAdvance(token.LBRACE) {
+			return nil
+		}
+
+		exp.Catch = p.parseBlockStatement()
+	}
+
+	if p.peekTokenIs(token.FINALLY) {
+		p.nextToken()
+
+		if !p.expectPeekAndAdvance(token.LBRACE) {
+			return nil
+		}
+
+		exp.Finally = p.parseBlockStatement()
+	}
+
+	if exp.Catch == nil && exp.Finally == nil {
+		p.addError(exp.Pos(), "try at %s without catch or finally", exp.Pos())
+		return nil
+	}
+
+	return exp
+}
+
+func (p *Parser) parseStructStatement() *ast.StructStatement {
+	stmt := &ast.StructStatement{Token: p.curToken}
+
+	if !p.expectPeekAndAdvance(token.IDENT) {
+		return nil
+	}
+
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeekAndAdvance(token.LBRACE) {
+		return nil
+	}
+
+	members := map[string]bool{}
+	addMember := func(name *ast.Identifier) bool {
+		if members[name.Value] {
+			p.addError(name.Pos(), "duplicate member %s in struct %s at %s",
+				name.Value, stmt.Name.Value, name.Pos())
+			return false
+		}
+		members[name.Value] = true
+		return true
+	}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+
+		switch p.curToken.Type {
+		case token.IDENT:
+			field := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			if !addMember(field) {
+				return nil
+			}
+			stmt.Fields = append(stmt.Fields, field)
+		case token.FUNCTION:
+			method := p.parseStructMethod(stmt.Name.Value)
+			if method == nil || !addMember(method.Name) {
+				return nil
+			}
+			stmt.Methods = append(stmt.Methods, method)
+		default:
+			p.addError(p.curToken.Pos(), "unexpected %s in struct %s at %s",
+				p.curToken.Type, stmt.Name.Value, p.curToken.Pos())
+			return nil
+		}
+
+		if p.peekTokenIs(token.COMMA) || p.peekTokenIs(token.SEMICOLON) {
+			p.nextToken()
+		}
+	}
+
+	if !p.expectPeekAndAdvance(token.RBRACE) {
+		return nil
+	}
+	stmt.EndToken = p.curToken
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseStructMethod(structName string) *ast.StructMethod {
+	lit := &ast.FunctionLiteral{Token: p.curToken}
+
+	if !p.expectPeekAndAdvance(token.IDENT) {
+		return nil
+	}
+
+	method := &ast.StructMethod{Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}, Function: lit}
+
+	if !p.expectPeekAndAdvance(token.LPAREN) {
+		return nil
+	}
+
+	lit.Parameters = p.parseFunctionParameters()
+	if lit.Parameters == nil {
+		return nil
+	}
+	if len(lit.Parameters) == 0 || lit.Parameters[0].Value != "self" {
+		p.addError(method.Name.Pos(), "method %s of struct %s at %s must take self as its first parameter",
+			method.Name.Value, structName, method.Name.Pos())
+		return nil
+	}
+
+	if !p.expectPeekAndAdvance(token.LBRACE) {
+		return nil
+	}
+
+	p.functionDepth++
+	lit.Body = p.parseBlockStatement()
+	p.functionDepth--
+
+	return method
+}
+
+func (p *Parser) parseMemberExpression(object ast.Expression) ast.Expression {
+	exp := &ast.MemberExpression{Token: p.curToken, Object: object}
+
+	if !p.expectPeekAndAdvance(token.IDENT) {
+		return nil
+	}
+
+	exp.Member = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	return exp
+}
+
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	exp := &ast.AssignExpression{Token: p.curToken, Target: target}
+
+	if _, ok := target.(*ast.MemberExpression); !ok {
+		p.addError(exp.Pos(), "cannot assign to %s at %s", target, exp.Pos())
+		return nil
+	}
+
+	p.nextToken()
+
+	// assignment is right associative, a.x = b.y = 1 assigns both
+	exp.Value = p.parseExpression(LOWEST)
+
+	return exp
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/wmolicki/go-monkey/ast"
@@ -786,6 +787,60 @@ func TestParsingArrayLiterals(t *testing.T) {
 	testInfixExpression(t, array.Elements[3], 3, "+", 3)
 }
 
+func TestParsingSetLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"#{}", "#{}"},
+		{"#{1, 2 * 3, a}", "#{1, (2 * 3), a}"},
+		{"#{1,\n 2,\n}", "#{1, 2}"},
+		{"#{#{1}, [2]}", "#{#{1}, [2]}"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		set, ok := stmt.Expression.(*ast.SetLiteral)
+		if !ok {
+			t.Fatalf("exp not ast.SetLiteral. got=%T", stmt.Expression)
+		}
+		if set.String() != tt.expected {
+			t.Errorf("wrong string. expected=%q, got=%q", tt.expected, set.String())
+		}
+	}
+}
+
+func TestParsingArrayLiteralTrailingComma(t *testing.T) {
+	input := `[
+	1,
+	2,
+]`
+
+	l := lexer.New(input)
+	p := New(l)
+
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	array, ok := stmt.Expression.(*ast.ArrayLiteral)
+	if !ok {
+		t.Fatalf("exp not ast.ArrayLiteral. got=%T", stmt.Expression)
+	}
+	if len(array.Elements) != 2 {
+		t.Fatalf("len(array.Elements) not 2. got=%d", len(array.Elements))
+	}
+	if array.Pos().Line != 1 || array.Elements[1].Pos().Line != 3 {
+		t.Errorf("wrong positions, array at %s, second element at %s",
+			array.Pos(), array.Elements[1].Pos())
+	}
+}
+
 func TestParsingIndexExpressions(t *testing.T) {
 	input := "myArray[1 + 1]"
 	l := lexer.New(input)
@@ -1047,3 +1102,528 @@ func TestForExpression(t *testing.T) {
 	}
 
 }
+
+func TestMacroLiteralParsing(t *testing.T) {
+	input := `macro(x, y) { x + y; }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("statement is not ast.ExpressionStatement. got=%T",
+			program.Statements[0])
+	}
+
+	macro, ok := stmt.Expression.(*ast.MacroLiteral)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.MacroLiteral. got=%T",
+			stmt.Expression)
+	}
+
+	if len(macro.Parameters) != 2 {
+		t.Fatalf("macro literal parameters wrong. want 2, got=%d\n",
+			len(macro.Parameters))
+	}
+
+	testLiteralExpression(t, macro.Parameters[0], "x")
+	testLiteralExpression(t, macro.Parameters[1], "y")
+
+	if len(macro.Body.Statements) != 1 {
+		t.Fatalf("macro.Body.Statements has not 1 statements. got=%d\n",
+			len(macro.Body.Statements))
+	}
+
+	bodyStmt, ok := macro.Body.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("macro body stmt is not ast.ExpressionStatement. got=%T",
+			macro.Body.Statements[0])
+	}
+
+	testInfixExpression(t, bodyStmt.Expression, "x", "+", "y")
+}
+
+func TestMatchExpressionParsing(t *testing.T) {
+	input := `match (x) {
+	0 => "zero",
+	-1 => "minus one",
+	[a, b, ...rest] => a,
+	[first, ...] => first,
+	{"name": n, "tags": []} => n,
+	n if n > 10 => "big",
+	_ => "other",
+}`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("statement is not ast.ExpressionStatement. got=%T",
+			program.Statements[0])
+	}
+
+	match, ok := stmt.Expression.(*ast.MatchExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.MatchExpression. got=%T",
+			stmt.Expression)
+	}
+
+	testIdentifier(t, match.Subject, "x")
+
+	expectedArms := []string{
+		`0 => zero`,
+		`(-1) => minus one`,
+		`[a, b, ...rest] => a`,
+		`[first, ...] => first`,
+		`{name: n, tags: []} => n`,
+		`n if (n > 10) => big`,
+		`_ => other`,
+	}
+
+	if len(match.Arms) != len(expectedArms) {
+		t.Fatalf("match.Arms has wrong length. want %d, got=%d",
+			len(expectedArms), len(match.Arms))
+	}
+
+	for i, expected := range expectedArms {
+		if got := match.Arms[i].String(); got != expected {
+			t.Errorf("arm %d wrong. want %q, got=%q", i, expected, got)
+		}
+	}
+
+	array, ok := match.Arms[2].Pattern.(*ast.ArrayPattern)
+	if !ok {
+		t.Fatalf("arm 2 pattern is not ast.ArrayPattern. got=%T", match.Arms[2].Pattern)
+	}
+	if !array.HasRest || array.Rest == nil || array.Rest.Value != "rest" {
+		t.Errorf("array pattern rest wrong. got HasRest=%v Rest=%v", array.HasRest, array.Rest)
+	}
+
+	if _, ok := match.Arms[6].Pattern.(*ast.WildcardPattern); !ok {
+		t.Errorf("arm 6 pattern is not ast.WildcardPattern. got=%T", match.Arms[6].Pattern)
+	}
+}
+
+func TestMatchExpressionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"match (x) { _ => 1, 2 => 2 }",
+			"unreachable match arm at 1:21: pattern _ at 1:13 matches every value",
+		},
+		{
+			"match (x) { n => 1, _ => 2 }",
+			"unreachable match arm at 1:21: pattern n at 1:13 matches every value",
+		},
+		{
+			`match (x) { "a" => 1, "a" => 2, _ => 3 }`,
+			`unreachable match arm at 1:23: pattern a is already matched`,
+		},
+		{
+			"match (x) { [...rest, a] => 1 }",
+			"rest pattern at 1:14 must be the last element",
+		},
+		{
+			"match (x) { a + 1 => 1 }",
+			"expected next token to be =>, got + instead",
+		},
+		{
+			"match (x) { {x: 1} => 1 }",
+			"hash pattern key at 1:14 must be a literal",
+		},
+		{
+			"match (x) { fn => 1 }",
+			"unexpected FUNCTION in pattern",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("%q: expected parser errors, got none", tt.input)
+			continue
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("%q: wrong error. want %q, got=%q", tt.input, tt.expected, errors[0])
+		}
+	}
+}
+
+func TestMatchGuardedArmsAreReachable(t *testing.T) {
+	input := `match (x) { n if n > 1 => 1, 1 => 2, 1 if true => 3, n => 4 }`
+
+	l := lexer.New(input)
+	p := New(l)
+	p.ParseProgram()
+	checkParserErrors(t, p)
+}
+
+func TestThrowStatement(t *testing.T) {
+	input := `throw error("boom");`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ThrowStatement)
+	if !ok {
+		t.Fatalf("statement is not ast.ThrowStatement. got=%T",
+			program.Statements[0])
+	}
+
+	if stmt.Value.String() != "error(boom)" {
+		t.Errorf("stmt.Value wrong. got=%q", stmt.Value.String())
+	}
+}
+
+func TestYieldStatement(t *testing.T) {
+	input := `fn() { yield 1 + 2; yield x }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	fn := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	if len(fn.Body.Statements) != 2 {
+		t.Fatalf("body does not contain 2 statements. got=%d", len(fn.Body.Statements))
+	}
+
+	for i, expected := range []string{"(1 + 2)", "x"} {
+		stmt, ok := fn.Body.Statements[i].(*ast.YieldStatement)
+		if !ok {
+			t.Fatalf("statement is not ast.YieldStatement. got=%T", fn.Body.Statements[i])
+		}
+		if stmt.Value.String() != expected {
+			t.Errorf("stmt.Value wrong. want %q, got=%q", expected, stmt.Value.String())
+		}
+	}
+}
+
+func TestYieldInMethod(t *testing.T) {
+	input := `struct S { n fn gen(self) { yield self.n } }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	st := program.Statements[0].(*ast.StructStatement)
+	body := st.Methods[0].Function.Body
+	if _, ok := body.Statements[0].(*ast.YieldStatement); !ok {
+		t.Fatalf("statement is not ast.YieldStatement. got=%T", body.Statements[0])
+	}
+}
+
+func TestYieldOutsideFunction(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"yield 1", "yield outside function at 1:1"},
+		{"fn() { 1 }; if (true) { yield 2 }", "yield outside function at 1:25"},
+		{"macro() { yield 1 }", "yield outside function at 1:11"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("%q: expected parser errors, got none", tt.input)
+			continue
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("%q: wrong error. want %q, got=%q", tt.input, tt.expected, errors[0])
+		}
+	}
+}
+
+func TestTryExpression(t *testing.T) {
+	tests := []struct {
+		input      string
+		hasParam   bool
+		hasCatch   bool
+		hasFinally bool
+	}{
+		{"try { x } catch (e) { y } finally { z }", true, true, true},
+		{"try { x } catch (e) { y }", true, true, false},
+		{"try { x } catch { y }", false, true, false},
+		{"try { x } finally { z }", false, false, true},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("statement is not ast.ExpressionStatement. got=%T",
+				program.Statements[0])
+		}
+
+		exp, ok := stmt.Expression.(*ast.TryExpression)
+		if !ok {
+			t.Fatalf("stmt.Expression is not ast.TryExpression. got=%T",
+				stmt.Expression)
+		}
+
+		if exp.Body.String() != "x" {
+			t.Errorf("%q: body wrong. got=%q", tt.input, exp.Body.String())
+		}
+		if (exp.CatchParameter != nil) != tt.hasParam {
+			t.Errorf("%q: catch parameter wrong. got=%v", tt.input, exp.CatchParameter)
+		}
+		if exp.CatchParameter != nil && exp.CatchParameter.Value != "e" {
+			t.Errorf("%q: catch parameter is not e. got=%q", tt.input, exp.CatchParameter.Value)
+		}
+		if (exp.Catch != nil) != tt.hasCatch {
+			t.Errorf("%q: catch wrong. got=%v", tt.input, exp.Catch)
+		}
+		if (exp.Finally != nil) != tt.hasFinally {
+			t.Errorf("%q: finally wrong. got=%v", tt.input, exp.Finally)
+		}
+	}
+}
+
+func TestTryExpressionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"try { x }", "try at 1:1 without catch or finally"},
+		{"try { x } catch (1) { y }", "expected next token to be IDENT, got INT instead"},
+		{"try x catch { y }", "expected next token to be {, got IDENT instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("%q: expected parser errors, got none", tt.input)
+			continue
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("%q: wrong error. want %q, got=%q", tt.input, tt.expected, errors[0])
+		}
+	}
+}
+
+func TestStructStatement(t *testing.T) {
+	input := `struct Point {
+	x, y
+	fn add(self, other) { self.x + other.x }
+	fn norm(self) { self.x }
+}`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.StructStatement)
+	if !ok {
+		t.Fatalf("statement is not ast.StructStatement. got=%T",
+			program.Statements[0])
+	}
+
+	if stmt.Name.Value != "Point" {
+		t.Errorf("stmt.Name wrong. got=%q", stmt.Name.Value)
+	}
+	if len(stmt.Fields) != 2 {
+		t.Fatalf("stmt.Fields wrong length. got=%d", len(stmt.Fields))
+	}
+	testLiteralExpression(t, stmt.Fields[0], "x")
+	testLiteralExpression(t, stmt.Fields[1], "y")
+
+	if len(stmt.Methods) != 2 {
+		t.Fatalf("stmt.Methods wrong length. got=%d", len(stmt.Methods))
+	}
+	if stmt.Methods[0].Name.Value != "add" || len(stmt.Methods[0].Function.Parameters) != 2 {
+		t.Errorf("first method wrong. got=%q", stmt.Methods[0].Function)
+	}
+	if stmt.Methods[1].Name.Value != "norm" || stmt.Methods[1].Function.Body.String() != "(self.x)" {
+		t.Errorf("second method wrong. got=%q", stmt.Methods[1].Function)
+	}
+}
+
+func TestMemberAndAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a.b", "(a.b)"},
+		{"a.b.c", "((a.b).c)"},
+		{"a.b(1).c", "((a.b)(1).c)"},
+		{"a.b[0]", "((a.b)[0])"},
+		{"-a.b", "(-(a.b))"},
+		{"a.b + c.d * 2", "((a.b) + ((c.d) * 2))"},
+		{"a.b = 1 + 2", "((a.b) = (1 + 2))"},
+		{"a.b = c.d = 3", "((a.b) = ((c.d) = 3))"},
+		{"a.b = x == y", "((a.b) = (x == y))"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestStructErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"struct P { x, x }", "duplicate member x in struct P at 1:15"},
+		{"struct P { x fn x(self) {} }", "duplicate member x in struct P at 1:17"},
+		{"struct P { fn m() {} }", "method m of struct P at 1:15 must take self as its first parameter"},
+		{"struct P { fn m(a, self) {} }", "method m of struct P at 1:15 must take self as its first parameter"},
+		{"struct P { 1 }", "unexpected INT in struct P at 1:12"},
+		{"struct { x }", "expected next token to be IDENT, got { instead"},
+		{"a = 1", "cannot assign to a at 1:3"},
+		{"a.1", "expected next token to be IDENT, got INT instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("%q: expected parser errors, got none", tt.input)
+			continue
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("%q: wrong error. want %q, got=%q", tt.input, tt.expected, errors[0])
+		}
+	}
+}
+
+func TestSpawnAndSelectExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"spawn f(1, 2)", "spawn f(1, 2)"},
+		{"spawn f", "spawn f"},
+		{"spawn a.b(c)", "spawn (a.b)(c)"},
+		{"recv(spawn f()) + 1", "(recv(spawn f()) + 1)"},
+		{"select { let v = recv(c) => v, send(d, 1) => 2 }", "select { let v = recv(c) => v, send(d, 1) => 2 }"},
+		{"select { recv(c) => 1, _ => 2 }", "select { recv(c) => 1, _ => 2 }"},
+		{"select { recv(c) => 1, }", "select { recv(c) => 1 }"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestSelectExpressionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"select { f(c) => 1 }", "select case at 1:10 must be recv(channel) or send(channel, value)"},
+		{"select { recv(c, 1) => 1 }", "select case at 1:10 must be recv(channel) or send(channel, value)"},
+		{"select { c => 1 }", "select case at 1:10 must be recv(channel) or send(channel, value)"},
+		{"select { let v = send(c, 1) => v }", "send in select case at 1:10 can't be bound"},
+		{"select { _ => 1, _ => 2 }", "duplicate default case in select at 1:18"},
+		{"select { recv(c) 1 }", "expected next token to be =>, got INT instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("%q: expected parser errors, got none", tt.input)
+			continue
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("%q: wrong error. want %q, got=%q", tt.input, tt.expected, errors[0])
+		}
+	}
+}
+
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let x = 1;", nil},
+		{"let = 1", []string{"1:5", "1:5"}},
+		{"let x = ;\nfoo(", []string{"1:9", "2:5", "2:6"}},
+		{"1 = 2", []string{"1:3"}},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		var got []string
+		for _, pos := range p.ErrorPositions() {
+			got = append(got, pos.String())
+		}
+		if len(got) != len(p.Errors()) {
+			t.Errorf("%q: got %d positions for %d errors", tt.input, len(got), len(p.Errors()))
+		}
+		if strings.Join(got, " ") != strings.Join(tt.expected, " ") {
+			t.Errorf("%q: wrong positions.\nwant=%v\ngot= %v (%q)", tt.input, tt.expected, got, p.Errors())
+		}
+	}
+}
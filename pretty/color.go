@@ -0,0 +1,48 @@
+// Package pretty prints Monkey source and values for people: it
+// highlights tokens of source code and lays out nested values over
+// several lines when they don't fit on one.
+package pretty
+
+import (
+	"github.com/wmolicki/go-monkey/object"
+)
+
+// Color is an ANSI escape sequence setting the terminal colour.
+type Color string
+
+const (
+	None    Color = ""
+	Reset   Color = "\x1b[0m"
+	Bold    Color = "\x1b[1m"
+	Dim     Color = "\x1b[2m"
+	Red     Color = "\x1b[31m"
+	Green   Color = "\x1b[32m"
+	Yellow  Color = "\x1b[33m"
+	Blue    Color = "\x1b[34m"
+	Magenta Color = "\x1b[35m"
+	Cyan    Color = "\x1b[36m"
+)
+
+// paint wraps s in color, unless color is None.
+func paint(s string, color Color) string {
+	if color == None || s == "" {
+		return s
+	}
+	return string(color) + s + string(Reset)
+}
+
+// objectColors are colours of values by their type.
+var objectColors = map[object.ObjectType]Color{
+	object.INTEGER_OBJ:      Cyan,
+	object.STRING_OBJ:       Green,
+	object.BOOLEAN_OBJ:      Yellow,
+	object.NULL_OBJ:         Dim,
+	object.ERROR_OBJ:        Red,
+	object.ERROR_VALUE_OBJ:  Red,
+	object.FUNCTION_OBJ:     Blue,
+	object.BUILTIN_OBJ:      Blue,
+	object.BOUND_METHOD_OBJ: Blue,
+	object.MACRO_OBJ:        Magenta,
+	object.STRUCT_TYPE_OBJ:  Magenta,
+	object.QUOTE_OBJ:        Magenta,
+}
@@ -0,0 +1,91 @@
+package pretty
+
+import (
+	"strings"
+
+	"github.com/wmolicki/go-monkey/lexer"
+	"github.com/wmolicki/go-monkey/token"
+)
+
+// tokenColor returns the colour of tok in highlighted source.
+func tokenColor(tok token.Token) Color {
+	switch tok.Type {
+	case token.STRING:
+		return Green
+	case token.INT:
+		return Cyan
+	case token.TRUE, token.FALSE:
+		return Yellow
+	case token.COMMENT:
+		return Dim
+	case token.ILLEGAL:
+		return Red
+	case token.IDENT:
+		return None
+	}
+	if token.LookupIdentifier(tok.Literal) != token.IDENT {
+		return Magenta
+	}
+	return None
+}
+
+// Highlight returns src with tokens coloured by their kind. Apart from
+// colours, the result is the same as src.
+func Highlight(src string) string {
+	// offsets where lines start, as tokens have line and byte column
+	lineStarts := []int{0}
+	for i := 0; i < len(src); i++ {
+		if src[i] == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+
+	l := lexer.New(src)
+	var tokens []token.Token
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		tokens = append(tokens, tok)
+	}
+	tokens = mergeComments(tokens, l.Comments())
+
+	var out strings.Builder
+	written := 0
+	for _, tok := range tokens {
+		if tok.Line < 1 || tok.Line > len(lineStarts) {
+			continue
+		}
+		start := lineStarts[tok.Line-1] + tok.Column - 1
+		end := start + len(tok.Literal)
+		if tok.Type == token.STRING {
+			// quotes are not part of the literal, the closing one
+			// is missing in unterminated strings
+			end = start + 1 + len(tok.Literal)
+			if end < len(src) && src[end] == '"' {
+				end++
+			}
+		}
+		if start < written || end > len(src) {
+			continue
+		}
+
+		out.WriteString(src[written:start])
+		out.WriteString(paint(src[start:end], tokenColor(tok)))
+		written = end
+	}
+	out.WriteString(src[written:])
+
+	return out.String()
+}
+
+// mergeComments returns tokens and comments in source order.
+func mergeComments(tokens, comments []token.Token) []token.Token {
+	merged := make([]token.Token, 0, len(tokens)+len(comments))
+	for len(tokens) > 0 && len(comments) > 0 {
+		if comments[0].Pos().Before(tokens[0].Pos()) {
+			merged, comments = append(merged, comments[0]), comments[1:]
+		} else {
+			merged, tokens = append(merged, tokens[0]), tokens[1:]
+		}
+	}
+	merged = append(merged, tokens...)
+	return append(merged, comments...)
+}
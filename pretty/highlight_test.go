@@ -0,0 +1,23 @@
+package pretty
+
+import "testing"
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = 1;", "\x1b[35mlet\x1b[0m x = \x1b[36m1\x1b[0m;"},
+		{`puts("hi")`, "puts(\x1b[32m\"hi\"\x1b[0m)"},
+		{`"open`, "\x1b[32m\"open\x1b[0m"},
+		{"true // yes\n  false", "\x1b[33mtrue\x1b[0m \x1b[2m// yes\x1b[0m\n  \x1b[33mfalse\x1b[0m"},
+		{"a @ b", "a \x1b[31m@\x1b[0m b"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Highlight(tt.input); got != tt.expected {
+			t.Errorf("Highlight(%q)\nwant=%q\ngot= %q", tt.input, tt.expected, got)
+		}
+	}
+}
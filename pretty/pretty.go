@@ -0,0 +1,187 @@
+package pretty
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/wmolicki/go-monkey/object"
+)
+
+// Printer lays out values, collections which don't fit in Width are
+// printed with one element per line.
+type Printer struct {
+	// Width is the maximum width of a line, 0 means no limit.
+	Width int
+	// Indent is added for each level of nesting.
+	Indent string
+	// MaxDepth is the number of nested collections printed, deeper
+	// ones are elided. 0 means no limit.
+	MaxDepth int
+	// MaxItems is the number of elements printed for each collection,
+	// the rest is elided. 0 means no limit.
+	MaxItems int
+	// Color enables colours per object type.
+	Color bool
+}
+
+// NewPrinter returns a printer with default limits and without colours.
+func NewPrinter() *Printer {
+	return &Printer{Width: 80, Indent: "    ", MaxDepth: 6, MaxItems: 100}
+}
+
+// Sprint returns obj laid out by p. Strings are quoted only inside of
+// collections, like in Inspect.
+func (p *Printer) Sprint(obj object.Object) string {
+	if s, ok := obj.(*object.String); ok {
+		return paint(s.Value, p.color(s))
+	}
+	return p.layout(obj, 0, 0, map[*object.StructInstance]bool{})
+}
+
+func (p *Printer) color(obj object.Object) Color {
+	if !p.Color {
+		return None
+	}
+	return objectColors[obj.Type()]
+}
+
+// collection is a value printed as a list of elements between brackets.
+type collection struct {
+	open, close string
+	elements    []object.Object
+	// keys are printed before elements of hashes and structs
+	keys []string
+}
+
+func (p *Printer) collection(obj object.Object) (collection, bool) {
+	switch obj := obj.(type) {
+	case *object.Array:
+		return collection{open: "[", close: "]", elements: obj.Elements()}, true
+	case *object.Set:
+		return collection{open: "#{", close: "}", elements: obj.Elements()}, true
+	case *object.Hash:
+		c := collection{open: "{", close: "}"}
+		for _, pair := range obj.Pairs() {
+			c.keys = append(c.keys, p.flat(pair.Key, 1, map[*object.StructInstance]bool{}))
+			c.elements = append(c.elements, pair.Value)
+		}
+		return c, true
+	case *object.StructInstance:
+		c := collection{open: obj.StructType.Name + "{", close: "}"}
+		for _, name := range obj.StructType.Fields {
+			value, _ := obj.Get(name)
+			c.keys = append(c.keys, name)
+			c.elements = append(c.elements, value)
+		}
+		return c, true
+	default:
+		return collection{}, false
+	}
+}
+
+// flat returns obj on a single line, depth is its level of nesting.
+// visiting holds struct instances being printed, a field referring back
+// to one of them is elided, so cycles terminate.
+func (p *Printer) flat(obj object.Object, depth int, visiting map[*object.StructInstance]bool) string {
+	c, ok := p.collection(obj)
+	if !ok {
+		return p.scalar(obj, depth)
+	}
+	if (p.MaxDepth > 0 && depth >= p.MaxDepth && len(c.elements) > 0) || isVisiting(obj, visiting) {
+		return c.open + "..." + c.close
+	}
+	if si, ok := obj.(*object.StructInstance); ok {
+		visiting[si] = true
+		defer delete(visiting, si)
+	}
+
+	items := []string{}
+	for i, el := range p.shown(c.elements) {
+		item := p.flat(el, depth+1, visiting)
+		if c.keys != nil {
+			item = c.keys[i] + ": " + item
+		}
+		items = append(items, item)
+	}
+	if more := p.elided(c.elements); more != "" {
+		items = append(items, more)
+	}
+	return c.open + strings.Join(items, ", ") + c.close
+}
+
+// layout returns obj starting at column, broken across lines if needed.
+func (p *Printer) layout(obj object.Object, depth, column int, visiting map[*object.StructInstance]bool) string {
+	flat := p.flat(obj, depth, visiting)
+	c, ok := p.collection(obj)
+	if !ok || p.Width <= 0 || column+visibleWidth(flat) <= p.Width ||
+		(p.MaxDepth > 0 && depth >= p.MaxDepth) || len(c.elements) == 0 || isVisiting(obj, visiting) {
+		return flat
+	}
+	if si, ok := obj.(*object.StructInstance); ok {
+		visiting[si] = true
+		defer delete(visiting, si)
+	}
+
+	indent := strings.Repeat(p.Indent, depth+1)
+	var out strings.Builder
+	out.WriteString(c.open + "\n")
+	for i, el := range p.shown(c.elements) {
+		prefix := indent
+		if c.keys != nil {
+			prefix += c.keys[i] + ": "
+		}
+		out.WriteString(prefix)
+		out.WriteString(p.layout(el, depth+1, visibleWidth(prefix), visiting))
+		out.WriteString(",\n")
+	}
+	if more := p.elided(c.elements); more != "" {
+		out.WriteString(indent + more + "\n")
+	}
+	out.WriteString(strings.Repeat(p.Indent, depth) + c.close)
+	return out.String()
+}
+
+func isVisiting(obj object.Object, visiting map[*object.StructInstance]bool) bool {
+	si, ok := obj.(*object.StructInstance)
+	return ok && visiting[si]
+}
+
+func (p *Printer) scalar(obj object.Object, depth int) string {
+	if s, ok := obj.(*object.String); ok && depth > 0 {
+		return paint(strconv.Quote(s.Value), p.color(s))
+	}
+	return paint(obj.Inspect(), p.color(obj))
+}
+
+// shown returns elements which are not elided.
+func (p *Printer) shown(elements []object.Object) []object.Object {
+	if p.MaxItems > 0 && len(elements) > p.MaxItems {
+		return elements[:p.MaxItems]
+	}
+	return elements
+}
+
+// elided returns a note about elements which are not shown, if any.
+func (p *Printer) elided(elements []object.Object) string {
+	if p.MaxItems > 0 && len(elements) > p.MaxItems {
+		return "... " + strconv.Itoa(len(elements)-p.MaxItems) + " more"
+	}
+	return ""
+}
+
+// visibleWidth returns the width of s on a terminal, without colours.
+func visibleWidth(s string) int {
+	width := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\x1b' {
+			for i < len(s) && s[i] != 'm' {
+				i++
+			}
+			continue
+		}
+		if s[i]&0xc0 != 0x80 {
+			width++
+		}
+	}
+	return width
+}
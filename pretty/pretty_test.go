@@ -0,0 +1,91 @@
+package pretty
+
+import (
+	"testing"
+
+	"github.com/wmolicki/go-monkey/object"
+)
+
+func integer(v int64) object.Object { return &object.Integer{Value: v} }
+func str(v string) object.Object    { return &object.String{Value: v} }
+
+func array(elements ...object.Object) object.Object { return object.NewArray(elements) }
+
+func hash(pairs ...object.Object) object.Object {
+	h := object.NewHash()
+	for i := 0; i < len(pairs); i += 2 {
+		h.Set(pairs[i], pairs[i+1])
+	}
+	return h
+}
+
+func TestSprint(t *testing.T) {
+	point := &object.StructType{Name: "Point", Fields: []string{"x", "y"}}
+	numbers := []object.Object{}
+	for i := int64(0); i < 12; i++ {
+		numbers = append(numbers, integer(i))
+	}
+
+	tests := []struct {
+		printer  Printer
+		obj      object.Object
+		expected string
+	}{
+		{Printer{Width: 80, Indent: "  "}, integer(1), "1"},
+		{Printer{Width: 80, Indent: "  "}, str("a"), "a"},
+		{Printer{Width: 80, Indent: "  "}, array(integer(1), str("a")), `[1, "a"]`},
+		{Printer{Width: 80, Indent: "  "}, hash(str("a"), array()), `{"a": []}`},
+		{Printer{Width: 80, Indent: "  "}, object.NewStructInstance(point, []object.Object{integer(1), integer(2)}), "Point{x: 1, y: 2}"},
+		{Printer{Width: 80, Indent: "  "}, array(), "[]"},
+		{Printer{Width: 10, Indent: "  "}, array(integer(100), integer(200), integer(300)), "[\n  100,\n  200,\n  300,\n]"},
+		{
+			Printer{Width: 20, Indent: "  "},
+			hash(str("name"), str("monke"), str("tags"), array(str("a"), str("b"))),
+			"{\n  \"name\": \"monke\",\n  \"tags\": [\"a\", \"b\"],\n}",
+		},
+		{
+			Printer{Width: 16, Indent: "  "},
+			hash(str("tags"), array(str("long"), str("tags"))),
+			"{\n  \"tags\": [\n    \"long\",\n    \"tags\",\n  ],\n}",
+		},
+		{Printer{MaxItems: 10}, object.NewArray(numbers), "[0, 1, 2, 3, 4, 5, 6, 7, 8, 9, ... 2 more]"},
+		{Printer{Width: 10, Indent: " ", MaxItems: 2}, object.NewArray(numbers), "[\n 0,\n 1,\n ... 10 more\n]"},
+		{Printer{MaxDepth: 2}, array(array(array(integer(1))), array(array())), "[[[...]], [[]]]"},
+		{Printer{Width: 80, Color: true}, integer(1), "\x1b[36m1\x1b[0m"},
+		{Printer{Width: 80, Color: true}, array(str("a")), "[\x1b[32m\"a\"\x1b[0m]"},
+		{Printer{Width: 80, Color: true}, object.NewError(object.TypeError, "boom"), "\x1b[31mERROR: boom\x1b[0m"},
+	}
+
+	for i, tt := range tests {
+		if got := tt.printer.Sprint(tt.obj); got != tt.expected {
+			t.Errorf("%d: wrong output.\nwant=%q\ngot= %q", i, tt.expected, got)
+		}
+	}
+}
+
+func TestSprintCycles(t *testing.T) {
+	node := &object.StructType{Name: "N", Fields: []string{"next", "value"}}
+	n := object.NewStructInstance(node, []object.Object{integer(0), integer(1)})
+	n.Set("next", array(n, n))
+
+	tests := []struct {
+		printer  Printer
+		expected string
+	}{
+		{Printer{}, "N{next: [N{...}, N{...}], value: 1}"},
+		{Printer{Width: 30, Indent: "  "}, "N{\n  next: [N{...}, N{...}],\n  value: 1,\n}"},
+	}
+
+	for i, tt := range tests {
+		if got := tt.printer.Sprint(n); got != tt.expected {
+			t.Errorf("%d: wrong output.\nwant=%q\ngot= %q", i, tt.expected, got)
+		}
+	}
+}
+
+func TestColorsDontCountTowardsWidth(t *testing.T) {
+	p := Printer{Width: 10, Indent: "  ", Color: true}
+	if got := p.Sprint(array(integer(1), integer(2))); got != "[\x1b[36m1\x1b[0m, \x1b[36m2\x1b[0m]" {
+		t.Errorf("short array was broken across lines. got=%q", got)
+	}
+}
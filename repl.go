@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/wmolicki/go-monkey/repl"
+)
+
+// runRepl implements `monke repl [-no-color] [-history-size n]`.
+func runRepl(args []string) int {
+	fs := flag.NewFlagSet("repl", flag.ExitOnError)
+	historySize := fs.Int("history-size", 1000, "number of lines kept in the history, 0 disables history")
+	noColor := fs.Bool("no-color", os.Getenv("NO_COLOR") != "", "disable colours")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: monke repl [-no-color] [-history-size n]\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	historyFile, _ := repl.DefaultHistoryFile()
+	code, err := repl.Start(os.Stdin, os.Stdout, repl.Options{
+		HistoryFile: historyFile,
+		HistorySize: *historySize,
+		NoColor:     *noColor,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	return code
+}
@@ -0,0 +1,145 @@
+package repl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wmolicki/go-monkey/astjson"
+	"github.com/wmolicki/go-monkey/lexer"
+	"github.com/wmolicki/go-monkey/object"
+	"github.com/wmolicki/go-monkey/token"
+)
+
+// command is a REPL command such as `:type 1 + 2`, run gets the text
+// following the name of the command.
+type command struct {
+	arg  string
+	help string
+	run  func(s *Session, arg string)
+}
+
+var commands map[string]command
+
+func init() {
+	// help refers to commands, so they can't be initialized together
+	commands = map[string]command{
+		"env":    {"", "list bindings of the session with their types", commandEnv},
+		"type":   {"expr", "evaluate expr and print the type of its value", commandType},
+		"ast":    {"expr", "print the syntax tree of expr", commandAst},
+		"tokens": {"expr", "print the tokens of expr", commandTokens},
+		"load":   {"file", "evaluate a file in the session", commandLoad},
+		"reset":  {"", "remove all bindings of the session", commandReset},
+		"time":   {"expr", "evaluate expr and print how long it took", commandTime},
+		"help":   {"", "print this help", commandHelp},
+	}
+}
+
+func (s *Session) command(line string) {
+	name, arg := line[1:], ""
+	if i := strings.IndexAny(name, " \t"); i >= 0 {
+		name, arg = name[:i], strings.TrimSpace(name[i:])
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(s.out, "unknown command :%s, see :help\n", name)
+		return
+	}
+	if cmd.arg != "" && arg == "" {
+		fmt.Fprintf(s.out, "usage: :%s %s\n", name, cmd.arg)
+		return
+	}
+	cmd.run(s, arg)
+}
+
+func commandEnv(s *Session, _ string) {
+	for _, env := range []*object.Environment{s.env, s.macroEnv} {
+		for _, name := range env.Names() {
+			value, _ := env.Get(name)
+			fmt.Fprintf(s.out, "%s: %s\n", name, value.Type())
+		}
+	}
+}
+
+func commandType(s *Session, arg string) {
+	evaluated := s.eval(arg)
+	switch {
+	case evaluated == nil:
+	case evaluated.Type() == object.ERROR_OBJ:
+		io.WriteString(s.out, evaluated.Inspect()+"\n")
+	default:
+		io.WriteString(s.out, string(evaluated.Type())+"\n")
+	}
+}
+
+func commandAst(s *Session, arg string) {
+	program, ok := s.parse(arg)
+	if !ok {
+		return
+	}
+
+	encoded, err := astjson.Marshal(program)
+	if err != nil {
+		fmt.Fprintf(s.out, "error encoding tree: %v\n", err)
+		return
+	}
+	var out bytes.Buffer
+	json.Indent(&out, encoded, "", "  ")
+	out.WriteString("\n")
+	out.WriteTo(s.out)
+}
+
+func commandTokens(s *Session, arg string) {
+	l := lexer.New(arg)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		fmt.Fprintf(s.out, "%s\t%s\t%q\n", tok.Pos(), tok.Type, tok.Literal)
+	}
+}
+
+func commandLoad(s *Session, arg string) {
+	script, err := os.ReadFile(arg)
+	if err != nil {
+		fmt.Fprintf(s.out, "error reading file: %v\n", err)
+		return
+	}
+
+	evaluated := s.eval(string(script))
+	if evaluated != nil && evaluated.Type() == object.ERROR_OBJ {
+		io.WriteString(s.out, evaluated.Inspect()+"\n")
+	}
+}
+
+func commandReset(s *Session, _ string) {
+	s.reset()
+}
+
+func commandTime(s *Session, arg string) {
+	start := time.Now()
+	evaluated := s.eval(arg)
+	elapsed := time.Since(start)
+
+	if evaluated != nil {
+		s.print(evaluated)
+	}
+	fmt.Fprintf(s.out, "time: %s\n", elapsed)
+}
+
+func commandHelp(s *Session, _ string) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cmd := commands[name]
+		fmt.Fprintf(s.out, "  %-14s %s\n", strings.TrimSpace(":"+name+" "+cmd.arg), cmd.help)
+	}
+	fmt.Fprintf(s.out, "  %-14s %s\n", "\\q", "quit")
+}
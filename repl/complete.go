@@ -0,0 +1,65 @@
+package repl
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/wmolicki/go-monkey/evaluator"
+	"github.com/wmolicki/go-monkey/token"
+)
+
+// completer completes the word before the cursor with keywords, builtins
+// and names bound in the session, or with names of commands at the start
+// of a line.
+type completer struct {
+	session *Session
+}
+
+// Do implements readline.AutoCompleter, it returns the rest of each
+// candidate and the length of the completed prefix.
+func (c *completer) Do(line []rune, pos int) ([][]rune, int) {
+	start := pos
+	for start > 0 && isIdentifierRune(line[start-1]) {
+		start--
+	}
+	prefix := string(line[start:pos])
+
+	var candidates []string
+	if start == 1 && line[0] == ':' && len(c.session.pending) == 0 {
+		for name := range commands {
+			candidates = append(candidates, name)
+		}
+		sort.Strings(candidates)
+	} else if prefix != "" {
+		candidates = c.names()
+	}
+
+	var completions [][]rune
+	for _, name := range candidates {
+		if strings.HasPrefix(name, prefix) && name != prefix {
+			completions = append(completions, []rune(name[len(prefix):]))
+		}
+	}
+	return completions, len([]rune(prefix))
+}
+
+// names returns names which can be completed, sorted and without duplicates.
+func (c *completer) names() []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, group := range [][]string{token.Keywords(), evaluator.BuiltinNames(), c.session.env.Names()} {
+		for _, name := range group {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func isIdentifierRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
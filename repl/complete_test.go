@@ -0,0 +1,42 @@
+package repl
+
+import (
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestCompleter(t *testing.T) {
+	s := NewSession(io.Discard)
+	s.Feed("let length = 1; let counter = 2;")
+	c := &completer{session: s}
+
+	tests := []struct {
+		line     string
+		pos      int
+		expected []string
+		length   int
+	}{
+		{"le", 2, []string{"n", "ngth", "t"}, 2},
+		{"1 + cou", 7, []string{"nter"}, 3},
+		{"puts(cou)", 8, []string{"nter"}, 3},
+		{"ret", 3, []string{"urn"}, 3},
+		{"to_", 3, []string{"array"}, 3},
+		{"counter", 7, nil, 7},
+		{"", 0, nil, 0},
+		{"1 + ", 4, nil, 0},
+		{":t", 2, []string{"ime", "okens", "ype"}, 1},
+		{"x :t", 4, []string{"hrow", "o_array", "rue", "ry", "ype"}, 1},
+	}
+
+	for _, tt := range tests {
+		completions, length := c.Do([]rune(tt.line), tt.pos)
+		var got []string
+		for _, completion := range completions {
+			got = append(got, string(completion))
+		}
+		if !reflect.DeepEqual(got, tt.expected) || length != tt.length {
+			t.Errorf("Do(%q, %d) = %q, %d, want %q, %d", tt.line, tt.pos, got, length, tt.expected, tt.length)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package repl
+
+import (
+	"strings"
+
+	"github.com/wmolicki/go-monkey/lexer"
+	"github.com/wmolicki/go-monkey/parser"
+	"github.com/wmolicki/go-monkey/token"
+)
+
+// incomplete reports whether input needs more lines before it can be
+// evaluated: it has unclosed brackets or strings, or parsing it fails
+// only because it ended too early, e.g. after an operator.
+func incomplete(input string) bool {
+	l := lexer.New(input)
+
+	depth := 0
+	last := token.Token{Type: token.EOF}
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		switch tok.Type {
+		case token.LPAREN, token.LBRACKET, token.LBRACE, token.HASH_LBRACE:
+			depth++
+		case token.RPAREN, token.RBRACKET, token.RBRACE:
+			depth--
+		}
+		if depth < 0 {
+			// let the parser report the stray bracket
+			return false
+		}
+		last = tok
+	}
+	if depth > 0 {
+		return true
+	}
+
+	// an unterminated string runs up to the end of input
+	if last.Type == token.STRING {
+		quoted := `"` + last.Literal
+		if strings.HasSuffix(input, quoted) && !strings.HasSuffix(input, quoted+`"`) {
+			return true
+		}
+	}
+
+	p := parser.New(lexer.New(input))
+	p.ParseProgram()
+	errors := p.Errors()
+	return len(errors) > 0 && strings.Contains(errors[0], token.EOF)
+}
@@ -0,0 +1,41 @@
+package repl
+
+import "testing"
+
+func TestIncomplete(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"let a = 1;", false},
+		{"let fact = fn(n) {", true},
+		{"let fact = fn(n) {\n  if (n < 2) { return 1 }\n", true},
+		{"let fact = fn(n) {\n  if (n < 2) { return 1 }\n  n * fact(n - 1)\n};", false},
+		{"let xs = [1,", true},
+		{"let s = #{1", true},
+		{"puts(1, 2", true},
+		{"let x = 1 +", true},
+		{"let x =", true},
+		{"if (x) { 1 } else", true},
+		{"a.", true},
+		{`"abc`, true},
+		{"\"abc\ndef", true},
+		{`"abc"`, false},
+		{`""`, false},
+		{`"`, true},
+		{`"(" + x`, false},
+		{"x // (", false},
+		{`"a" // "b`, false},
+		// errors which are not caused by missing input are reported right away
+		{")", false},
+		{"let x = 1 + ) + (", false},
+		{"let 1", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := incomplete(tt.input); got != tt.expected {
+			t.Errorf("incomplete(%q) = %t, want %t", tt.input, got, tt.expected)
+		}
+	}
+}
@@ -1,51 +1,134 @@
 package repl
 
 import (
+	"bufio"
 	"io"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/chzyer/readline"
 
 	"github.com/wmolicki/go-monkey/evaluator"
-	"github.com/wmolicki/go-monkey/lexer"
-	"github.com/wmolicki/go-monkey/object"
-	"github.com/wmolicki/go-monkey/parser"
+	"github.com/wmolicki/go-monkey/pretty"
 )
 
 const PROMPT = ">> "
 
-func Start(in io.ReadCloser, out io.Writer) {
-	conf := &readline.Config{Prompt: PROMPT}
+// CONTINUATION_PROMPT is shown while the input read so far is incomplete.
+const CONTINUATION_PROMPT = ".. "
+
+// Options configure the interactive REPL.
+type Options struct {
+	// HistoryFile is where entered lines are kept between sessions,
+	// history is not saved if it's empty.
+	HistoryFile string
+	// HistorySize is the maximum number of lines kept in history,
+	// history is disabled if it's 0.
+	HistorySize int
+	// NoColor disables highlighting of input and colours of results.
+	NoColor bool
+}
+
+// DefaultHistoryFile returns the path of the history file in the user's
+// config directory.
+func DefaultHistoryFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "monke", "history"), nil
+}
+
+// Start runs the REPL reading from in and writing to out. Line editing,
+// completion and history are used only if in is a terminal, other input
+// is evaluated by Run. It returns the code input passed to exit, or 0.
+func Start(in io.ReadCloser, out io.Writer, opts Options) (int, error) {
+	if !isTerminal(in) {
+		return Run(in, out)
+	}
+
+	io.WriteString(out, "Monke REPL!\n")
+	session := NewSession(out)
+	session.Printer = pretty.NewPrinter()
+	session.Printer.Color = !opts.NoColor
+	conf := &readline.Config{
+		Prompt:       PROMPT,
+		Stdin:        in,
+		Stdout:       out,
+		AutoComplete: &completer{session: session},
+		HistoryLimit: opts.HistorySize,
+	}
+	if opts.HistorySize <= 0 {
+		// readline uses a default size for 0
+		conf.HistoryLimit = -1
+	} else if opts.HistoryFile != "" {
+		// without the directory history is only kept in memory
+		if err := os.MkdirAll(filepath.Dir(opts.HistoryFile), 0o755); err == nil {
+			conf.HistoryFile = opts.HistoryFile
+		}
+	}
+
+	if !opts.NoColor {
+		conf.Painter = highlighter{}
+	}
+
 	scanner, err := readline.NewEx(conf)
 	if err != nil {
-		panic(err)
+		return 0, err
 	}
-	env := object.NewEnvironment()
+	defer scanner.Close()
 
 	for {
+		session.Printer.Width = readline.GetScreenWidth()
+		scanner.SetPrompt(session.Prompt())
 		line, err := scanner.Readline()
-		if err != nil || line == "\\q" {
+		// ctrl-c discards incomplete input
+		if err == readline.ErrInterrupt && session.Interrupt() {
+			continue
+		}
+		if err != nil || !session.Feed(line) {
 			io.WriteString(out, "bye")
-			return
+			return session.ExitCode(), nil
 		}
+	}
+}
 
-		l := lexer.New(line)
-		p := parser.New(l)
-
-		program := p.ParseProgram()
-		if len(p.Errors()) != 0 {
-			printParserErrors(out, p.Errors())
-			continue
+// Run evaluates lines read from in like the REPL does, without prompts.
+// Builtins reading input, such as read_line, read the lines following
+// the one being evaluated. It returns the code input passed to exit, or 0.
+func Run(in io.Reader, out io.Writer) (int, error) {
+	session := NewSession(out)
+	r := bufio.NewReader(in)
+	// the reader is shared, so input isn't lost to either's buffer
+	evaluator.SetStdin(r)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			session.Flush()
+			if err == io.EOF {
+				return session.ExitCode(), nil
+			}
+			return session.ExitCode(), err
 		}
-
-		evaluated := evaluator.Eval(program, env)
-		if evaluated != nil {
-			io.WriteString(out, evaluated.Inspect())
-			io.WriteString(out, "\n")
+		if !session.Feed(strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")) {
+			return session.ExitCode(), nil
 		}
-
 	}
 }
 
+// highlighter implements readline.Painter.
+type highlighter struct{}
+
+func (highlighter) Paint(line []rune, _ int) []rune {
+	return []rune(pretty.Highlight(string(line)))
+}
+
+func isTerminal(in io.Reader) bool {
+	f, ok := in.(*os.File)
+	return ok && readline.IsTerminal(int(f.Fd()))
+}
+
 func printParserErrors(out io.Writer, errors []string) {
 	io.WriteString(out, "Error interpreting program\n")
 	io.WriteString(out, "  parser errors:\n")
@@ -0,0 +1,146 @@
+package repl
+
+import (
+	"io"
+	"strings"
+
+	"github.com/wmolicki/go-monkey/ast"
+	"github.com/wmolicki/go-monkey/evaluator"
+	"github.com/wmolicki/go-monkey/lexer"
+	"github.com/wmolicki/go-monkey/object"
+	"github.com/wmolicki/go-monkey/parser"
+	"github.com/wmolicki/go-monkey/pretty"
+)
+
+// Session evaluates input of a REPL given to it line by line, results
+// are written to its output. Definitions are kept between lines.
+type Session struct {
+	// Printer lays out results, they are printed with Inspect if it's nil.
+	Printer *pretty.Printer
+
+	out      io.Writer
+	env      *object.Environment
+	macroEnv *object.Environment
+
+	// lines of input which is not complete yet
+	pending []string
+	// exit is set once input calls exit
+	exit *evaluator.Exit
+}
+
+// NewSession returns a session writing results to out. Builtins such
+// as puts write to out as well.
+func NewSession(out io.Writer) *Session {
+	evaluator.SetStdout(out)
+	s := &Session{out: out}
+	s.reset()
+	return s
+}
+
+func (s *Session) reset() {
+	s.env = object.NewEnvironment()
+	s.macroEnv = object.NewEnvironment()
+	s.pending = nil
+}
+
+// Prompt returns the prompt for the next line.
+func (s *Session) Prompt() string {
+	if len(s.pending) > 0 {
+		return CONTINUATION_PROMPT
+	}
+	return PROMPT
+}
+
+// Feed handles a line of input. Lines are collected until they form
+// complete input, which is then evaluated. Lines starting with : are
+// commands, see :help. Feed returns false once the session is over.
+func (s *Session) Feed(line string) bool {
+	if len(s.pending) == 0 {
+		if line == "\\q" {
+			return false
+		}
+		if strings.HasPrefix(line, ":") {
+			s.command(line)
+			return s.exit == nil
+		}
+	}
+
+	s.pending = append(s.pending, line)
+	if incomplete(strings.Join(s.pending, "\n")) {
+		return true
+	}
+	s.Flush()
+	return s.exit == nil
+}
+
+// ExitCode returns the code input passed to exit, 0 if it didn't call it.
+func (s *Session) ExitCode() int {
+	if s.exit == nil {
+		return 0
+	}
+	return s.exit.Code
+}
+
+// Flush evaluates input collected so far even if it's incomplete, so
+// errors in input cut short are reported.
+func (s *Session) Flush() {
+	if len(s.pending) == 0 {
+		return
+	}
+	input := strings.Join(s.pending, "\n")
+	s.pending = nil
+
+	if evaluated := s.eval(input); evaluated != nil {
+		s.print(evaluated)
+	}
+}
+
+func (s *Session) print(obj object.Object) {
+	if s.Printer != nil {
+		io.WriteString(s.out, s.Printer.Sprint(obj))
+	} else {
+		io.WriteString(s.out, obj.Inspect())
+	}
+	io.WriteString(s.out, "\n")
+}
+
+// Interrupt discards incomplete input, it reports whether there was any.
+func (s *Session) Interrupt() bool {
+	discarded := len(s.pending) > 0
+	s.pending = nil
+	return discarded
+}
+
+// eval evaluates input in the session environment. Parser and macro
+// expansion errors are written to the output and nil is returned, as
+// it is when input calls exit.
+func (s *Session) eval(input string) object.Object {
+	program, ok := s.parse(input)
+	if !ok {
+		return nil
+	}
+
+	evaluator.DefineMacros(program, s.macroEnv)
+	expanded, err := evaluator.ExpandMacros(program, s.macroEnv)
+	if err != nil {
+		io.WriteString(s.out, "error expanding macros: "+err.Error()+"\n")
+		return nil
+	}
+
+	evaluated := evaluator.Eval(expanded, s.env)
+	if exit, ok := evaluated.(*evaluator.Exit); ok {
+		s.exit = exit
+		return nil
+	}
+	return evaluated
+}
+
+func (s *Session) parse(input string) (*ast.Program, bool) {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		printParserErrors(s.out, p.Errors())
+		return nil, false
+	}
+	return program, true
+}
@@ -0,0 +1,146 @@
+package repl
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/wmolicki/go-monkey/pretty"
+)
+
+// feed gives lines read from input to a new session and returns its output.
+func feed(input string) string {
+	var out bytes.Buffer
+	s := NewSession(&out)
+
+	scanner := bufio.NewScanner(strings.NewReader(input))
+	for scanner.Scan() {
+		if !s.Feed(scanner.Text()) {
+			break
+		}
+	}
+	return out.String()
+}
+
+func TestSession(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 + 2", "3\n"},
+		{"let a = 1\na + 1", "2\n"},
+		{"let f = fn(n) {\n  n * 2\n};\nf(3)", "6\n"},
+		{"\\q\n1", ""},
+		{"1\nexit()\n2", "1\n"},
+		{"let x = 1 +", ""},
+		{"let m = macro(a) { quote(unquote(a) + 1) };\nm(1)", "2\n"},
+		{"foo", "ERROR: identifier not found: foo\n"},
+		{"let 1", "Error interpreting program\n  parser errors:\n\texpected next token to be IDENT, got INT instead\n"},
+	}
+
+	for _, tt := range tests {
+		if got := feed(tt.input); got != tt.expected {
+			t.Errorf("%q: wrong output.\nwant=%q\ngot= %q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestSessionInterrupt(t *testing.T) {
+	var out bytes.Buffer
+	s := NewSession(&out)
+
+	s.Feed("let f = fn() {")
+	if s.Prompt() != CONTINUATION_PROMPT {
+		t.Errorf("wrong prompt for incomplete input. got=%q", s.Prompt())
+	}
+	if !s.Interrupt() {
+		t.Errorf("Interrupt didn't discard incomplete input")
+	}
+	if s.Prompt() != PROMPT {
+		t.Errorf("wrong prompt after interrupt. got=%q", s.Prompt())
+	}
+	if s.Interrupt() {
+		t.Errorf("Interrupt discarded input twice")
+	}
+
+	s.Feed("1")
+	if out.String() != "1\n" {
+		t.Errorf("wrong output after interrupt. got=%q", out.String())
+	}
+}
+
+func TestCommands(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "lib.monke")
+	if err := os.WriteFile(script, []byte("let double = fn(x) { x * 2 };\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let b = true; let a = [1];\n:env", "a: ARRAY\nb: BOOLEAN\n"},
+		{"let m = macro() { quote(1) };\n:env", "m: MACRO\n"},
+		{":type 1 + 2", "INTEGER\n"},
+		{":type foo", "ERROR: identifier not found: foo\n"},
+		{":type let", "Error interpreting program\n  parser errors:\n\texpected next token to be IDENT, got EOF instead\n"},
+		{":tokens let x = 1;", "1:1\tLET\t\"let\"\n1:5\tIDENT\t\"x\"\n1:7\t=\t\"=\"\n1:9\tINT\t\"1\"\n1:10\t;\t\";\"\n"},
+		{":load " + script + "\ndouble(4)", "8\n"},
+		{":load " + filepath.Join(dir, "missing.monke"), "error reading file: open " + filepath.Join(dir, "missing.monke") + ": no such file or directory\n"},
+		{"let a = 1;\n:reset\na", "ERROR: identifier not found: a\n"},
+		{":type", "usage: :type expr\n"},
+		{":nope", "unknown command :nope, see :help\n"},
+		// commands are not recognized inside incomplete input
+		{"let s = \"a\n:b\";\ns", "a\n:b\n"},
+	}
+
+	for _, tt := range tests {
+		if got := feed(tt.input); got != tt.expected {
+			t.Errorf("%q: wrong output.\nwant=%q\ngot= %q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestAstCommand(t *testing.T) {
+	got := feed(":ast 1")
+	for _, want := range []string{`"kind": "Program"`, `"kind": "IntegerLiteral"`, `"value": 1`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("tree doesn't contain %s. got=%s", want, got)
+		}
+	}
+}
+
+func TestTimeCommand(t *testing.T) {
+	got := feed(":time 1 + 2")
+	if !regexp.MustCompile(`^3\ntime: \S+s\n$`).MatchString(got) {
+		t.Errorf("wrong output. got=%q", got)
+	}
+}
+
+func TestHelpCommand(t *testing.T) {
+	got := feed(":help")
+	for name := range commands {
+		if !strings.Contains(got, ":"+name) {
+			t.Errorf("help doesn't mention :%s. got=%s", name, got)
+		}
+	}
+}
+
+func TestSessionPrinter(t *testing.T) {
+	var out bytes.Buffer
+	s := NewSession(&out)
+	s.Printer = &pretty.Printer{Width: 12, Indent: "  "}
+
+	s.Feed(`{"name": "monke", "id": 1}`)
+	s.Feed(`"plain"`)
+
+	expected := "{\n  \"name\": \"monke\",\n  \"id\": 1,\n}\nplain\n"
+	if out.String() != expected {
+		t.Errorf("wrong output.\nwant=%q\ngot= %q", expected, out.String())
+	}
+}
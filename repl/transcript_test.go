@@ -0,0 +1,113 @@
+package repl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Transcripts in testdata are REPL sessions, lines starting with a prompt
+// are input and other lines are the output expected after it.
+func TestTranscripts(t *testing.T) {
+	files, err := filepath.Glob("testdata/*.transcript")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no transcripts found")
+	}
+
+	for _, file := range files {
+		transcript, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := trimLines(string(transcript))
+		got := trimLines(replay(string(transcript)))
+		if got != want {
+			t.Errorf("%s: wrong transcript, expected:\n%s\ngot:\n%s", file, want, got)
+		}
+	}
+}
+
+// replay feeds input lines of transcript to a new session and returns
+// the transcript of the session.
+func replay(transcript string) string {
+	var out bytes.Buffer
+	s := NewSession(&out)
+
+	for _, line := range strings.Split(transcript, "\n") {
+		input, ok := cutPrompt(line)
+		if !ok {
+			continue
+		}
+		out.WriteString(s.Prompt() + input + "\n")
+		if !s.Feed(input) {
+			break
+		}
+	}
+	return out.String()
+}
+
+func cutPrompt(line string) (string, bool) {
+	for _, prompt := range []string{PROMPT, CONTINUATION_PROMPT} {
+		if strings.HasPrefix(line, prompt) {
+			return line[len(prompt):], true
+		}
+		// editors strip the space of the prompt of an empty line
+		if line == strings.TrimSpace(prompt) {
+			return "", true
+		}
+	}
+	return "", false
+}
+
+// trimLines removes trailing whitespace of lines and of the text.
+func trimLines(text string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		code     int
+	}{
+		{"1 + 2\n", "3\n", 0},
+		{"let a = 1;\r\na + 1", "2\n", 0},
+		{"let f = fn(x) {\n\n  x * 2\n};\nf(2)\n", "4\n", 0},
+		{"1\n\\q\n2\n", "1\n", 0},
+		// incomplete input at the end is evaluated, so errors are reported
+		{"let a = [1,\n", "Error interpreting program\n  parser errors:\n" +
+			"\tno prefix parse function for 'EOF' found\n\texpected next token to be ], got EOF instead\n", 0},
+		{"", "", 0},
+		{"puts(1 + 2)\n", "3\nnull\n", 0},
+		// builtins read input following the line being evaluated
+		{"let name = read_line();\nmonke\nname\n", "monke\n", 0},
+		{"1\nexit(3)\n2\n", "1\n", 3},
+		{"exit(0)", "", 0},
+		// exit on the last line without a newline is flushed
+		{"puts(1); exit(4)", "1\n", 4},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		code, err := Run(strings.NewReader(tt.input), &out)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.input, err)
+		}
+		if code != tt.code {
+			t.Errorf("%q: wrong exit code. expected=%d, got=%d", tt.input, tt.code, code)
+		}
+		if out.String() != tt.expected {
+			t.Errorf("%q: wrong output.\nwant=%q\ngot= %q", tt.input, tt.expected, out.String())
+		}
+	}
+}
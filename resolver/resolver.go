@@ -0,0 +1,330 @@
+// Package resolver finds definitions of names used in a program, without
+// evaluating it, and reports names which are not defined anywhere.
+package resolver
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/wmolicki/go-monkey/ast"
+	"github.com/wmolicki/go-monkey/token"
+)
+
+// Result of resolving a program.
+type Result struct {
+	// Definitions maps identifiers referring to a name to the identifier
+	// defining it, e.g. the name of a let statement or a parameter.
+	// Names defined by the universe are not included.
+	Definitions map[*ast.Identifier]*ast.Identifier
+	// Declarations lists identifiers defining names, in source order.
+	Declarations []*ast.Identifier
+	// Undefined lists uses of undefined names in source order.
+	Undefined []*ast.Identifier
+	// Errors describe each of Undefined.
+	Errors []string
+
+	scopes []*scope
+}
+
+// scope holds names defined in a function, or in a match arm, catch
+// block or select case, which get their own environment when evaluated.
+type scope struct {
+	outer *scope
+	// definitions of each name in source order, a name can be
+	// defined again with let
+	names map[string][]definition
+	// start and end of the scope in the source, the scope of the
+	// program has no end
+	start, end token.Position
+}
+
+type definition struct {
+	ident *ast.Identifier
+	// seq orders definitions and uses as they're evaluated
+	seq int
+}
+
+type use struct {
+	ident *ast.Identifier
+	scope *scope
+	seq   int
+	// inLoop is true if the use can be evaluated again after
+	// definitions following it in its function
+	inLoop bool
+}
+
+type resolver struct {
+	uses   []use
+	scopes []*scope
+	decls  []*ast.Identifier
+	seq    int
+	// loops is the number of loops the current node is in, in the
+	// current function
+	loops int
+}
+
+func (r *resolver) define(s *scope, name *ast.Identifier) {
+	r.seq++
+	r.decls = append(r.decls, name)
+	s.names[name.Value] = append(s.names[name.Value], definition{ident: name, seq: r.seq})
+}
+
+// Resolve resolves names used in program. Names in universe, e.g.
+// builtin functions, are defined everywhere unless they are shadowed.
+//
+// Environments are only known when the program runs, so a use refers to
+// the closest definition evaluated before it in the innermost scope
+// defining the name. A use in a nested scope or a loop may refer to the
+// first definition after it, as in a function calling a function which
+// is defined later.
+func Resolve(program *ast.Program, universe []string) *Result {
+	r := &resolver{}
+	r.node(program, r.newScope(nil, token.Position{}, token.Position{}))
+
+	predeclared := map[string]bool{}
+	for _, name := range universe {
+		predeclared[name] = true
+	}
+
+	result := &Result{Definitions: map[*ast.Identifier]*ast.Identifier{}, scopes: r.scopes}
+	sort.SliceStable(r.uses, func(i, j int) bool {
+		return r.uses[i].ident.Pos().Before(r.uses[j].ident.Pos())
+	})
+	result.Declarations = r.decls
+	sort.SliceStable(result.Declarations, func(i, j int) bool {
+		return result.Declarations[i].Pos().Before(result.Declarations[j].Pos())
+	})
+
+	for _, u := range r.uses {
+		if def := lookup(u); def != nil {
+			result.Definitions[u.ident] = def
+		} else if !predeclared[u.ident.Value] {
+			result.Undefined = append(result.Undefined, u.ident)
+			result.Errors = append(result.Errors, fmt.Sprintf("identifier not found: %s at %s", u.ident.Value, u.ident.Pos()))
+		}
+	}
+	return result
+}
+
+// NamesAt returns names which can be used at pos, sorted. Like uses,
+// names defined after pos are only included from enclosing scopes.
+func (result *Result) NamesAt(pos token.Position) []string {
+	// scopes are created outer first and siblings don't overlap, so
+	// the last scope containing pos is the innermost one
+	var innermost *scope
+	for _, s := range result.scopes {
+		if !pos.Before(s.start) && (s.end == token.Position{} || !s.end.Before(pos)) {
+			innermost = s
+		}
+	}
+
+	seen := map[string]bool{}
+	var names []string
+	for s := innermost; s != nil; s = s.outer {
+		for name, defs := range s.names {
+			if seen[name] || (s == innermost && !defs[0].ident.Pos().Before(pos)) {
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *resolver) newScope(outer *scope, start, end token.Position) *scope {
+	s := &scope{outer: outer, names: map[string][]definition{}, start: start, end: end}
+	r.scopes = append(r.scopes, s)
+	return s
+}
+
+func lookup(u use) *ast.Identifier {
+	for s := u.scope; s != nil; s = s.outer {
+		defs := s.names[u.ident.Value]
+		if len(defs) == 0 {
+			continue
+		}
+		for i := len(defs) - 1; i >= 0; i-- {
+			if defs[i].seq < u.seq {
+				return defs[i].ident
+			}
+		}
+		if s != u.scope || u.inLoop {
+			return defs[0].ident
+		}
+		return nil
+	}
+	return nil
+}
+
+func (r *resolver) node(node ast.Node, s *scope) {
+	switch node := node.(type) {
+	case *ast.Program:
+		for _, stmt := range node.Statements {
+			r.node(stmt, s)
+		}
+	case *ast.BlockStatement:
+		// blocks are evaluated in the environment they're in
+		for _, stmt := range node.Statements {
+			r.node(stmt, s)
+		}
+	case *ast.ExpressionStatement:
+		r.expression(node.Expression, s)
+	case *ast.LetStatement:
+		r.expression(node.Value, s)
+		r.define(s, node.Name)
+	case *ast.ReturnStatement:
+		r.expression(node.ReturnValue, s)
+	case *ast.ThrowStatement:
+		r.expression(node.Value, s)
+	case *ast.YieldStatement:
+		r.expression(node.Value, s)
+	case *ast.StructStatement:
+		r.define(s, node.Name)
+		for _, method := range node.Methods {
+			r.expression(method.Function, s)
+		}
+	default:
+		r.expression(node, s)
+	}
+}
+
+func (r *resolver) expression(node ast.Node, s *scope) {
+	switch node := node.(type) {
+	case nil:
+	case *ast.Identifier:
+		r.seq++
+		r.uses = append(r.uses, use{ident: node, scope: s, seq: r.seq, inLoop: r.loops > 0})
+	case *ast.PrefixExpression:
+		r.expression(node.Right, s)
+	case *ast.InfixExpression:
+		r.expression(node.Left, s)
+		r.expression(node.Right, s)
+	case *ast.IfExpression:
+		r.expression(node.Condition, s)
+		r.node(node.Consequence, s)
+		if node.Alternative != nil {
+			r.node(node.Alternative, s)
+		}
+	case *ast.ForExpression:
+		r.node(node.Initializer, s)
+		r.loops++
+		r.expression(node.Condition, s)
+		r.node(node.Loop, s)
+		r.node(node.Body, s)
+		r.loops--
+	case *ast.FunctionLiteral:
+		r.function(node, node.Parameters, node.Body, s)
+	case *ast.MacroLiteral:
+		r.function(node, node.Parameters, node.Body, s)
+	case *ast.CallExpression:
+		// quoted code is evaluated where it's unquoted
+		if node.Function.TokenLiteral() == "quote" {
+			return
+		}
+		r.expression(node.Function, s)
+		for _, arg := range node.Arguments {
+			r.expression(arg, s)
+		}
+	case *ast.ArrayLiteral:
+		for _, el := range node.Elements {
+			r.expression(el, s)
+		}
+	case *ast.SetLiteral:
+		for _, el := range node.Elements {
+			r.expression(el, s)
+		}
+	case *ast.HashLiteral:
+		for _, key := range node.Keys() {
+			r.expression(key, s)
+			r.expression(node.Pairs[key], s)
+		}
+	case *ast.IndexExpression:
+		r.expression(node.Left, s)
+		r.expression(node.Index, s)
+	case *ast.MemberExpression:
+		r.expression(node.Object, s)
+	case *ast.AssignExpression:
+		r.expression(node.Target, s)
+		r.expression(node.Value, s)
+	case *ast.TryExpression:
+		r.node(node.Body, s)
+		if node.Catch != nil {
+			start := node.Catch.Pos()
+			if node.CatchParameter != nil {
+				start = node.CatchParameter.Pos()
+			}
+			catch := r.newScope(s, start, node.Catch.EndToken.Pos())
+			if node.CatchParameter != nil {
+				r.define(catch, node.CatchParameter)
+			}
+			r.node(node.Catch, catch)
+		}
+		if node.Finally != nil {
+			r.node(node.Finally, s)
+		}
+	case *ast.MatchExpression:
+		r.expression(node.Subject, s)
+		for i, arm := range node.Arms {
+			end := node.EndToken.Pos()
+			if i+1 < len(node.Arms) {
+				end = node.Arms[i+1].Pattern.Pos()
+			}
+			armScope := r.newScope(s, arm.Pattern.Pos(), end)
+			r.pattern(arm.Pattern, armScope)
+			r.expression(arm.Guard, armScope)
+			r.expression(arm.Body, armScope)
+		}
+	case *ast.SpawnExpression:
+		r.expression(node.Value, s)
+	case *ast.SelectExpression:
+		for i, c := range node.Cases {
+			if c.Call != nil {
+				r.expression(c.Call, s)
+			}
+			end := node.EndToken.Pos()
+			if i+1 < len(node.Cases) {
+				end = node.Cases[i+1].Token.Pos()
+			}
+			caseScope := r.newScope(s, c.Token.Pos(), end)
+			if c.Binding != nil {
+				r.define(caseScope, c.Binding)
+			}
+			r.expression(c.Body, caseScope)
+		}
+	}
+}
+
+func (r *resolver) function(node ast.Node, params []*ast.Identifier, body *ast.BlockStatement, outer *scope) {
+	s := r.newScope(outer, node.Pos(), body.EndToken.Pos())
+	for _, param := range params {
+		r.define(s, param)
+	}
+
+	loops := r.loops
+	r.loops = 0
+	r.node(body, s)
+	r.loops = loops
+}
+
+// pattern defines names bound by p in s.
+func (r *resolver) pattern(p ast.Pattern, s *scope) {
+	switch p := p.(type) {
+	case *ast.BindingPattern:
+		r.define(s, p.Name)
+	case *ast.LiteralPattern:
+		r.expression(p.Value, s)
+	case *ast.ArrayPattern:
+		for _, el := range p.Elements {
+			r.pattern(el, s)
+		}
+		if p.Rest != nil {
+			r.define(s, p.Rest)
+		}
+	case *ast.HashPattern:
+		for _, pair := range p.Pairs {
+			r.pattern(pair.Value, s)
+		}
+	}
+}
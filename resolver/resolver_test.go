@@ -0,0 +1,134 @@
+package resolver
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/wmolicki/go-monkey/ast"
+	"github.com/wmolicki/go-monkey/lexer"
+	"github.com/wmolicki/go-monkey/parser"
+	"github.com/wmolicki/go-monkey/token"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("%q: parser errors: %v", input, p.Errors())
+	}
+	return program
+}
+
+func TestResolveErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let a = 1; a + len([])", nil},
+		{"a + b", []string{"identifier not found: a at 1:1", "identifier not found: b at 1:5"}},
+		{"let f = fn(x) { x + y }", []string{"identifier not found: y at 1:21"}},
+		{"let x = x", []string{"identifier not found: x at 1:9"}},
+		{"puts(x); let x = 1", []string{"identifier not found: x at 1:6"}},
+		// a loop may use a name defined later in its previous iteration
+		{"for (let i = 0; i < 2; let i = i + 1) { if (i > 0) { prev }; let prev = i }", nil},
+		// functions can refer to names defined after them
+		{"let even = fn(n) { if (n == 0) { true } else { odd(n - 1) } }; let odd = fn(n) { even(n) }", nil},
+		{"let f = fn() { g() }; f()", []string{"identifier not found: g at 1:16"}},
+		// blocks don't have their own scope
+		{"if (true) { let a = 1 }; a", nil},
+		{"for (let i = 0; i < 3; let i = i + 1) { let last = i }; last", nil},
+		// match arms, catch blocks and select cases do
+		{"match (1) { [a, ...rest] => a + len(rest), {\"k\": v} => v, n if n > 0 => n, _ => 0 }", nil},
+		{"match (1) { n => n }; n", []string{"identifier not found: n at 1:23"}},
+		{"try { throw 1 } catch (e) { e } finally { e }", []string{"identifier not found: e at 1:43"}},
+		{"let c = chan(); select { let v = recv(c) => v, _ => v }", []string{"identifier not found: v at 1:53"}},
+		{"struct P { x fn get(self) { self.x + y } }; P(1)", []string{"identifier not found: y at 1:38"}},
+		{"let p = {}; p.missing; p.x = q", []string{"identifier not found: q at 1:30"}},
+		{"let m = macro(a) { quote(unquote(a) + b) }; m(1)", nil},
+		{"spawn f(x)", []string{"identifier not found: f at 1:7", "identifier not found: x at 1:9"}},
+	}
+
+	for _, tt := range tests {
+		result := Resolve(parse(t, tt.input), []string{"len", "chan", "recv", "puts"})
+		if !reflect.DeepEqual(result.Errors, tt.expected) {
+			t.Errorf("%q: wrong errors.\nwant=%q\ngot= %q", tt.input, tt.expected, result.Errors)
+		}
+	}
+}
+
+func TestResolveDefinitions(t *testing.T) {
+	tests := []struct {
+		input string
+		// positions of uses and their definitions
+		uses map[string]string
+	}{
+		{"let a = 1; a", map[string]string{"1:12": "1:5"}},
+		{"let a = 1; let a = a + 1; a", map[string]string{"1:20": "1:5", "1:27": "1:16"}},
+		{"let f = fn(a) { a }; let a = 2; f(a)", map[string]string{"1:17": "1:12", "1:33": "1:5", "1:35": "1:26"}},
+		{"let f = fn() { g }; let g = 1", map[string]string{"1:16": "1:25"}},
+		{"let len = 1; len", map[string]string{"1:14": "1:5"}},
+	}
+
+	for _, tt := range tests {
+		result := Resolve(parse(t, tt.input), []string{"len"})
+		got := map[string]string{}
+		for use, def := range result.Definitions {
+			got[use.Pos().String()] = def.Pos().String()
+		}
+		if !reflect.DeepEqual(got, tt.uses) {
+			t.Errorf("%q: wrong definitions.\nwant=%v\ngot= %v", tt.input, tt.uses, got)
+		}
+	}
+}
+
+func TestDeclarations(t *testing.T) {
+	input := `let f = fn(a, b) { let c = a; c };
+struct P { x };
+match (1) { [h, ...t] => h, _ => 0 };
+try { 1 } catch (e) { e }`
+
+	var got []string
+	for _, decl := range Resolve(parse(t, input), nil).Declarations {
+		got = append(got, decl.Value+" "+decl.Pos().String())
+	}
+	expected := []string{"f 1:5", "a 1:12", "b 1:15", "c 1:24", "P 2:8", "h 3:14", "t 3:20", "e 4:18"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("wrong declarations.\nwant=%q\ngot= %q", expected, got)
+	}
+}
+
+func TestNamesAt(t *testing.T) {
+	input := `let a = 1;
+let f = fn(x) {
+  let y = x;
+  y
+};
+match (a) { n => n };
+let b = 2`
+
+	tests := []struct {
+		pos      string
+		expected []string
+	}{
+		{"1:1", nil},
+		{"2:1", []string{"a"}},
+		// functions can use names defined after them
+		{"3:3", []string{"a", "b", "f", "x"}},
+		{"4:3", []string{"a", "b", "f", "x", "y"}},
+		{"5:1", []string{"a", "b", "f", "x", "y"}},
+		{"6:18", []string{"a", "b", "f", "n"}},
+		{"7:1", []string{"a", "f"}},
+		{"8:1", []string{"a", "b", "f"}},
+	}
+
+	result := Resolve(parse(t, input), nil)
+	for _, tt := range tests {
+		var pos token.Position
+		fmt.Sscanf(tt.pos, "%d:%d", &pos.Line, &pos.Column)
+		if got := result.NamesAt(pos); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: wrong names.\nwant=%q\ngot= %q", tt.pos, tt.expected, got)
+		}
+	}
+}
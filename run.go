@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/wmolicki/go-monkey/ast"
+	"github.com/wmolicki/go-monkey/evaluator"
+	"github.com/wmolicki/go-monkey/lexer"
+	"github.com/wmolicki/go-monkey/object"
+	"github.com/wmolicki/go-monkey/optimizer"
+	"github.com/wmolicki/go-monkey/parser"
+)
+
+// runRun implements `monke run [-O [-passes list]] (-e expr | files... | -) [args...]`.
+// Files are evaluated in order in the same environment, and the value of
+// the last one is printed when it finishes. Without files, or with -, the
+// program is read from standard input. Arguments following the program
+// are given to it as the `args` array.
+func runRun(args []string) int {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	expr := fs.String("e", "", "run expr instead of files")
+	optimize := fs.Bool("O", false, "optimize the program before running it")
+	passes := fs.String("passes", "", "comma separated optimizer passes used with -O, all by default")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: monke run [-O [-passes list]] (-e expr | files... | -) [args...]\n\n")
+		fmt.Fprintf(fs.Output(), "The first argument is always a file, following ones are files\n")
+		fmt.Fprintf(fs.Output(), "up to the first one which isn't - or doesn't end in .monke, or up to --.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	var names, sources []string
+	scriptArgs := fs.Args()
+	if *expr != "" {
+		names, sources = []string{"-e"}, []string{*expr}
+	} else {
+		files, rest, err := splitFiles(fs.Args())
+		if err != nil {
+			fmt.Fprintln(fs.Output(), err)
+			fs.Usage()
+			return exitUsage
+		}
+		scriptArgs = rest
+		for _, filename := range files {
+			src, err := readScript(filename)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return exitError
+			}
+			names, sources = append(names, filename), append(sources, src)
+		}
+	}
+
+	var optimizations []optimizer.Pass
+	if *optimize {
+		var err error
+		if optimizations, err = optimizerPasses(*passes); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+	}
+
+	return runPrograms(names, sources, scriptArgs, optimizations, os.Stdout, os.Stderr)
+}
+
+// runPrograms runs sources in order in the same environment, they are
+// optimized with passes unless passes is nil. Output of the programs and
+// the value of the last one are written to stdout, errors to stderr.
+func runPrograms(names, sources, scriptArgs []string, passes []optimizer.Pass, stdout, stderr io.Writer) int {
+	evaluator.SetStdout(stdout)
+
+	// all files are parsed before any of them runs, so a syntax error
+	// doesn't leave the program half done
+	programs := make([]*ast.Program, len(sources))
+	for i, src := range sources {
+		p := parser.New(lexer.New(src))
+		programs[i] = p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			if len(sources) > 1 {
+				fmt.Fprintf(stderr, "%s: ", names[i])
+			}
+			printParserErrors(stderr, p.Errors())
+			return exitError
+		}
+	}
+
+	macroEnv := object.NewEnvironment()
+	for i, program := range programs {
+		evaluator.DefineMacros(program, macroEnv)
+		expanded, err := evaluator.ExpandMacros(program, macroEnv)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: error expanding macros: %v\n", names[i], err)
+			return exitError
+		}
+		programs[i] = expanded.(*ast.Program)
+	}
+	// files share the environment, so they are optimized together
+	// to keep names one of them uses from another
+	if passes != nil {
+		optimizer.OptimizeAll(programs, passes)
+	}
+
+	env := object.NewEnvironment()
+	env.Set("args", scriptArguments(scriptArgs))
+
+	var evaluated object.Object
+	for _, program := range programs {
+		evaluated = evaluator.Eval(program, env)
+		switch evaluated := evaluated.(type) {
+		case *evaluator.Exit:
+			return evaluated.Code
+		case *object.Error:
+			printError(stderr, evaluated)
+			return exitError
+		}
+	}
+
+	if evaluated != nil {
+		io.WriteString(stdout, evaluated.Inspect())
+		io.WriteString(stdout, "\n")
+	}
+	return exitOK
+}
+
+// splitFiles splits arguments of run into files of the program and its
+// arguments. The first argument is a file, so scripts which aren't named
+// .monke can be run, followed by files ending in .monke or -. -- ends files.
+// Without arguments, the program is read from standard input, which can
+// be given only once.
+func splitFiles(args []string) (files, scriptArgs []string, err error) {
+	if len(args) == 0 {
+		return []string{"-"}, nil, nil
+	}
+	n := 1
+	for n < len(args) && (strings.HasSuffix(args[n], ".monke") || args[n] == "-") {
+		n++
+	}
+	files, scriptArgs = args[:n], args[n:]
+	if len(scriptArgs) > 0 && scriptArgs[0] == "--" {
+		scriptArgs = scriptArgs[1:]
+	}
+
+	stdin := 0
+	for _, filename := range files {
+		if filename == "-" {
+			stdin++
+		}
+	}
+	if stdin > 1 {
+		return nil, nil, errors.New("standard input (-) can be read only once")
+	}
+	return files, scriptArgs, nil
+}
+
+// scriptArguments returns args as an array of strings.
+func scriptArguments(args []string) *object.Array {
+	elements := make([]object.Object, len(args))
+	for i, arg := range args {
+		elements[i] = &object.String{Value: arg}
+	}
+	return object.NewArray(elements)
+}
+
+// printError writes an uncaught error with its stack to out.
+func printError(out io.Writer, err *object.Error) {
+	io.WriteString(out, err.Inspect()+"\n")
+	for _, frame := range err.Stack {
+		io.WriteString(out, "\tat "+frame+"\n")
+	}
+}
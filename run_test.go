@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/wmolicki/go-monkey/optimizer"
+)
+
+func TestRunPrograms(t *testing.T) {
+	tests := []struct {
+		sources  []string
+		args     []string
+		passes   []optimizer.Pass
+		code     int
+		expected string
+		stderr   string
+	}{
+		{[]string{"1 + 2"}, nil, nil, exitOK, "3\n", ""},
+		{[]string{"puts(args); len(args)"}, []string{"a", "b"}, nil, exitOK, "[a, b]\n2\n", ""},
+		// files share the environment, only the last value is printed
+		{[]string{"let helper = fn() { 42 }; 1", "helper()"}, nil, nil, exitOK, "42\n", ""},
+		// the optimizer keeps names used by later files
+		{[]string{"let helper = fn() { 42 }; 1", "helper()"}, nil, optimizer.DefaultPasses, exitOK, "42\n", ""},
+		{[]string{"let unused = 1; let x = 2;", "x"}, nil, optimizer.DefaultPasses, exitOK, "2\n", ""},
+		// macros defined by a file are expanded in later ones
+		{[]string{"let twice = macro(x) { quote(unquote(x) * 2) };", "twice(21)"}, nil, optimizer.DefaultPasses, exitOK, "42\n", ""},
+		{[]string{"puts(1)", "exit(3)", "puts(2)"}, nil, nil, 3, "1\n", ""},
+		{[]string{"1 / 0"}, nil, nil, exitError, "", "ERROR: division by zero\n"},
+		// no file runs if one of them doesn't parse
+		{[]string{"puts(1)", "let = 1"}, nil, nil, exitError, "", "b.monke: Error interpreting program\n" +
+			"  parser errors:\n\texpected next token to be IDENT, got = instead\n\tno prefix parse function for '=' found\n"},
+	}
+
+	for _, tt := range tests {
+		names := []string{"a.monke", "b.monke", "c.monke"}[:len(tt.sources)]
+		var stdout, stderr bytes.Buffer
+		code := runPrograms(names, tt.sources, tt.args, tt.passes, &stdout, &stderr)
+		if code != tt.code {
+			t.Errorf("%q: wrong exit code. expected=%d, got=%d", tt.sources, tt.code, code)
+		}
+		if stdout.String() != tt.expected {
+			t.Errorf("%q: wrong output.\nwant=%q\ngot= %q", tt.sources, tt.expected, stdout.String())
+		}
+		if stderr.String() != tt.stderr {
+			t.Errorf("%q: wrong errors.\nwant=%q\ngot= %q", tt.sources, tt.stderr, stderr.String())
+		}
+	}
+}
+
+func TestSplitFiles(t *testing.T) {
+	tests := []struct {
+		args       []string
+		files      []string
+		scriptArgs []string
+		err        bool
+	}{
+		// without files the program is read from standard input
+		{nil, []string{"-"}, nil, false},
+		{[]string{"a.monke"}, []string{"a.monke"}, []string{}, false},
+		// the first argument is a file whatever its name
+		{[]string{"script", "x"}, []string{"script"}, []string{"x"}, false},
+		{[]string{"-", "x"}, []string{"-"}, []string{"x"}, false},
+		{[]string{"a.monke", "b.monke", "-", "x", "c.monke"}, []string{"a.monke", "b.monke", "-"}, []string{"x", "c.monke"}, false},
+		// -- ends files, so arguments ending in .monke can be passed
+		{[]string{"a.monke", "--", "b.monke"}, []string{"a.monke"}, []string{"b.monke"}, false},
+		{[]string{"a.monke", "--", "--"}, []string{"a.monke"}, []string{"--"}, false},
+		{[]string{"a.monke", "b.monke", "--"}, []string{"a.monke", "b.monke"}, []string{}, false},
+		{[]string{"-", "a.monke", "-"}, nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		files, scriptArgs, err := splitFiles(tt.args)
+		if (err != nil) != tt.err {
+			t.Errorf("%q: wrong error: %v", tt.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(files, tt.files) || !reflect.DeepEqual(scriptArgs, tt.scriptArgs) {
+			t.Errorf("%q: wrong split. expected=%q %q, got=%q %q", tt.args, tt.files, tt.scriptArgs, files, scriptArgs)
+		}
+	}
+}
+
+func TestReadScriptFromStdin(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("puts(1)")
+	f.Seek(0, 0)
+
+	stdin := os.Stdin
+	os.Stdin = f
+	defer func() { os.Stdin = stdin }()
+
+	files, _, err := splitFiles(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := readScript(files[0])
+	if err != nil || src != "puts(1)" {
+		t.Errorf("wrong script read from standard input. got=%q, err=%v", src, err)
+	}
+}
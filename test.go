@@ -0,0 +1,226 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/wmolicki/go-monkey/ast"
+	"github.com/wmolicki/go-monkey/evaluator"
+	"github.com/wmolicki/go-monkey/lexer"
+	"github.com/wmolicki/go-monkey/object"
+	"github.com/wmolicki/go-monkey/parser"
+)
+
+// runTest implements `monke test [-v] [-junit file] [paths...]`. It runs
+// functions named test_* in *_test.monke files found in paths, a test
+// fails if it raises an error, e.g. by a failed assert. A file without
+// such functions is a single test.
+func runTest(args []string) int {
+	flags := flag.NewFlagSet("test", flag.ExitOnError)
+	verbose := flags.Bool("v", false, "list all tests, not only failed ones")
+	junit := flags.String("junit", "", "also write results to `file` in the JUnit XML format")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: monke test [-v] [-junit file] [dirs or files...]\n")
+		fmt.Fprintf(flags.Output(), "directories are searched recursively, the current one by default\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	files, err := testFiles(paths)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	if len(files) == 0 {
+		fmt.Fprintln(os.Stderr, "no test files found")
+		return exitError
+	}
+
+	status := exitOK
+	var results []testFileResult
+	for _, filename := range files {
+		result := runTestFile(filename)
+		results = append(results, result)
+		if result.failed() {
+			status = exitError
+		}
+		printTestFileResult(os.Stdout, result, *verbose)
+	}
+
+	if *junit != "" {
+		if err := writeJUnitFile(*junit, results); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+	}
+	return status
+}
+
+// testFiles returns test files in paths in lexical order, files given
+// explicitly are included even if their name doesn't end with _test.monke.
+func testFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.HasSuffix(path, "_test.monke") {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// testResult is the outcome of a single test.
+type testResult struct {
+	name string
+	// failure describes why the test failed, it's empty if it passed
+	failure string
+	elapsed time.Duration
+}
+
+type testFileResult struct {
+	filename string
+	tests    []testResult
+	elapsed  time.Duration
+}
+
+func (r testFileResult) failed() bool {
+	for _, test := range r.tests {
+		if test.failure != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// runTestFile runs tests of a test file. Each test runs in a new
+// environment in which the file is evaluated again, so tests can't
+// affect each other.
+func runTestFile(filename string) (result testFileResult) {
+	start := time.Now()
+	result.filename = filename
+	defer func() { result.elapsed = time.Since(start) }()
+
+	// errors which prevent running any tests fail the file as a whole
+	fileFailure := func(failure string) testFileResult {
+		result.tests = []testResult{{name: filename, failure: failure, elapsed: time.Since(start)}}
+		return result
+	}
+
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return fileFailure(err.Error())
+	}
+	p := parser.New(lexer.New(string(src)))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return fileFailure(strings.Join(p.Errors(), "\n"))
+	}
+
+	macroEnv := object.NewEnvironment()
+	evaluator.DefineMacros(program, macroEnv)
+	expanded, err := evaluator.ExpandMacros(program, macroEnv)
+	if err != nil {
+		return fileFailure("error expanding macros: " + err.Error())
+	}
+
+	names := testNames(program)
+	if len(names) == 0 {
+		return fileFailure(testFailure(evaluator.Eval(expanded, object.NewEnvironment())))
+	}
+
+	for _, name := range names {
+		testStart := time.Now()
+		env := object.NewEnvironment()
+		failure := testFailure(evaluator.Eval(expanded, env))
+		if failure != "" {
+			failure = "error evaluating file: " + failure
+		} else {
+			test, _ := env.Get(name)
+			failure = testFailure(evaluator.Apply(test))
+		}
+		result.tests = append(result.tests, testResult{name: name, failure: failure, elapsed: time.Since(testStart)})
+	}
+	return result
+}
+
+// testNames returns names of tests defined at the top of program, in
+// source order.
+func testNames(program *ast.Program) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, stmt := range program.Statements {
+		let, ok := stmt.(*ast.LetStatement)
+		if !ok || !strings.HasPrefix(let.Name.Value, "test_") || seen[let.Name.Value] {
+			continue
+		}
+		seen[let.Name.Value] = true
+		names = append(names, let.Name.Value)
+	}
+	return names
+}
+
+// testFailure describes the result of a test if it failed, or returns an
+// empty string.
+func testFailure(obj object.Object) string {
+	switch obj := obj.(type) {
+	case *object.Error:
+		lines := []string{obj.ErrorKind() + ": " + obj.Message}
+		for _, frame := range obj.Stack {
+			lines = append(lines, "\tat "+frame)
+		}
+		return strings.Join(lines, "\n")
+	case *evaluator.Exit:
+		if obj.Code != 0 {
+			return fmt.Sprintf("exit status %d", obj.Code)
+		}
+	}
+	return ""
+}
+
+// printTestFileResult writes results of a file like `go test` does,
+// passed tests are listed only if verbose is set.
+func printTestFileResult(out io.Writer, result testFileResult, verbose bool) {
+	for _, test := range result.tests {
+		if test.failure != "" {
+			fmt.Fprintf(out, "--- FAIL: %s (%.2fs)\n", test.name, test.elapsed.Seconds())
+			for _, line := range strings.Split(test.failure, "\n") {
+				fmt.Fprintf(out, "    %s\n", line)
+			}
+		} else if verbose {
+			fmt.Fprintf(out, "--- PASS: %s (%.2fs)\n", test.name, test.elapsed.Seconds())
+		}
+	}
+
+	if result.failed() {
+		fmt.Fprintf(out, "FAIL\t%s\t%.3fs\n", result.filename, result.elapsed.Seconds())
+	} else {
+		fmt.Fprintf(out, "ok\t%s\t%.3fs\n", result.filename, result.elapsed.Seconds())
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/wmolicki/go-monkey/evaluator"
+	"github.com/wmolicki/go-monkey/object"
+)
+
+func TestRunTestFile(t *testing.T) {
+	tests := []struct {
+		filename string
+		// expected are names of tests followed by their failures
+		expected []string
+	}{
+		{"tests_test.monke", []string{
+			// tests don't see changes made by other tests
+			"test_first", "",
+			"test_second", "",
+			"test_fails", "AssertionError: values are not equal:\n[1]: got 2, want 3",
+			"test_nested", "AssertionError: assertion failed: not positive\n\tat check_positive (27:5)",
+			"test_exit_zero", "",
+			"test_exit", "exit status 3",
+		}},
+		// a file without tests is a single test
+		{"top_level_test.monke", []string{
+			"testdata/test/top_level_test.monke", "AssertionError: assertion failed: one is not greater than two",
+		}},
+		{"syntax_error_test.monke", []string{
+			"testdata/test/syntax_error_test.monke",
+			"expected next token to be IDENT, got = instead\nno prefix parse function for '=' found",
+		}},
+		// tests can't run if the file fails
+		{"broken_file_test.monke", []string{
+			"test_never_runs", "error evaluating file: NameError: identifier not found: missing",
+		}},
+		{"missing_test.monke", []string{
+			"testdata/test/missing_test.monke", "open testdata/test/missing_test.monke: no such file or directory",
+		}},
+	}
+
+	for _, tt := range tests {
+		filename := filepath.Join("testdata", "test", tt.filename)
+		result := runTestFile(filename)
+		if result.filename != filename {
+			t.Errorf("%s: wrong filename %q", tt.filename, result.filename)
+		}
+
+		var got []string
+		for _, test := range result.tests {
+			got = append(got, test.name, test.failure)
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: wrong results.\nwant=%q\ngot= %q", tt.filename, tt.expected, got)
+		}
+
+		wantFailed := false
+		for i := 1; i < len(tt.expected); i += 2 {
+			wantFailed = wantFailed || tt.expected[i] != ""
+		}
+		if result.failed() != wantFailed {
+			t.Errorf("%s: wrong failed(). expected=%t, got=%t", tt.filename, wantFailed, result.failed())
+		}
+	}
+}
+
+func TestTestFiles(t *testing.T) {
+	dir := filepath.Join("testdata", "test")
+	files, err := testFiles([]string{dir, "test.monke"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		filepath.Join(dir, "broken_file_test.monke"),
+		filepath.Join(dir, "syntax_error_test.monke"),
+		filepath.Join(dir, "tests_test.monke"),
+		filepath.Join(dir, "top_level_test.monke"),
+		// files given explicitly are included whatever their name
+		"test.monke",
+	}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("wrong files.\nwant=%q\ngot= %q", expected, files)
+	}
+
+	if _, err := testFiles([]string{"missing"}); err == nil {
+		t.Errorf("expected an error for a missing path")
+	}
+}
+
+func TestTestNames(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let test_b = fn() {}; let test_a = fn() {}", []string{"test_b", "test_a"}},
+		// only top-level lets are tests, redefined ones run once
+		{"let f = fn() { let test_inner = fn() {} }; let test_a = 1; let test_a = 2", []string{"test_a"}},
+		{"let testing = 1; let helper = 2; test_a", nil},
+	}
+
+	for _, tt := range tests {
+		program, ok := parse(tt.input)
+		if !ok {
+			t.Fatalf("%q doesn't parse", tt.input)
+		}
+		if got := testNames(program); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%q: wrong names. expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestTestFailure(t *testing.T) {
+	withStack := object.NewError(object.TypeError, "boom")
+	withStack.Stack = []string{"inner (2:3)", "outer (5:1)"}
+
+	tests := []struct {
+		obj      object.Object
+		expected string
+	}{
+		{&object.Integer{Value: 1}, ""},
+		{nil, ""},
+		{&evaluator.Exit{Code: 0}, ""},
+		{&evaluator.Exit{Code: 2}, "exit status 2"},
+		{&object.Error{Message: "boom"}, "Error: boom"},
+		{withStack, "TypeError: boom\n\tat inner (2:3)\n\tat outer (5:1)"},
+		// error values are results, not failures
+		{&object.ErrorValue{Err: withStack}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := testFailure(tt.obj); got != tt.expected {
+			t.Errorf("%v: wrong failure. expected=%q, got=%q", tt.obj, tt.expected, got)
+		}
+	}
+}
+
+func TestWriteJUnitFile(t *testing.T) {
+	results := []testFileResult{
+		{
+			filename: "a_test.monke",
+			elapsed:  1500 * time.Millisecond,
+			tests: []testResult{
+				{name: "test_ok", elapsed: 500 * time.Millisecond},
+				{name: "test_fails", failure: "AssertionError: values are not equal:\n<got 1, want 2>", elapsed: time.Second},
+			},
+		},
+		{
+			filename: "b_test.monke",
+			elapsed:  250 * time.Millisecond,
+			tests:    []testResult{{name: "b_test.monke", elapsed: 250 * time.Millisecond}},
+		},
+	}
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="3" failures="1" time="1.750">
+  <testsuite name="a_test.monke" tests="2" failures="1" time="1.500">
+    <testcase name="test_ok" classname="a_test.monke" time="0.500"></testcase>
+    <testcase name="test_fails" classname="a_test.monke" time="1.000">
+      <failure message="AssertionError: values are not equal:">AssertionError: values are not equal:&#xA;&lt;got 1, want 2&gt;</failure>
+    </testcase>
+  </testsuite>
+  <testsuite name="b_test.monke" tests="1" failures="0" time="0.250">
+    <testcase name="b_test.monke" classname="b_test.monke" time="0.250"></testcase>
+  </testsuite>
+</testsuites>
+`
+
+	filename := filepath.Join(t.TempDir(), "report.xml")
+	if err := writeJUnitFile(filename, results); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != expected {
+		t.Errorf("wrong report.\nwant=%s\ngot= %s", expected, got)
+	}
+
+	if err := writeJUnitFile(filepath.Join(t.TempDir(), "missing", "report.xml"), results); err == nil {
+		t.Errorf("expected an error writing to a missing directory")
+	}
+}
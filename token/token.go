@@ -1,10 +1,40 @@
 package token
 
+import (
+	"fmt"
+	"sort"
+)
+
 type TokenType string
 
 type Token struct {
 	Type    TokenType
 	Literal string
+	Line    int // 1-based line of the first character, 0 if unknown
+	Column  int // 1-based column of the first character, 0 if unknown
+}
+
+// Pos returns the position the token starts at.
+func (t Token) Pos() Position {
+	return Position{Line: t.Line, Column: t.Column}
+}
+
+// Position is a location in the source, Line and Column are 1-based.
+type Position struct {
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Before reports whether p comes before other in the source.
+func (p Position) Before(other Position) bool {
+	if p.Line != other.Line {
+		return p.Line < other.Line
+	}
+	return p.Column < other.Column
 }
 
 const (
@@ -15,6 +45,10 @@ const (
 	INT    = "INT"
 	STRING = "STRING"
 
+	// COMMENT tokens are not returned by the lexer's NextToken,
+	// they are collected separately, see lexer.Lexer.Comments.
+	COMMENT = "COMMENT"
+
 	// operators
 	ASSIGN   = "="
 	PLUS     = "+"
@@ -28,6 +62,10 @@ const (
 	LT = "<"
 	GT = ">"
 
+	FAT_ARROW = "=>"
+	ELLIPSIS  = "..."
+	DOT       = "."
+
 	COMMA     = ","
 	SEMICOLON = ";"
 	COLON     = ":"
@@ -39,6 +77,8 @@ const (
 	LBRACKET = "["
 	RBRACKET = "]"
 
+	HASH_LBRACE = "#{"
+
 	// keywords
 	FUNCTION = "FUNCTION"
 	LET      = "LET"
@@ -48,17 +88,47 @@ const (
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
 	FOR      = "FOR"
+	MACRO    = "MACRO"
+	MATCH    = "MATCH"
+	THROW    = "THROW"
+	TRY      = "TRY"
+	CATCH    = "CATCH"
+	FINALLY  = "FINALLY"
+	STRUCT   = "STRUCT"
+	YIELD    = "YIELD"
+	SPAWN    = "SPAWN"
+	SELECT   = "SELECT"
 )
 
 var keywords = map[string]TokenType{
-	"fn":     FUNCTION,
-	"let":    LET,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
-	"for":    FOR,
+	"fn":      FUNCTION,
+	"let":     LET,
+	"true":    TRUE,
+	"false":   FALSE,
+	"if":      IF,
+	"else":    ELSE,
+	"return":  RETURN,
+	"for":     FOR,
+	"macro":   MACRO,
+	"match":   MATCH,
+	"throw":   THROW,
+	"try":     TRY,
+	"catch":   CATCH,
+	"finally": FINALLY,
+	"struct":  STRUCT,
+	"yield":   YIELD,
+	"spawn":   SPAWN,
+	"select":  SELECT,
+}
+
+// Keywords returns all keywords of the language in sorted order.
+func Keywords() []string {
+	names := make([]string, 0, len(keywords))
+	for name := range keywords {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 func LookupIdentifier(ident string) TokenType {
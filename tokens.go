@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/wmolicki/go-monkey/lexer"
+	"github.com/wmolicki/go-monkey/token"
+)
+
+// runTokens implements `monke tokens (-e expr | file.monke)`, it prints
+// a token per line with its position, type and literal.
+func runTokens(args []string) int {
+	fs := flag.NewFlagSet("tokens", flag.ExitOnError)
+	expr := fs.String("e", "", "print tokens of expr instead of a file")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: monke tokens (-e expr | file.monke)\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	_, src, err := programSource(*expr, fs.Args())
+	if err == errUsage {
+		fs.Usage()
+		return exitUsage
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	status := exitOK
+	l := lexer.New(src)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		fmt.Printf("%s\t%s\t%q\n", tok.Pos(), tok.Type, tok.Literal)
+		if tok.Type == token.ILLEGAL {
+			status = exitError
+		}
+	}
+	return status
+}
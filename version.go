@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// version is set when building releases, e.g.
+// go build -ldflags "-X main.version=v1.2.3".
+var version = "dev"
+
+// runVersion implements `monke version`.
+func runVersion(args []string) int {
+	fs := flag.NewFlagSet("version", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: monke version\n")
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	v := version
+	if info, ok := debug.ReadBuildInfo(); ok && v == "dev" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		v = info.Main.Version
+	}
+	fmt.Printf("monke %s %s/%s %s\n", v, runtime.GOOS, runtime.GOARCH, runtime.Version())
+	return exitOK
+}